@@ -10,9 +10,12 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 )
 
 var port uint16 = 50051
@@ -60,6 +63,12 @@ func TestServiceClient_Create(t *testing.T) {
 			},
 			err: status.Error(codes.Internal, "failed to send activity"),
 		},
+		"blocked": {
+			req: &CreateRequest{
+				Addr: "blocked",
+			},
+			err: status.Error(codes.PermissionDenied, "source has blocked the instance"),
+		},
 	}
 	//
 	for k, c := range cases {
@@ -216,3 +225,233 @@ func TestServiceClient_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceClient_RotateKey(t *testing.T) {
+	//
+	addr := fmt.Sprintf("localhost:%d", port)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	client := NewServiceClient(conn)
+	//
+	cases := map[string]struct {
+		req *RotateKeyRequest
+		id  string
+		err error
+	}{
+		"ok": {
+			req: &RotateKeyRequest{
+				GracePeriod: durationpb.New(24 * time.Hour),
+			},
+			id: "https://test.social/actor#key-next",
+		},
+		"invalid": {
+			req: &RotateKeyRequest{
+				GracePeriod: durationpb.New(-1 * time.Hour),
+			},
+			err: status.Error(codes.InvalidArgument, "invalid argument"),
+		},
+	}
+	//
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			resp, err := client.RotateKey(context.TODO(), c.req)
+			if c.err == nil {
+				assert.Equal(t, c.id, resp.Key.Id)
+			}
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestServiceClient_RetireKey(t *testing.T) {
+	//
+	addr := fmt.Sprintf("localhost:%d", port)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	client := NewServiceClient(conn)
+	//
+	cases := map[string]struct {
+		req *RetireKeyRequest
+		err error
+	}{
+		"ok": {
+			req: &RetireKeyRequest{
+				Id: "https://test.social/actor#key-prev",
+			},
+		},
+		"active": {
+			req: &RetireKeyRequest{
+				Id: "https://test.social/actor#main-key",
+			},
+			err: status.Error(codes.InvalidArgument, "invalid argument"),
+		},
+		"missing": {
+			req: &RetireKeyRequest{
+				Id: "missing",
+			},
+			err: status.Error(codes.NotFound, "key not found"),
+		},
+	}
+	//
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			_, err := client.RetireKey(context.TODO(), c.req)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestServiceClient_ListAudit(t *testing.T) {
+	//
+	addr := fmt.Sprintf("localhost:%d", port)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	client := NewServiceClient(conn)
+	//
+	cases := map[string]struct {
+		req  *ListAuditRequest
+		kind string
+		err  error
+	}{
+		"ok": {
+			req: &ListAuditRequest{
+				Filter: &AuditFilter{
+					ActorId: "https://host.social/users/johndoe",
+					Kinds:   []string{"drop"},
+					Since:   timestamppb.New(time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)),
+				},
+				Limit: 10,
+				Order: Order_DESC,
+			},
+			kind: "drop",
+		},
+		"fail": {
+			req: &ListAuditRequest{
+				Filter: &AuditFilter{
+					ActorId: "fail",
+				},
+			},
+			err: status.Error(codes.Internal, "audit storage internal failure"),
+		},
+	}
+	//
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			resp, err := client.ListAudit(context.TODO(), c.req)
+			if c.err == nil {
+				require.Len(t, resp.Page, 1)
+				assert.Equal(t, c.kind, resp.Page[0].Kind)
+				assert.Equal(t, c.req.Filter.ActorId, resp.Page[0].ActorId)
+			}
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestServiceClient_ListReports(t *testing.T) {
+	//
+	addr := fmt.Sprintf("localhost:%d", port)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	client := NewServiceClient(conn)
+	//
+	cases := map[string]struct {
+		req *ListReportsRequest
+		err error
+	}{
+		"ok": {
+			req: &ListReportsRequest{
+				Filter: &ReportFilter{
+					InterestId: "interest0",
+					Unresolved: true,
+				},
+				Limit: 10,
+			},
+		},
+		"fail": {
+			req: &ListReportsRequest{
+				Filter: &ReportFilter{
+					InterestId: "fail",
+				},
+			},
+			err: status.Error(codes.Internal, "moderation storage internal failure"),
+		},
+	}
+	//
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			resp, err := client.ListReports(context.TODO(), c.req)
+			if c.err == nil {
+				require.Len(t, resp.Page, 1)
+				assert.Equal(t, c.req.Filter.InterestId, resp.Page[0].InterestId)
+				assert.Equal(t, []string{"https://test.social/note1"}, resp.Page[0].Objects)
+				assert.Equal(t, "spam", resp.Page[0].Content)
+			}
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestServiceClient_ResolveReport(t *testing.T) {
+	//
+	addr := fmt.Sprintf("localhost:%d", port)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	client := NewServiceClient(conn)
+	//
+	cases := map[string]struct {
+		req     *ResolveReportRequest
+		deleted uint32
+		err     error
+	}{
+		"suspend": {
+			req: &ResolveReportRequest{
+				Id:     "report0",
+				Action: ReportAction_SUSPEND,
+			},
+		},
+		"delete notes": {
+			req: &ResolveReportRequest{
+				Id:     "report0",
+				Action: ReportAction_DELETE_NOTES,
+			},
+			deleted: 2,
+		},
+		"unknown action": {
+			req: &ResolveReportRequest{
+				Id:     "report0",
+				Action: 42,
+			},
+			err: status.Error(codes.InvalidArgument, "unknown report action: 42"),
+		},
+		"invalid": {
+			req: &ResolveReportRequest{
+				Id:     "invalid",
+				Action: ReportAction_SUSPEND,
+			},
+			err: status.Error(codes.InvalidArgument, "invalid moderation request"),
+		},
+		"missing": {
+			req: &ResolveReportRequest{
+				Id: "missing",
+			},
+			err: status.Error(codes.NotFound, "report not found"),
+		},
+		"fail": {
+			req: &ResolveReportRequest{
+				Id: "fail",
+			},
+			err: status.Error(codes.Internal, "moderation storage internal failure"),
+		},
+	}
+	//
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			resp, err := client.ResolveReport(context.TODO(), c.req)
+			if c.err == nil {
+				assert.Equal(t, c.deleted, resp.Deleted)
+			}
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
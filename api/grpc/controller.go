@@ -3,10 +3,16 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/awakari/int-activitypub/model"
 	"github.com/awakari/int-activitypub/service"
 	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/service/keys"
+	"github.com/awakari/int-activitypub/service/moderation"
 	"github.com/awakari/int-activitypub/storage"
+	storageAudit "github.com/awakari/int-activitypub/storage/audit"
+	storageKeys "github.com/awakari/int-activitypub/storage/keys"
+	storageModeration "github.com/awakari/int-activitypub/storage/moderation"
 	vocab "github.com/go-ap/activitypub"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -82,6 +88,171 @@ func (c controller) ListUrls(ctx context.Context, req *ListUrlsRequest) (resp *L
 	return
 }
 
+func (c controller) RotateKey(ctx context.Context, req *RotateKeyRequest) (resp *RotateKeyResponse, err error) {
+	resp = &RotateKeyResponse{}
+	var k model.Key
+	k, err = c.svc.RotateKey(ctx, req.GracePeriod.AsDuration())
+	switch err {
+	case nil:
+		resp.Key = encodeKey(k)
+	default:
+		err = encodeError(err)
+	}
+	return
+}
+
+func (c controller) RetireKey(ctx context.Context, req *RetireKeyRequest) (resp *RetireKeyResponse, err error) {
+	resp = &RetireKeyResponse{}
+	err = c.svc.RetireKey(ctx, req.Id)
+	err = encodeError(err)
+	return
+}
+
+func (c controller) ListKeys(ctx context.Context, req *ListKeysRequest) (resp *ListKeysResponse, err error) {
+	resp = &ListKeysResponse{}
+	ks, err := c.svc.ListKeys(ctx)
+	switch err {
+	case nil:
+		for _, k := range ks {
+			resp.Keys = append(resp.Keys, encodeKey(k))
+		}
+	default:
+		err = encodeError(err)
+	}
+	return
+}
+
+func (c controller) ListAudit(ctx context.Context, req *ListAuditRequest) (resp *ListAuditResponse, err error) {
+	resp = &ListAuditResponse{}
+	var filter model.AuditFilter
+	reqFilter := req.Filter
+	if reqFilter != nil {
+		filter.ActorId = reqFilter.ActorId
+		filter.GroupId = reqFilter.GroupId
+		filter.UserId = reqFilter.UserId
+		filter.SubId = reqFilter.SubId
+		for _, kind := range reqFilter.Kinds {
+			filter.Kinds = append(filter.Kinds, model.AuditKind(kind))
+		}
+		if reqFilter.Since != nil {
+			filter.Since = reqFilter.Since.AsTime()
+		}
+		if reqFilter.Until != nil {
+			filter.Until = reqFilter.Until.AsTime()
+		}
+	}
+	var order model.Order
+	switch req.Order {
+	case Order_DESC:
+		order = model.OrderDesc
+	default:
+		order = model.OrderAsc
+	}
+	page, err := c.svc.ListAudit(ctx, filter, req.Limit, req.Cursor, order)
+	switch err {
+	case nil:
+		for _, evt := range page {
+			resp.Page = append(resp.Page, encodeAuditEvent(evt))
+		}
+	default:
+		err = encodeError(err)
+	}
+	return
+}
+
+func (c controller) ListReports(ctx context.Context, req *ListReportsRequest) (resp *ListReportsResponse, err error) {
+	resp = &ListReportsResponse{}
+	var filter model.ReportFilter
+	if req.Filter != nil {
+		filter.InterestId = req.Filter.InterestId
+		filter.ReporterId = req.Filter.ReporterId
+		filter.Unresolved = req.Filter.Unresolved
+	}
+	page, err := c.svc.ListReports(ctx, filter, req.Limit, req.Cursor)
+	switch err {
+	case nil:
+		for _, r := range page {
+			resp.Page = append(resp.Page, encodeReport(r))
+		}
+	default:
+		err = encodeError(err)
+	}
+	return
+}
+
+func (c controller) ResolveReport(ctx context.Context, req *ResolveReportRequest) (resp *ResolveReportResponse, err error) {
+	resp = &ResolveReportResponse{}
+	var action model.ReportAction
+	switch req.Action {
+	case ReportAction_DISMISS:
+		action = model.ReportActionDismiss
+	case ReportAction_SUSPEND:
+		action = model.ReportActionSuspend
+	case ReportAction_REINSTATE:
+		action = model.ReportActionReinstate
+	case ReportAction_DELETE_NOTES:
+		action = model.ReportActionDeleteNotes
+	default:
+		err = status.Error(codes.InvalidArgument, fmt.Sprintf("unknown report action: %s", req.Action))
+	}
+	if err == nil {
+		resp.Deleted, err = c.svc.ResolveReport(ctx, req.Id, action, req.NoteIds)
+		err = encodeError(err)
+	}
+	return
+}
+
+func encodeReport(r model.Report) (dst *Report) {
+	dst = &Report{
+		Id:         r.Id,
+		ActivityId: r.ActivityId,
+		ReporterId: r.ReporterId,
+		InterestId: r.InterestId,
+		Objects:    r.Objects,
+		Content:    r.Content,
+		Resolution: string(r.Resolution),
+	}
+	if !r.Created.IsZero() {
+		dst.Created = timestamppb.New(r.Created)
+	}
+	if !r.Resolved.IsZero() {
+		dst.Resolved = timestamppb.New(r.Resolved)
+	}
+	return
+}
+
+func encodeAuditEvent(evt model.AuditEvent) (dst *AuditEvent) {
+	dst = &AuditEvent{
+		Id:         evt.Id,
+		Time:       timestamppb.New(evt.Time),
+		Kind:       string(evt.Kind),
+		ActorId:    evt.ActorId,
+		ActivityId: evt.ActivityId,
+		GroupId:    evt.GroupId,
+		UserId:     evt.UserId,
+		SubId:      evt.SubId,
+		Reason:     evt.Reason,
+	}
+	return
+}
+
+func encodeKey(k model.Key) (dst *Key) {
+	dst = &Key{
+		Id:     k.Id,
+		Public: k.Public,
+	}
+	if !k.Created.IsZero() {
+		dst.Created = timestamppb.New(k.Created)
+	}
+	if !k.Activated.IsZero() {
+		dst.Activated = timestamppb.New(k.Activated)
+	}
+	if !k.Deprecated.IsZero() {
+		dst.Deprecated = timestamppb.New(k.Deprecated)
+	}
+	return
+}
+
 func encodeSource(src model.Source) (dst *Source) {
 	dst = &Source{
 		ActorId:  src.ActorId,
@@ -92,6 +263,7 @@ func encodeSource(src model.Source) (dst *Source) {
 		Summary:  src.Summary,
 		Accepted: src.Accepted,
 		Rejected: src.Rejected,
+		Blocked:  src.Blocked,
 		SubId:    src.SubId,
 		Term:     src.Term,
 	}
@@ -107,15 +279,17 @@ func encodeSource(src model.Source) (dst *Source) {
 func encodeError(src error) (dst error) {
 	switch {
 	case src == nil:
-	case errors.Is(src, storage.ErrConflict):
+	case errors.Is(src, storage.ErrConflict), errors.Is(src, storageKeys.ErrConflict), errors.Is(src, storageModeration.ErrConflict):
 		dst = status.Error(codes.AlreadyExists, src.Error())
-	case errors.Is(src, storage.ErrNotFound):
+	case errors.Is(src, storage.ErrNotFound), errors.Is(src, storageKeys.ErrNotFound), errors.Is(src, storageModeration.ErrNotFound):
 		dst = status.Error(codes.NotFound, src.Error())
-	case errors.Is(src, storage.ErrInternal), errors.Is(src, activitypub.ErrActivitySend):
+	case errors.Is(src, storage.ErrInternal), errors.Is(src, activitypub.ErrActivitySend),
+		errors.Is(src, storageKeys.ErrInternal), errors.Is(src, keys.ErrGenerate), errors.Is(src, storageAudit.ErrInternal),
+		errors.Is(src, storageModeration.ErrInternal):
 		dst = status.Error(codes.Internal, src.Error())
-	case errors.Is(src, service.ErrInvalid), errors.Is(src, storage.ErrInternal):
+	case errors.Is(src, service.ErrInvalid), errors.Is(src, keys.ErrInvalid), errors.Is(src, moderation.ErrInvalid):
 		dst = status.Error(codes.InvalidArgument, src.Error())
-	case errors.Is(src, service.ErrNoBot):
+	case errors.Is(src, service.ErrNoBot), errors.Is(src, service.ErrBlocked):
 		dst = status.Error(codes.PermissionDenied, src.Error())
 	case errors.Is(src, context.DeadlineExceeded):
 		dst = status.Error(codes.DeadlineExceeded, src.Error())
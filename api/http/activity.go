@@ -66,6 +66,27 @@ var contextExtMastodon = map[string]any{
 	},
 }
 
+// contextExtToot defines the Mastodon attachment and poll properties, added to the activities having these.
+var contextExtToot = map[string]any{
+	"toot":     "http://joinmastodon.org/ns#",
+	"blurhash": "toot:blurhash",
+	"focalPoint": map[string]any{
+		"@container": "@list",
+		"@id":        "toot:focalPoint",
+	},
+	"votersCount": "toot:votersCount",
+}
+
+// contextExtEvent defines the Mobilizon event properties, added to the activities having the Event object.
+var contextExtEvent = map[string]any{
+	"mz":               "https://joinmobilizon.org/ns#",
+	"sc":               "http://schema.org#",
+	"timezone":         "mz:timezone",
+	"joinMode":         "mz:joinMode",
+	"participantCount": "mz:participantCount",
+	"address":          "sc:address",
+}
+
 func FixContext(obj vocab.ActivityObject) (m map[string]any, checkSum uint32) {
 	d, _ := sonic.Marshal(obj)
 	checkSum = crc32.ChecksumIEEE(d)
@@ -75,6 +96,19 @@ func FixContext(obj vocab.ActivityObject) (m map[string]any, checkSum uint32) {
 	switch obj.(type) {
 	case vocab.Actor:
 		c = append(c.([]any), contextExtMastodon)
+	case vocab.Activity:
+		if ok {
+			exts := []any{c}
+			if hasTootExt(m["object"]) {
+				exts = append(exts, contextExtToot)
+			}
+			if hasEventExt(m["object"]) {
+				exts = append(exts, contextExtEvent)
+			}
+			if len(exts) > 1 {
+				c = exts
+			}
+		}
 	}
 	if ok {
 		m["@context"] = c
@@ -82,3 +116,47 @@ func FixContext(obj vocab.ActivityObject) (m map[string]any, checkSum uint32) {
 	}
 	return
 }
+
+// FixObjectContext sets the JSON-LD context of the standalone object, e.g. served by its id.
+func FixObjectContext(m map[string]any) {
+	c := []any{
+		"https://www.w3.org/ns/activitystreams",
+	}
+	if hasTootExt(m) {
+		c = append(c, contextExtToot)
+	}
+	if hasEventExt(m) {
+		c = append(c, contextExtEvent)
+	}
+	m["@context"] = c
+	delete(m, "context")
+}
+
+func hasTootExt(obj any) (found bool) {
+	o, _ := obj.(map[string]any)
+	if _, found = o["votersCount"]; found {
+		return
+	}
+	var atts []any
+	switch att := o["attachment"].(type) {
+	case []any:
+		atts = att
+	case map[string]any:
+		atts = []any{att}
+	}
+	for _, att := range atts {
+		a, _ := att.(map[string]any)
+		_, bh := a["blurhash"]
+		_, fp := a["focalPoint"]
+		if bh || fp {
+			found = true
+			break
+		}
+	}
+	return
+}
+
+func hasEventExt(obj any) bool {
+	o, _ := obj.(map[string]any)
+	return o["type"] == string(vocab.EventType)
+}
@@ -2,10 +2,12 @@ package http
 
 import (
 	"fmt"
+	"github.com/awakari/int-activitypub/model"
 	"github.com/bytedance/sonic"
 	vocab "github.com/go-ap/activitypub"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 const host = "host.social"
@@ -61,3 +63,96 @@ func Test_FixContext(t *testing.T) {
 	)
 	assert.Equal(t, uint32(2542952543), cs)
 }
+
+func Test_FixContext_Media(t *testing.T) {
+	cases := map[string]struct {
+		att  vocab.Item
+		ctxt any
+	}{
+		"plain": {
+			att: vocab.Object{
+				Type: vocab.ImageType,
+				URL:  vocab.IRI("https://host/img.png"),
+			},
+			ctxt: "https://www.w3.org/ns/activitystreams",
+		},
+		"blurhash": {
+			att: model.Media{
+				Object: vocab.Object{
+					Type: vocab.ImageType,
+					URL:  vocab.IRI("https://host/img.png"),
+				},
+				Blurhash: "L00000fQfQfQfQfQfQfQfQfQfQfQ",
+			},
+			ctxt: []any{
+				"https://www.w3.org/ns/activitystreams",
+				map[string]any{
+					"toot":     "http://joinmastodon.org/ns#",
+					"blurhash": "toot:blurhash",
+					"focalPoint": map[string]any{
+						"@container": "@list",
+						"@id":        "toot:focalPoint",
+					},
+					"votersCount": "toot:votersCount",
+				},
+			},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			a := vocab.Activity{
+				ID:      vocab.ID(fmt.Sprintf("https://%s/activity1", host)),
+				Type:    vocab.CreateType,
+				Context: vocab.IRI("https://www.w3.org/ns/activitystreams"),
+				Object: &vocab.Object{
+					Type:       vocab.NoteType,
+					Attachment: vocab.ItemCollection{c.att},
+				},
+			}
+			m, _ := FixContext(a)
+			assert.Equal(t, c.ctxt, m["@context"])
+		})
+	}
+}
+
+func Test_FixContext_Poll(t *testing.T) {
+	a := vocab.Activity{
+		ID:      vocab.ID(fmt.Sprintf("https://%s/activity1", host)),
+		Type:    vocab.CreateType,
+		Context: vocab.IRI("https://www.w3.org/ns/activitystreams"),
+		Object: model.Poll{
+			Object: vocab.Object{
+				ID: vocab.ID(fmt.Sprintf("https://%s/poll/1", host)),
+			},
+			Options: []model.PollOption{
+				{Name: "yes", Votes: 1},
+			},
+			Voters: 1,
+		},
+	}
+	m, _ := FixContext(a)
+	assert.Equal(t, []any{"https://www.w3.org/ns/activitystreams", contextExtToot}, m["@context"])
+	obj, _ := m["object"].(map[string]any)
+	assert.Equal(t, "Question", obj["type"])
+	assert.Len(t, obj["oneOf"], 1)
+}
+
+func Test_FixContext_Event(t *testing.T) {
+	a := vocab.Activity{
+		ID:      vocab.ID(fmt.Sprintf("https://%s/activity1", host)),
+		Type:    vocab.CreateType,
+		Context: vocab.IRI("https://www.w3.org/ns/activitystreams"),
+		Object: model.Event{
+			Object: vocab.Object{
+				ID:        vocab.ID(fmt.Sprintf("https://%s/evt1", host)),
+				StartTime: time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC),
+			},
+			Timezone: "Europe/Paris",
+		},
+	}
+	m, _ := FixContext(a)
+	assert.Equal(t, []any{"https://www.w3.org/ns/activitystreams", contextExtEvent}, m["@context"])
+	obj, _ := m["object"].(map[string]any)
+	assert.Equal(t, "Event", obj["type"])
+	assert.Equal(t, "Europe/Paris", obj["timezone"])
+}
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/config"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type transport struct {
+	rt        http.RoundTripper
+	allowHttp map[string]bool
+}
+
+var ErrForbiddenScheme = errors.New("forbidden URL scheme")
+var ErrForbiddenAddress = errors.New("forbidden destination address")
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// blockedPrefixes complement the net/netip classification methods used in isBlockedAddr.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, may embed any IPv4 address
+}
+
+// NewClient returns the HTTP client for the outgoing requests to any remote party.
+// Only https is allowed, except the hosts from the configured allowHttp list, e.g. the trusted relays.
+// The remote address is checked after the DNS resolution and the connection to any private, loopback or link-local
+// address is refused unless the host is in the allowHttp list.
+// The internal services should be requested using the NewClientInternal instead.
+func NewClient(cfg config.HttpClientConfig) (c *http.Client) {
+	allowed := make(map[string]bool)
+	for _, h := range cfg.AllowHttp {
+		if h != "" {
+			allowed[strings.ToLower(h)] = true
+		}
+	}
+	dialer := &net.Dialer{
+		Timeout:   cfg.Timeout.Dial,
+		KeepAlive: 30 * time.Second,
+	}
+	dialerSafe := &net.Dialer{
+		Timeout:   cfg.Timeout.Dial,
+		KeepAlive: 30 * time.Second,
+		Control:   controlAddr,
+	}
+	rt := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (conn net.Conn, err error) {
+			host, _, _ := net.SplitHostPort(addr)
+			switch allowed[strings.ToLower(host)] {
+			case true:
+				conn, err = dialer.DialContext(ctx, network, addr)
+			default:
+				conn, err = dialerSafe.DialContext(ctx, network, addr)
+			}
+			return
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   cfg.ConnsPerHost,
+		MaxConnsPerHost:       cfg.ConnsPerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   cfg.Timeout.Dial,
+		ResponseHeaderTimeout: cfg.Timeout.Header,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	c = &http.Client{
+		Transport: transport{
+			rt:        rt,
+			allowHttp: allowed,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) (err error) {
+			if len(via) > cfg.RedirectsMax {
+				err = fmt.Errorf("%w: %d, last: %s", ErrTooManyRedirects, len(via), req.URL)
+			}
+			return
+		},
+		Timeout: cfg.Timeout.Total,
+	}
+	return
+}
+
+// NewClientInternal returns the HTTP client for the requests to the internal services only, never to a remote party.
+// Neither the scheme nor the destination address is restricted.
+func NewClientInternal(cfg config.HttpClientInternalConfig) (c *http.Client) {
+	c = &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   cfg.Timeout.Dial,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   cfg.ConnsPerHost,
+			IdleConnTimeout:       90 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		Timeout: cfg.Timeout.Total,
+	}
+	return
+}
+
+// RoundTrip checks the URL scheme of every request including the redirected ones.
+func (t transport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	switch req.URL.Scheme {
+	case "https":
+	case "http":
+		if !t.allowHttp[strings.ToLower(req.URL.Hostname())] {
+			err = fmt.Errorf("%w: %s", ErrForbiddenScheme, req.URL)
+		}
+	default:
+		err = fmt.Errorf("%w: %s", ErrForbiddenScheme, req.URL)
+	}
+	if err == nil {
+		resp, err = t.rt.RoundTrip(req)
+	}
+	return
+}
+
+// controlAddr is invoked with the resolved address right before the connection is established.
+func controlAddr(network, address string, _ syscall.RawConn) (err error) {
+	var addrPort netip.AddrPort
+	addrPort, err = netip.ParseAddrPort(address)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	case isBlockedAddr(addrPort.Addr()):
+		err = fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	return
+}
+
+func isBlockedAddr(addr netip.Addr) (blocked bool) {
+	addr = addr.Unmap()
+	blocked = !addr.IsValid() ||
+		addr.IsUnspecified() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast()
+	for _, p := range blockedPrefixes {
+		if blocked {
+			break
+		}
+		blocked = p.Contains(addr)
+	}
+	return
+}
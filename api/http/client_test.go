@@ -0,0 +1,145 @@
+package http
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsBlockedAddr(t *testing.T) {
+	cases := map[string]bool{
+		"1.1.1.1":              false,
+		"2606:4700::1111":      false,
+		"127.0.0.1":            true,
+		"::1":                  true,
+		"10.1.2.3":             true,
+		"172.16.0.1":           true,
+		"192.168.1.1":          true,
+		"169.254.169.254":      true,
+		"fe80::1":              true,
+		"fc00::1":              true,
+		"0.0.0.0":              true,
+		"100.64.0.1":           true,
+		"224.0.0.1":            true,
+		"::ffff:127.0.0.1":     true,
+		"::ffff:8.8.8.8":       false,
+		"64:ff9b::a9fe:a9fe":   true,
+		"198.18.0.1":           true,
+		"2001:db8::1":          false,
+		"192.0.0.8":            true,
+		"::":                   true,
+		"ff02::1":              true,
+		"fd12:3456:789a:1::1":  true,
+		"203.0.113.10":         false,
+		"8.8.4.4":              false,
+		"::ffff:169.254.1.1":   true,
+		"::ffff:192.168.0.100": true,
+	}
+	for addr, blocked := range cases {
+		t.Run(addr, func(t *testing.T) {
+			assert.Equal(t, blocked, isBlockedAddr(netip.MustParseAddr(addr)))
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/elsewhere":
+			http.Redirect(w, r, "ftp://example.com/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer srv.Close()
+	srvTls := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srvTls.Close()
+	var cfg config.HttpClientConfig
+	cfg.ConnsPerHost = 2
+	cfg.RedirectsMax = 3
+	cfg.Timeout.Dial = 1 * time.Second
+	cfg.Timeout.Header = 1 * time.Second
+	cfg.Timeout.Total = 3 * time.Second
+	cases := map[string]struct {
+		allowHttp []string
+		url       string
+		err       error
+	}{
+		"allowed internal http": {
+			allowHttp: []string{"127.0.0.1"},
+			url:       srv.URL,
+		},
+		"http is forbidden": {
+			url: srv.URL,
+			err: ErrForbiddenScheme,
+		},
+		"https to loopback is forbidden": {
+			url: srvTls.URL,
+			err: ErrForbiddenAddress,
+		},
+		"resolves to loopback": {
+			url: "https://localhost:1/",
+			err: ErrForbiddenAddress,
+		},
+		"metadata address": {
+			url: "https://169.254.169.254/latest/meta-data/",
+			err: ErrForbiddenAddress,
+		},
+		"unsupported scheme": {
+			url: "file:///etc/passwd",
+			err: ErrForbiddenScheme,
+		},
+		"redirect loop": {
+			allowHttp: []string{"127.0.0.1"},
+			url:       srv.URL + "/loop",
+			err:       ErrTooManyRedirects,
+		},
+		"redirect to forbidden scheme": {
+			allowHttp: []string{"127.0.0.1"},
+			url:       srv.URL + "/elsewhere",
+			err:       ErrForbiddenScheme,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			cfg.AllowHttp = c.allowHttp
+			client := NewClient(cfg)
+			req, _ := http.NewRequestWithContext(context.TODO(), http.MethodGet, c.url, nil)
+			resp, err := client.Do(req)
+			assert.ErrorIs(t, err, c.err)
+			if err == nil {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				_ = resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestNewClientInternal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	var cfg config.HttpClientInternalConfig
+	cfg.ConnsPerHost = 2
+	cfg.Timeout.Dial = 1 * time.Second
+	cfg.Timeout.Total = 3 * time.Second
+	client := NewClientInternal(cfg)
+	resp, err := client.Get(srv.URL)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+	// the remote client never reaches the internal service
+	_, err = NewClient(config.HttpClientConfig{}).Get(srv.URL)
+	assert.ErrorIs(t, err, ErrForbiddenScheme)
+}
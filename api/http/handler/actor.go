@@ -7,17 +7,20 @@ import (
 	"github.com/awakari/int-activitypub/api/http/interests"
 	"github.com/awakari/int-activitypub/config"
 	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/model/interest"
+	"github.com/awakari/int-activitypub/service/keys"
 	"github.com/gin-gonic/gin"
 	vocab "github.com/go-ap/activitypub"
+	"hash/crc32"
 	"net/http"
+	"time"
 )
 
 type actorHandler struct {
 	actorDefault             vocab.Actor
-	actorDefaultFixed        map[string]any
-	actorDefaultChecksum     uint32
 	extraAttrs               map[string]any
 	svcInterests             interests.Service
+	svcKeys                  keys.Service
 	urlPrefixInterestDetails string
 	cfgApi                   config.ApiConfig
 }
@@ -26,19 +29,15 @@ func NewActorHandler(
 	actorDefault vocab.Actor,
 	extraAttrs map[string]any,
 	svcInterests interests.Service,
+	svcKeys keys.Service,
 	urlPrefixInterestDetails string,
 	cfgApi config.ApiConfig,
 ) (h Handler) {
-	aFixed, aCheckSum := apiHttp.FixContext(actorDefault)
-	for k, v := range extraAttrs {
-		aFixed[k] = v
-	}
 	h = actorHandler{
 		actorDefault:             actorDefault,
-		actorDefaultFixed:        aFixed,
-		actorDefaultChecksum:     aCheckSum,
 		extraAttrs:               extraAttrs,
 		svcInterests:             svcInterests,
+		svcKeys:                  svcKeys,
 		urlPrefixInterestDetails: urlPrefixInterestDetails,
 		cfgApi:                   cfgApi,
 	}
@@ -87,9 +86,47 @@ func (ah actorHandler) handleDefault(ctx *gin.Context, accept string) {
 	</p>
 </body>`)
 	default:
-		ctx.Writer.Header().Set("content-type", apiHttp.ContentTypeActivity)
-		ctx.Writer.Header().Set("etag", fmt.Sprintf("W/\"%x\"", ah.actorDefaultChecksum))
-		ctx.JSON(http.StatusOK, ah.actorDefaultFixed)
+		ks, err := ah.svcKeys.Instance(ctx)
+		if err == nil && len(ks) == 0 {
+			err = errors.New("no instance key")
+		}
+		switch err {
+		case nil:
+			actor := ah.actorDefault
+			actor.PublicKey.ID = vocab.ID(ks[0].Id)
+			actor.PublicKey.PublicKeyPem = ks[0].Public
+			aFixed, cs := apiHttp.FixContext(actor)
+			for k, v := range ah.extraAttrs {
+				aFixed[k] = v
+			}
+			if len(ks) > 1 {
+				// publish all keys during the rotation, the one used for signing comes first
+				aFixed["publicKey"] = publicKeys(actor.ID, ks)
+				for _, k := range ks[1:] {
+					cs = crc32.Update(cs, crc32.IEEETable, []byte(k.Id))
+				}
+			}
+			ctx.Writer.Header().Set("content-type", apiHttp.ContentTypeActivity)
+			ctx.Writer.Header().Set("etag", fmt.Sprintf("W/\"%x\"", cs))
+			ctx.JSON(http.StatusOK, aFixed)
+		default:
+			ctx.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+	return
+}
+
+func publicKeys(owner vocab.ID, ks []model.Key) (pks []map[string]any) {
+	for _, k := range ks {
+		pk := map[string]any{
+			"id":           k.Id,
+			"owner":        owner,
+			"publicKeyPem": k.Public,
+		}
+		if !k.Deprecated.IsZero() {
+			pk["deprecated"] = true
+		}
+		pks = append(pks, pk)
 	}
 	return
 }
@@ -100,9 +137,16 @@ func (ah actorHandler) handleInterest(ctx *gin.Context, accept, id string) {
 	case "text/html", "application/xhtml+xml", "text/xml", "application/xml":
 		ctx.Redirect(http.StatusMovedPermanently, urlDetails)
 	default:
-		d, err := ah.svcInterests.Read(ctx, model.GroupIdDefault, model.UserIdDefault, id)
-		switch {
-		case err == nil:
+		d, status, err := readPublicInterest(ctx, ah.svcInterests, id)
+		var pubKeyPem string
+		if status == http.StatusOK {
+			pubKeyPem, err = ah.svcKeys.Public(ctx, fmt.Sprintf("https://%s/actor/%s#main-key", ah.cfgApi.Http.Host, id))
+			if err != nil {
+				status = http.StatusInternalServerError
+			}
+		}
+		switch status {
+		case http.StatusOK:
 			actor := ah.actorDefault // derive the default actor
 			actor.Context = vocab.ItemCollection{
 				vocab.IRI(model.NsAs),
@@ -132,6 +176,7 @@ func (ah actorHandler) handleInterest(ctx *gin.Context, accept, id string) {
 			}
 			actor.PublicKey.ID = actor.ID + "#main-key"
 			actor.PublicKey.Owner = actor.ID
+			actor.PublicKey.PublicKeyPem = pubKeyPem
 			actor.Attachment = vocab.ItemCollection{
 				vocab.Object{
 					Name: vocab.DefaultNaturalLanguageValue("homepage"),
@@ -146,7 +191,9 @@ func (ah actorHandler) handleInterest(ctx *gin.Context, accept, id string) {
 			ctx.Writer.Header().Set("content-type", apiHttp.ContentTypeActivity)
 			ctx.Writer.Header().Set("etag", fmt.Sprintf("W/\"%x\"", cs))
 			ctx.JSON(http.StatusOK, aFixed)
-		case errors.Is(err, interests.ErrNotFound):
+		case http.StatusGone:
+			ah.handleInterestGone(ctx, id, d)
+		case http.StatusNotFound:
 			ctx.String(http.StatusNotFound, "public interest does not exist: %s", id)
 		default:
 			ctx.String(http.StatusInternalServerError, err.Error())
@@ -154,3 +201,20 @@ func (ah actorHandler) handleInterest(ctx *gin.Context, accept, id string) {
 	}
 	return
 }
+
+func (ah actorHandler) handleInterestGone(ctx *gin.Context, id string, d interest.Data) {
+	t := vocab.Tombstone{
+		ID:         vocab.ID(fmt.Sprintf("https://%s/actor/%s", ah.cfgApi.Http.Host, id)),
+		Type:       vocab.TombstoneType,
+		Context:    vocab.IRI(model.NsAs),
+		FormerType: ah.actorDefault.Type,
+		Deleted:    d.Updated,
+	}
+	if d.Expired(time.Now().UTC()) {
+		t.Deleted = d.Expires
+	}
+	tFixed, _ := apiHttp.FixContext(t)
+	ctx.Writer.Header().Set("content-type", apiHttp.ContentTypeActivity)
+	ctx.JSON(http.StatusGone, tFixed)
+	return
+}
@@ -3,10 +3,17 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"github.com/awakari/int-activitypub/api/http/interests"
 	"github.com/awakari/int-activitypub/api/http/subscriptions"
 	"github.com/awakari/int-activitypub/config"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/model/interest"
+	"github.com/awakari/int-activitypub/service"
 	"github.com/awakari/int-activitypub/service/activitypub"
 	"github.com/awakari/int-activitypub/service/converter"
+	"github.com/awakari/int-activitypub/service/moderation"
+	"github.com/awakari/int-activitypub/storage/polls"
+	"github.com/awakari/int-activitypub/storage/tags"
 	"github.com/bytedance/sonic"
 	"github.com/bytedance/sonic/utf8"
 	ceProto "github.com/cloudevents/sdk-go/binding/format/protobuf/v2"
@@ -18,6 +25,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type CallbackHandler interface {
@@ -30,6 +38,11 @@ type callbackHandler struct {
 	host            string
 	svcConv         converter.Service
 	svcAp           activitypub.Service
+	svc             service.Service
+	svcInterests    interests.Service
+	svcMod          moderation.Service
+	storTags        tags.Storage
+	storPolls       polls.Storage
 	cfgEvtType      config.EventTypeConfig
 }
 
@@ -38,12 +51,27 @@ const keyHubTopic = "hub.topic"
 const linkSelfSuffix = ">; rel=\"self\""
 const keyAckCount = "X-Ack-Count"
 
-func NewCallbackHandler(topicPrefixBase, host string, svcConv converter.Service, svcAp activitypub.Service, cfgEvtType config.EventTypeConfig) CallbackHandler {
+func NewCallbackHandler(
+	topicPrefixBase, host string,
+	svcConv converter.Service,
+	svcAp activitypub.Service,
+	svc service.Service,
+	svcInterests interests.Service,
+	svcMod moderation.Service,
+	storTags tags.Storage,
+	storPolls polls.Storage,
+	cfgEvtType config.EventTypeConfig,
+) CallbackHandler {
 	return callbackHandler{
 		topicPrefixBase: topicPrefixBase,
 		host:            host,
 		svcConv:         svcConv,
 		svcAp:           svcAp,
+		svc:             svc,
+		svcInterests:    svcInterests,
+		svcMod:          svcMod,
+		storTags:        storTags,
+		storPolls:       storPolls,
 		cfgEvtType:      cfgEvtType,
 	}
 }
@@ -113,7 +141,44 @@ func (ch callbackHandler) Deliver(ctx *gin.Context) {
 		return
 	}
 
+	var d interest.Data
+	d, err = ch.svcInterests.Read(ctx, model.GroupIdDefault, model.UserIdDefault, interestId)
+	switch {
+	case errors.Is(err, interests.ErrNotFound), err == nil && d.Expired(time.Now().UTC()):
+		// interest is deleted or expired: let the follower know the interest actor is gone
+		err = ch.svc.DeleteInterestActor(ctx, interestId, follower)
+		if err != nil {
+			ctx.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		ctx.Writer.Header().Add(keyAckCount, strconv.Itoa(len(evts)))
+		ctx.String(http.StatusGone, fmt.Sprintf("interest is gone: %s", interestId))
+		return
+	case err != nil:
+		ctx.String(http.StatusInternalServerError, fmt.Sprintf("failed to read the interest %s: %s", interestId, err))
+		return
+	case !d.Public, !d.Enabled:
+		// don't deliver anything on behalf of the non-resolvable interest actor
+		ctx.Writer.Header().Add(keyAckCount, strconv.Itoa(len(evts)))
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	var suspended bool
+	suspended, err = ch.svcMod.Suspended(ctx, interestId)
+	switch {
+	case err != nil:
+		ctx.String(http.StatusInternalServerError, fmt.Sprintf("failed to check the interest %s suspension: %s", interestId, err))
+		return
+	case suspended:
+		// the interest actor's federation is suspended by the moderator: drop everything
+		ctx.Writer.Header().Add(keyAckCount, strconv.Itoa(len(evts)))
+		ctx.Status(http.StatusOK)
+		return
+	}
+
 	var countDelivered uint64
+	var deliveries []model.Delivery
 	for _, evt := range evts {
 		var evtProto *pb.CloudEvent
 		evtProto, err = ceProto.ToProto(evt)
@@ -146,6 +211,31 @@ func (ch callbackHandler) Deliver(ctx *gin.Context) {
 				if errNotify == nil {
 					errNotify = ch.svcAp.SendActivity(ctx, a, follower.Inbox.GetLink(), pubKeyId)
 				}
+				if errNotify == nil && a.Type != vocab.UndoType {
+					// remember where the note went, so it may be deleted or the boost undone by the moderator's decision
+					var announced string
+					if a.Type == vocab.AnnounceType {
+						announced = a.Object.GetLink().String()
+					}
+					deliveries = append(deliveries, model.Delivery{
+						NoteId:     a.ID.String(),
+						InterestId: interestId,
+						Inbox:      follower.Inbox.GetLink().String(),
+						Announced:  announced,
+					})
+					// make the public note resolvable by its hashtags
+					if note, tagNames, ok := taggedNote(a, interestId); ok {
+						if errTags := ch.storTags.Put(ctx, note, tagNames); errTags != nil {
+							fmt.Printf("Failed to store the note %s by tags %+v: %s\n", note.NoteId, tagNames, errTags)
+						}
+					}
+					// make the public poll resolvable by its id
+					if p, ok := publishedPoll(a, "https://"+ch.host+"/poll/"); ok {
+						if errPoll := ch.storPolls.Put(ctx, p); errPoll != nil {
+							fmt.Printf("Failed to store the poll %s: %s\n", p.Id, errPoll)
+						}
+					}
+				}
 				if errNotify != nil {
 					err = errors.Join(err, errNotify)
 				}
@@ -156,6 +246,11 @@ func (ch callbackHandler) Deliver(ctx *gin.Context) {
 		}
 		countDelivered++
 	}
+	if len(deliveries) > 0 {
+		if errRecord := ch.svcMod.RecordDeliveries(ctx, deliveries); errRecord != nil {
+			fmt.Printf("Failed to record %d deliveries to %s: %s\n", len(deliveries), follower.Inbox.GetLink(), errRecord)
+		}
+	}
 
 	ctx.Writer.Header().Add(keyAckCount, strconv.FormatUint(countDelivered, 10))
 	switch {
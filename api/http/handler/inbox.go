@@ -6,12 +6,13 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"github.com/awakari/int-activitypub/api/http/interests"
 	"github.com/awakari/int-activitypub/api/http/pub"
 	"github.com/awakari/int-activitypub/api/http/subscriptions"
 	"github.com/awakari/int-activitypub/service"
 	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/service/moderation"
 	"github.com/awakari/int-activitypub/util"
-	"github.com/bytedance/sonic"
 	"github.com/gin-gonic/gin"
 	vocab "github.com/go-ap/activitypub"
 	"github.com/superseriousbusiness/httpsig"
@@ -20,18 +21,22 @@ import (
 )
 
 type inboxHandler struct {
-	svcActivityPub activitypub.Service
-	svc            service.Service
-	host           string
+	svcActivityPub  activitypub.Service
+	svc             service.Service
+	svcInterests    interests.Service
+	host            string
+	normalizeJsonLd bool
 }
 
 const limitReqBodyLen = 262_144
 
-func NewInboxHandler(svcActivityPub activitypub.Service, svc service.Service, host string) Handler {
+func NewInboxHandler(svcActivityPub activitypub.Service, svc service.Service, svcInterests interests.Service, host string, normalizeJsonLd bool) Handler {
 	return inboxHandler{
-		svcActivityPub: svcActivityPub,
-		svc:            svc,
-		host:           host,
+		svcActivityPub:  svcActivityPub,
+		svc:             svc,
+		svcInterests:    svcInterests,
+		host:            host,
+		normalizeJsonLd: normalizeJsonLd,
 	}
 }
 
@@ -46,24 +51,27 @@ func (h inboxHandler) Handle(ctx *gin.Context) {
 		return
 	}
 
-	var activity vocab.Activity
-	err = sonic.Unmarshal(data, &activity)
+	// the signature digest is calculated over the original payload, keep it for the verification
+	dataDecode := data
+	if h.normalizeJsonLd {
+		var dataNorm []byte
+		dataNorm, err = util.NormalizeJsonLd(data)
+		switch err {
+		case nil:
+			dataDecode = dataNorm
+		default:
+			fmt.Printf("Inbox request JSON-LD normalization failure, decoding as is: %s\n", err)
+		}
+	}
+
+	var env util.Envelope
+	env, err = util.DecodeEnvelope(dataDecode)
 	if err != nil {
 		fmt.Printf("Inbox request unmarshal failure: %s\n", err)
 		ctx.String(http.StatusBadRequest, err.Error())
 		return
 	}
-
-	var tags util.ActivityTags
-	_ = sonic.Unmarshal(data, &tags)
-	if service.ActivityHasNoBotTag(tags) {
-		fmt.Printf("Activity %s contains %s tag\n", activity.ID, service.NoBot)
-		ctx.String(http.StatusUnprocessableEntity, fmt.Sprintf("Activity %s contains %s tag\n", activity.ID, service.NoBot))
-		return
-	}
-
-	var cm util.ActivityContentMap
-	_ = sonic.Unmarshal(data, &cm)
+	activity := env.Activity
 
 	t := activity.Type
 	if t == "" || t == vocab.DeleteType && activity.Actor.GetID() == activity.Object.GetID() {
@@ -78,6 +86,23 @@ func (h inboxHandler) Handle(ctx *gin.Context) {
 		pubKeyId = fmt.Sprintf("https://%s/actor#main-key", h.host)
 	default:
 		pubKeyId = fmt.Sprintf("https://%s/actor/%s#main-key", h.host, actorIdLocal)
+		// let the followers undo the follow even when the interest actor is gone
+		if t != vocab.UndoType {
+			var status int
+			_, status, err = readPublicInterest(ctx, h.svcInterests, actorIdLocal)
+			switch status {
+			case http.StatusOK:
+			case http.StatusNotFound:
+				ctx.String(http.StatusNotFound, "public interest does not exist: %s", actorIdLocal)
+				return
+			case http.StatusGone:
+				ctx.String(http.StatusGone, "interest is gone: %s", actorIdLocal)
+				return
+			default:
+				ctx.String(http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
 	}
 
 	var actor vocab.Actor
@@ -96,15 +121,15 @@ func (h inboxHandler) Handle(ctx *gin.Context) {
 	}
 
 	var post func()
-	post, err = h.svc.HandleActivity(ctx, actorIdLocal, pubKeyId, actor, actorTags, activity, tags, cm)
+	post, err = h.svc.HandleActivity(ctx, actorIdLocal, pubKeyId, actor, actorTags, env)
 	switch {
 	case errors.Is(err, subscriptions.ErrConflict):
 		ctx.String(http.StatusConflict, err.Error())
 		return
-	case errors.Is(err, service.ErrNoAccept), errors.Is(err, service.ErrNoBot):
+	case errors.Is(err, service.ErrNoAccept), errors.Is(err, service.ErrNoBot), errors.Is(err, service.ErrBlocked), errors.Is(err, service.ErrSuspended):
 		ctx.String(http.StatusUnprocessableEntity, err.Error())
 		return
-	case errors.Is(err, service.ErrInvalid):
+	case errors.Is(err, service.ErrInvalid), errors.Is(err, moderation.ErrInvalid):
 		ctx.String(http.StatusBadRequest, err.Error())
 		return
 	case errors.Is(err, pub.ErrLimitReached):
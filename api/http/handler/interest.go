@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/awakari/int-activitypub/api/http/interests"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/model/interest"
+	"net/http"
+	"time"
+)
+
+// readPublicInterest resolves the interest backing the local actor and returns the HTTP status to respond with.
+// A missing or private interest is reported as 404 to avoid disclosing its existence.
+// A disabled or expired interest is reported as 410, the interest actor is gone in this case.
+func readPublicInterest(ctx context.Context, svcInterests interests.Service, id string) (d interest.Data, status int, err error) {
+	d, err = svcInterests.Read(ctx, model.GroupIdDefault, model.UserIdDefault, id)
+	switch {
+	case err == nil:
+		switch {
+		case !d.Public:
+			status = http.StatusNotFound
+		case !d.Enabled, d.Expired(time.Now().UTC()):
+			status = http.StatusGone
+		default:
+			status = http.StatusOK
+		}
+	case errors.Is(err, interests.ErrNotFound):
+		status = http.StatusNotFound
+		err = nil
+	default:
+		status = http.StatusInternalServerError
+	}
+	return
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"fmt"
 	apiHttp "github.com/awakari/int-activitypub/api/http"
+	"github.com/awakari/int-activitypub/api/http/interests"
 	"github.com/awakari/int-activitypub/api/http/reader"
 	"github.com/awakari/int-activitypub/model"
 	"github.com/awakari/int-activitypub/service/converter"
@@ -12,24 +13,40 @@ import (
 )
 
 type outboxHandler struct {
-	svcReader reader.Service
-	svcConv   converter.Service
-	baseUrl   string
+	svcReader    reader.Service
+	svcConv      converter.Service
+	svcInterests interests.Service
+	baseUrl      string
 }
 
 const maxPageLen = 100
 
-func NewOutboxHandler(svcReader reader.Service, svcConv converter.Service, baseUrl string) Handler {
+func NewOutboxHandler(svcReader reader.Service, svcConv converter.Service, svcInterests interests.Service, baseUrl string) Handler {
 	return outboxHandler{
-		svcReader: svcReader,
-		svcConv:   svcConv,
-		baseUrl:   baseUrl,
+		svcReader:    svcReader,
+		svcConv:      svcConv,
+		svcInterests: svcInterests,
+		baseUrl:      baseUrl,
 	}
 }
 
 func (oh outboxHandler) Handle(ctx *gin.Context) {
 
 	id := ctx.Param("id")
+	_, status, err := readPublicInterest(ctx, oh.svcInterests, id)
+	switch status {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		ctx.String(http.StatusNotFound, "public interest does not exist: %s", id)
+		return
+	case http.StatusGone:
+		ctx.String(http.StatusGone, "interest is gone: %s", id)
+		return
+	default:
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	evts, err := oh.svcReader.Feed(ctx, id, maxPageLen)
 	if err != nil {
 		ctx.String(http.StatusInternalServerError, err.Error())
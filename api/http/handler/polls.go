@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	apiHttp "github.com/awakari/int-activitypub/api/http"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/storage/polls"
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+	vocab "github.com/go-ap/activitypub"
+	"hash/crc32"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type pollsHandler struct {
+	stor    polls.Storage
+	baseUrl string
+}
+
+func NewPollsHandler(stor polls.Storage, baseUrl string) Handler {
+	return pollsHandler{
+		stor:    stor,
+		baseUrl: baseUrl,
+	}
+}
+
+func (ph pollsHandler) Handle(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if id == "" {
+		ctx.String(http.StatusBadRequest, "poll id is missing")
+		return
+	}
+	p, err := ph.stor.Read(ctx, ph.baseUrl+"/"+id)
+	var m map[string]any
+	if err == nil {
+		err = sonic.UnmarshalString(p.Data, &m)
+	}
+	switch {
+	case err == nil:
+		apiHttp.FixObjectContext(m)
+		ctx.Writer.Header().Set("content-type", apiHttp.ContentTypeActivity)
+		ctx.Writer.Header().Set("etag", fmt.Sprintf("W/\"%x\"", crc32.ChecksumIEEE([]byte(p.Data))))
+		ctx.JSON(http.StatusOK, m)
+	case errors.Is(err, polls.ErrNotFound):
+		ctx.String(http.StatusNotFound, err.Error())
+	default:
+		ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	return
+}
+
+// publishedPoll returns the public Question having the id with the specified prefix, addressed to the public only.
+// Returns false when the activity doesn't create or update such a poll.
+func publishedPoll(a vocab.Activity, idPrefix string) (p model.PublishedPoll, ok bool) {
+	if a.Type != vocab.CreateType && a.Type != vocab.UpdateType {
+		return
+	}
+	q, isPoll := a.Object.(model.Poll)
+	if !isPoll || !strings.HasPrefix(q.ID.String(), idPrefix) {
+		return
+	}
+	q.To = publicOnly(q.To)
+	q.CC = publicOnly(q.CC)
+	if len(q.To) == 0 && len(q.CC) == 0 {
+		return
+	}
+	data, err := sonic.Marshal(q)
+	if err == nil {
+		p = model.PublishedPoll{
+			Id:      q.ID.String(),
+			Data:    string(data),
+			Updated: time.Now().UTC(),
+		}
+		ok = true
+	}
+	return
+}
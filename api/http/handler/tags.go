@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"fmt"
+	apiHttp "github.com/awakari/int-activitypub/api/http"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/storage/tags"
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/microcosm-cc/bluemonday"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type tagsHandler struct {
+	stor    tags.Storage
+	baseUrl string
+}
+
+type tagPage struct {
+	Tag   string
+	Notes []tagPageNote
+}
+
+type tagPageNote struct {
+	Url       string
+	Published time.Time
+	Content   template.HTML
+}
+
+// tagsFormats are the offered representations, the 1st one is the default.
+var tagsFormats = []string{
+	"application/activity+json",
+	"application/ld+json",
+	"application/json",
+	"text/html",
+	"application/xhtml+xml",
+}
+
+// htmlNote keeps the safe markup of the stored notes, the notes may come from the customized templates.
+var htmlNote = bluemonday.UGCPolicy()
+
+var tplTagPage = template.Must(template.New("tag").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <title>#{{ .Tag }}</title>
+    <meta charset="utf-8">
+</head>
+<body>
+	<h1>#{{ .Tag }}</h1>
+	{{- range .Notes }}
+	<article>
+		<p><a href="{{ .Url }}">{{ .Published.Format "2006-01-02 15:04" }}</a></p>
+		<p>{{ .Content }}</p>
+	</article>
+	{{- end }}
+</body>
+</html>`))
+
+func NewTagsHandler(stor tags.Storage, baseUrl string) Handler {
+	return tagsHandler{
+		stor:    stor,
+		baseUrl: baseUrl,
+	}
+}
+
+func (th tagsHandler) Handle(ctx *gin.Context) {
+	tag := model.NormalizeTag(ctx.Param("name"))
+	if tag == "" {
+		ctx.String(http.StatusBadRequest, "tag name is missing")
+		return
+	}
+	notes, err := th.stor.List(ctx, tag, maxPageLen)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	var items vocab.ItemCollection
+	for _, n := range notes {
+		item, errDecode := vocab.UnmarshalJSON([]byte(n.Data))
+		switch errDecode {
+		case nil:
+			items = append(items, item)
+		default:
+			fmt.Printf("failed to decode the tagged note %s, skipping: %s\n", n.NoteId, errDecode)
+		}
+	}
+	switch ctx.NegotiateFormat(tagsFormats...) {
+	case "text/html", "application/xhtml+xml":
+		th.handleHtml(ctx, tag, items)
+	default:
+		u := th.baseUrl + "/" + tag
+		coll := vocab.OrderedCollectionNew(vocab.ID(u))
+		coll.Context = vocab.IRI(model.NsAs)
+		coll.TotalItems = uint(len(items))
+		coll.OrderedItems = items
+		d, cs := apiHttp.FixContext(coll)
+		ctx.Writer.Header().Set("content-type", apiHttp.ContentTypeActivity)
+		ctx.Writer.Header().Set("etag", fmt.Sprintf("W/\"%x\"", cs))
+		ctx.JSON(http.StatusOK, d)
+	}
+	return
+}
+
+func (th tagsHandler) handleHtml(ctx *gin.Context, tag string, items vocab.ItemCollection) {
+	page := tagPage{
+		Tag: tag,
+	}
+	for _, item := range items {
+		_ = vocab.OnObject(item, func(obj *vocab.Object) error {
+			n := tagPageNote{
+				Url:       obj.ID.String(),
+				Published: obj.Published,
+				Content:   template.HTML(htmlNote.Sanitize(obj.Content.String())),
+			}
+			if obj.URL != nil {
+				n.Url = obj.URL.GetLink().String()
+			}
+			page.Notes = append(page.Notes, n)
+			return nil
+		})
+	}
+	var sb strings.Builder
+	err := tplTagPage.Execute(&sb, page)
+	switch err {
+	case nil:
+		ctx.Writer.Header().Add("Content-Type", "text/html; charset=utf-8")
+		ctx.String(http.StatusOK, sb.String())
+	default:
+		ctx.String(http.StatusInternalServerError, err.Error())
+	}
+	return
+}
+
+// taggedNote returns the public Note having the hashtags, addressed to the public only.
+// Returns false when the activity doesn't create such a note.
+func taggedNote(a vocab.Activity, interestId string) (note model.TaggedNote, tagNames []string, ok bool) {
+	if a.Type != vocab.CreateType {
+		return
+	}
+	obj, isObj := a.Object.(*vocab.Object)
+	if !isObj || len(obj.Tag) == 0 {
+		return
+	}
+	pub := *obj
+	pub.To = publicOnly(obj.To)
+	pub.CC = publicOnly(obj.CC)
+	if len(pub.To) == 0 && len(pub.CC) == 0 {
+		return
+	}
+	for _, t := range obj.Tag {
+		if t.GetType() != "Hashtag" {
+			continue
+		}
+		if l, isLink := t.(*vocab.Link); isLink {
+			if name := model.NormalizeTag(l.Name.String()); name != "" {
+				tagNames = append(tagNames, name)
+			}
+		}
+	}
+	if len(tagNames) == 0 {
+		return
+	}
+	data, err := sonic.Marshal(pub)
+	if err == nil {
+		note = model.TaggedNote{
+			NoteId:     obj.ID.String(),
+			InterestId: interestId,
+			Data:       string(data),
+			Created:    time.Now().UTC(),
+		}
+		ok = true
+	}
+	return
+}
+
+func publicOnly(addrs vocab.ItemCollection) (pub vocab.ItemCollection) {
+	for _, addr := range addrs {
+		if addr.GetLink() == vocab.PublicNS {
+			pub = append(pub, addr)
+		}
+	}
+	return
+}
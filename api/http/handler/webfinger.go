@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"errors"
 	"fmt"
 	apiHttp "github.com/awakari/int-activitypub/api/http"
 	"github.com/awakari/int-activitypub/api/http/interests"
@@ -51,9 +50,9 @@ func (w webFingerHandler) handleNonDefault(ctx *gin.Context, r string) {
 		return
 	}
 	interestId := rParts[0][len(model.WebFingerPrefixAcct):]
-	_, err := w.svcInterests.Read(ctx, model.GroupIdDefault, model.UserIdDefault, interestId)
-	switch {
-	case err == nil:
+	_, status, err := readPublicInterest(ctx, w.svcInterests, interestId)
+	switch status {
+	case http.StatusOK:
 		wf := apiHttp.WebFinger{
 			Subject: r,
 			Links: []apiHttp.WebFingerLink{
@@ -65,8 +64,10 @@ func (w webFingerHandler) handleNonDefault(ctx *gin.Context, r string) {
 			},
 		}
 		respond(ctx, wf)
-	case errors.Is(err, interests.ErrNotFound):
-		ctx.String(http.StatusBadRequest, "interest doesn't exist: %s", interestId)
+	case http.StatusNotFound:
+		ctx.String(http.StatusNotFound, "interest doesn't exist: %s", interestId)
+	case http.StatusGone:
+		ctx.String(http.StatusGone, "interest is gone: %s", interestId)
 	default:
 		ctx.String(http.StatusInternalServerError, err.Error())
 	}
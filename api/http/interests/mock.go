@@ -0,0 +1,30 @@
+package interests
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model/interest"
+	"time"
+)
+
+type mock struct {
+}
+
+func NewServiceMock() Service {
+	return mock{}
+}
+
+func (m mock) Read(ctx context.Context, groupId, userId, subId string) (subData interest.Data, err error) {
+	switch subId {
+	case "fail":
+		err = ErrNoAuth
+	case "interest-deleted":
+		err = ErrNotFound
+	case "interest-expired":
+		subData.Description = "expired"
+		subData.Expires = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		subData.Description = "active"
+		subData.Enabled = true
+	}
+	return
+}
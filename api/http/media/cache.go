@@ -0,0 +1,40 @@
+package media
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"time"
+)
+
+type cache struct {
+	svc     Service
+	results *expirable.LRU[string, probeResult]
+}
+
+type probeResult struct {
+	meta model.MediaMeta
+	err  error
+}
+
+// NewCache remembers the probe results by the media address, the failures too, so the same media delivered to many
+// followers is probed once.
+func NewCache(svc Service, size int, ttl time.Duration) Service {
+	return cache{
+		svc:     svc,
+		results: expirable.NewLRU[string, probeResult](size, nil, ttl),
+	}
+}
+
+func (c cache) Probe(ctx context.Context, addr string) (meta model.MediaMeta, err error) {
+	r, found := c.results.Get(addr)
+	if !found {
+		r.meta, r.err = c.svc.Probe(ctx, addr)
+		// the caller's cancellation says nothing about the media
+		if ctx.Err() == nil {
+			c.results.Add(addr, r)
+		}
+	}
+	meta, err = r.meta, r.err
+	return
+}
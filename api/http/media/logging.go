@@ -0,0 +1,27 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"log/slog"
+)
+
+type logging struct {
+	svc Service
+	log *slog.Logger
+}
+
+func NewLogging(svc Service, log *slog.Logger) Service {
+	return logging{
+		svc: svc,
+		log: log,
+	}
+}
+
+func (l logging) Probe(ctx context.Context, addr string) (meta model.MediaMeta, err error) {
+	meta, err = l.svc.Probe(ctx, addr)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("media.Probe(%s): %+v, %s", addr, meta, err))
+	return
+}
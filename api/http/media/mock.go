@@ -0,0 +1,31 @@
+package media
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"strings"
+)
+
+type mock struct {
+}
+
+func NewMock() Service {
+	return mock{}
+}
+
+func (m mock) Probe(ctx context.Context, addr string) (meta model.MediaMeta, err error) {
+	switch {
+	case strings.HasSuffix(addr, "/fail"):
+		err = ErrProbe
+	case strings.HasSuffix(addr, ".mp4"):
+		meta.MediaType = "video/mp4"
+	default:
+		meta = model.MediaMeta{
+			MediaType: "image/png",
+			Width:     640,
+			Height:    480,
+			Blurhash:  "L00000fQfQfQfQfQfQfQfQfQfQfQ",
+		}
+	}
+	return
+}
@@ -0,0 +1,141 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Service resolves the metadata of the remote media file.
+type Service interface {
+	Probe(ctx context.Context, addr string) (meta model.MediaMeta, err error)
+}
+
+type service struct {
+	clientHttp *http.Client
+	timeout    time.Duration
+	lenMax     int64
+}
+
+const blurHashCompsX = 4
+const blurHashCompsY = 3
+
+// imagePixelsMax limits the image to decode for the blurhash, the small file may declare the huge dimensions.
+const imagePixelsMax = 16_000_000
+
+var ErrProbe = errors.New("media probe failure")
+
+func NewService(clientHttp *http.Client, timeout time.Duration, lenMax int64) Service {
+	return service{
+		clientHttp: clientHttp,
+		timeout:    timeout,
+		lenMax:     lenMax,
+	}
+}
+
+func (svc service) Probe(ctx context.Context, addr string) (meta model.MediaMeta, err error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, svc.timeout)
+	defer cancel()
+	// the HEAD response is enough for the non-image media, some servers don't support it so the failure is not fatal
+	meta.MediaType = svc.head(ctxTimeout, addr)
+	switch {
+	case meta.MediaType != "" && meta.MediaType != "application/octet-stream" && !strings.HasPrefix(meta.MediaType, "image/"):
+	default:
+		err = svc.probeContent(ctxTimeout, addr, &meta)
+	}
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrProbe, err)
+	}
+	return
+}
+
+func (svc service) head(ctx context.Context, addr string) (mediaType string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, addr, nil)
+	var resp *http.Response
+	if err == nil {
+		resp, err = svc.clientHttp.Do(req)
+	}
+	if err == nil {
+		_ = resp.Body.Close()
+		if resp.StatusCode < 300 {
+			mediaType = model.ParseMediaType(resp.Header.Get("Content-Type"))
+		}
+	}
+	return
+}
+
+// probeContent requests the leading part of the file only, the image dimensions are usually in the header.
+// The blurhash is calculated only when the whole image fits the length limit.
+func (svc service) probeContent(ctx context.Context, addr string, meta *model.MediaMeta) (err error) {
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
+	var resp *http.Response
+	if err == nil {
+		req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", svc.lenMax-1))
+		resp, err = svc.clientHttp.Do(req)
+	}
+	var data []byte
+	if err == nil {
+		defer resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusPartialContent:
+			data, err = io.ReadAll(io.LimitReader(resp.Body, svc.lenMax+1))
+		default:
+			err = fmt.Errorf("response status: %d", resp.StatusCode)
+		}
+	}
+	if err == nil {
+		if meta.MediaType == "" || meta.MediaType == "application/octet-stream" {
+			meta.MediaType = model.ParseMediaType(resp.Header.Get("Content-Type"))
+		}
+		if meta.MediaType == "" || meta.MediaType == "application/octet-stream" {
+			meta.MediaType = model.ParseMediaType(http.DetectContentType(data))
+		}
+		if strings.HasPrefix(meta.MediaType, "image/") {
+			svc.probeImage(data, complete(resp, int64(len(data)), svc.lenMax), meta)
+		}
+	}
+	return
+}
+
+func (svc service) probeImage(data []byte, complete bool, meta *model.MediaMeta) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err == nil {
+		meta.Width = cfg.Width
+		meta.Height = cfg.Height
+	}
+	if err == nil && complete && cfg.Width*cfg.Height <= imagePixelsMax {
+		var img image.Image
+		img, _, err = image.Decode(bytes.NewReader(data))
+		if err == nil {
+			meta.Blurhash, _ = util.BlurHash(img, blurHashCompsX, blurHashCompsY)
+		}
+	}
+}
+
+func complete(resp *http.Response, l, lenMax int64) (ok bool) {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		ok = l <= lenMax
+	case http.StatusPartialContent:
+		// Content-Range: bytes 0-1023/1024
+		r := resp.Header.Get("Content-Range")
+		if i := strings.LastIndex(r, "/"); i > 0 {
+			total, err := strconv.ParseInt(r[i+1:], 10, 64)
+			ok = err == nil && total == l
+		}
+	}
+	return
+}
@@ -0,0 +1,172 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/stretchr/testify/assert"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestService_Probe(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 48; y++ {
+			img.Set(x, y, color.Black)
+		}
+	}
+	var imgData bytes.Buffer
+	assert.Nil(t, png.Encode(&imgData, img))
+	// the same image declaring the huge dimensions in the header
+	imgHuge := bytes.Clone(imgData.Bytes())
+	binary.BigEndian.PutUint32(imgHuge[16:20], 20_000)
+	binary.BigEndian.PutUint32(imgHuge[20:24], 20_000)
+	binary.BigEndian.PutUint32(imgHuge[29:33], crc32.ChecksumIEEE(imgHuge[12:29]))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/img.png", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "img.png", time.Time{}, bytes.NewReader(imgData.Bytes()))
+	})
+	mux.HandleFunc("/huge.png", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "huge.png", time.Time{}, bytes.NewReader(imgHuge))
+	})
+	mux.HandleFunc("/noext", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(imgData.Bytes())
+	})
+	mux.HandleFunc("/video.mp4", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Error("video content should not be requested")
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	cases := map[string]struct {
+		addr   string
+		lenMax int64
+		meta   model.MediaMeta
+		err    error
+	}{
+		"image": {
+			addr:   "/img.png",
+			lenMax: 1 << 20,
+			meta: model.MediaMeta{
+				MediaType: "image/png",
+				Width:     64,
+				Height:    48,
+				Blurhash:  "L00000fQfQfQfQfQfQfQfQfQfQfQ",
+			},
+		},
+		"image exceeds the length limit": {
+			addr:   "/img.png",
+			lenMax: 64,
+			meta: model.MediaMeta{
+				MediaType: "image/png",
+				Width:     64,
+				Height:    48,
+			},
+		},
+		"image exceeds the pixel limit": {
+			addr:   "/huge.png",
+			lenMax: 1 << 20,
+			meta: model.MediaMeta{
+				MediaType: "image/png",
+				Width:     20_000,
+				Height:    20_000,
+			},
+		},
+		"no head support and generic content type": {
+			addr:   "/noext",
+			lenMax: 1 << 20,
+			meta: model.MediaMeta{
+				MediaType: "image/png",
+				Width:     64,
+				Height:    48,
+				Blurhash:  "L00000fQfQfQfQfQfQfQfQfQfQfQ",
+			},
+		},
+		"video": {
+			addr:   "/video.mp4",
+			lenMax: 1 << 20,
+			meta: model.MediaMeta{
+				MediaType: "video/mp4",
+			},
+		},
+		"missing": {
+			addr:   "/missing",
+			lenMax: 1 << 20,
+			err:    ErrProbe,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			svc := NewLogging(NewService(srv.Client(), 10*time.Second, c.lenMax), slog.Default())
+			meta, err := svc.Probe(context.TODO(), srv.URL+c.addr)
+			assert.Equal(t, c.meta, meta)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestCache_Probe(t *testing.T) {
+	var count atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/video.mp4", func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.Header().Set("Content-Type", "video/mp4")
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	svc := NewCache(NewService(srv.Client(), 10*time.Second, 1<<20), 16, time.Minute)
+	cases := map[string]struct {
+		addr     string
+		meta     model.MediaMeta
+		err      error
+		requests int32
+	}{
+		"video": {
+			addr: "/video.mp4",
+			meta: model.MediaMeta{
+				MediaType: "video/mp4",
+			},
+			requests: 1,
+		},
+		"missing": {
+			addr:     "/missing",
+			err:      ErrProbe,
+			requests: 2,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			count.Store(0)
+			for i := 0; i < 3; i++ {
+				meta, err := svc.Probe(context.TODO(), srv.URL+c.addr)
+				assert.Equal(t, c.meta, meta)
+				assert.ErrorIs(t, err, c.err)
+			}
+			assert.Equal(t, c.requests, count.Load())
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package http
+
+const NodeInfoWellKnownFmtUrl = "https://%s/.well-known/nodeinfo"
+const NodeInfoRelPrefix = "http://nodeinfo.diaspora.software/ns/schema/"
+
+// NodeInfoLinks is the nodeinfo discovery document.
+type NodeInfoLinks struct {
+	Links []WebFingerLink `json:"links"`
+}
+
+// NodeInfo contains only the remote nodeinfo document's parts in use.
+type NodeInfo struct {
+	Software struct {
+		Name string `json:"name"`
+	} `json:"software"`
+	// Metadata is free form, every server software puts there own properties.
+	Metadata map[string]any `json:"metadata"`
+}
@@ -18,8 +18,13 @@ func (m mock) Subscribe(ctx context.Context, _, _, _, url string, interval time.
 }
 
 func (m mock) Unsubscribe(ctx context.Context, _, _, _, url string) (err error) {
-	//TODO implement me
-	panic("implement me")
+	switch url {
+	case "http://int-activitypub:8081?follower=fail":
+		err = ErrInternal
+	case "http://int-activitypub:8081?follower=missing":
+		err = ErrConflict
+	}
+	return
 }
 
 func (m mock) CountByInterest(ctx context.Context, interestId, _, _ string) (count int64, err error) {
@@ -15,17 +15,35 @@ type Config struct {
 
 type ApiConfig struct {
 	Http struct {
-		Host string `envconfig:"API_HTTP_HOST" required:"true"`
-		Port uint16 `envconfig:"API_HTTP_PORT" default:"8080" required:"true"`
+		Host           string `envconfig:"API_HTTP_HOST" required:"true"`
+		Port           uint16 `envconfig:"API_HTTP_PORT" default:"8080" required:"true"`
+		Client         HttpClientConfig
+		ClientInternal HttpClientInternalConfig
 	}
 	Port    uint16 `envconfig:"API_PORT" default:"50051" required:"true"`
 	Metrics struct {
 		Port uint16 `envconfig:"API_METRICS_PORT" default:"9090" required:"true"`
 	}
 	EventType EventTypeConfig
+	Inbox     struct {
+		// NormalizeJsonLd enables expanding the inbound activities against the bundled JSON-LD contexts
+		// and compacting to the canonical context before decoding.
+		NormalizeJsonLd bool `envconfig:"API_INBOX_NORMALIZE_JSONLD" default:"false"`
+		// ArticleLenMax limits the plain text converted from the long-form article's HTML content, 0 means no limit.
+		ArticleLenMax int `envconfig:"API_INBOX_ARTICLE_LEN_MAX" default:"10000" required:"true"`
+		// UrlRewrites point the bridged accounts and posts to the network of origin, the rules are separated by ";",
+		// every rule is "<bridge> <pattern> [<template>]". The custom rules precede the built-in ones.
+		UrlRewrites struct {
+			Rules   string `envconfig:"API_INBOX_URL_REWRITES" default:""`
+			Builtin bool   `envconfig:"API_INBOX_URL_REWRITES_BUILTIN" default:"true"`
+		}
+	}
 	Interests struct {
 		Uri              string `envconfig:"API_INTERESTS_URI" required:"true" default:"http://interests-api:8080/v1"`
 		DetailsUriPrefix string `envconfig:"API_INTERESTS_DETAILS_URI_PREFIX" required:"true" default:"https://awakari.com/sub-details.html?id="`
+		// SweepInterval defines how often the followed interests are checked for deletion, expiration and the key
+		// not announced to the followers yet.
+		SweepInterval time.Duration `envconfig:"API_INTERESTS_SWEEP_INTERVAL" required:"true" default:"1h"`
 	}
 	Reader struct {
 		Uri          string `envconfig:"API_READER_URI" default:"http://reader:8080/v1" required:"true"`
@@ -44,9 +62,10 @@ type ApiConfig struct {
 		Name string `envconfig:"API_ACTOR_NAME" required:"true" default:"awakari"`
 		Type string `envconfig:"API_ACTOR_TYPE" required:"true" default:"Service"`
 	}
+	// Key is the initial instance key pair, used only when there's no any instance key in the storage yet.
 	Key struct {
-		Public  string `envconfig:"API_KEY_PUBLIC" required:"true"`
-		Private string `envconfig:"API_KEY_PRIVATE" required:"true"`
+		Public  string `envconfig:"API_KEY_PUBLIC" required:"false"`
+		Private string `envconfig:"API_KEY_PRIVATE" required:"false"`
 	}
 	Node struct {
 		Name        string `envconfig:"API_NODE_NAME" required:"true"`
@@ -54,6 +73,9 @@ type ApiConfig struct {
 	}
 	Prometheus PrometheusConfig
 	Queue      QueueConfig
+	Visibility VisibilityConfig
+	Consent    ConsentConfig
+	Outbound   OutboundConfig
 }
 
 type WriterCacheConfig struct {
@@ -80,6 +102,51 @@ type DbConfig struct {
 			Shard           bool          `envconfig:"DB_TABLE_SHARD_FOLLOWING" default:"true"`
 			RetentionPeriod time.Duration `envconfig:"DB_TABLE_RETENTION_PERIOD_FOLLOWING" default:"720h" required:"true"`
 		}
+		Keys struct {
+			Cache struct {
+				Size int           `envconfig:"DB_TABLE_KEYS_CACHE_SIZE" default:"1024" required:"true"`
+				Ttl  time.Duration `envconfig:"DB_TABLE_KEYS_CACHE_TTL" default:"1h" required:"true"`
+			}
+			Name string `envconfig:"DB_TABLE_NAME_KEYS" default:"keys" required:"true"`
+			// Kek is the base64 encoded 256-bit key encrypting the stored private keys.
+			Kek string `envconfig:"DB_TABLE_KEYS_KEK" required:"true"`
+		}
+		Announcements struct {
+			Name string `envconfig:"DB_TABLE_NAME_ANNOUNCEMENTS" default:"announcements" required:"true"`
+		}
+		Blocks struct {
+			Name string `envconfig:"DB_TABLE_NAME_BLOCKS" default:"blocks" required:"true"`
+		}
+		Audit struct {
+			Name            string        `envconfig:"DB_TABLE_NAME_AUDIT" default:"audit" required:"true"`
+			RetentionPeriod time.Duration `envconfig:"DB_TABLE_RETENTION_PERIOD_AUDIT" default:"8760h" required:"true"`
+		}
+		Reports struct {
+			Name string `envconfig:"DB_TABLE_NAME_REPORTS" default:"reports" required:"true"`
+		}
+		Suspensions struct {
+			Name string `envconfig:"DB_TABLE_NAME_SUSPENSIONS" default:"suspensions" required:"true"`
+		}
+		Deliveries struct {
+			Name            string        `envconfig:"DB_TABLE_NAME_DELIVERIES" default:"deliveries" required:"true"`
+			RetentionPeriod time.Duration `envconfig:"DB_TABLE_RETENTION_PERIOD_DELIVERIES" default:"720h" required:"true"`
+		}
+		Tags struct {
+			Cache struct {
+				Size int           `envconfig:"DB_TABLE_TAGS_CACHE_SIZE" default:"1024" required:"true"`
+				Ttl  time.Duration `envconfig:"DB_TABLE_TAGS_CACHE_TTL" default:"1h" required:"true"`
+			}
+			Name            string        `envconfig:"DB_TABLE_NAME_TAGS" default:"tags" required:"true"`
+			RetentionPeriod time.Duration `envconfig:"DB_TABLE_RETENTION_PERIOD_TAGS" default:"720h" required:"true"`
+		}
+		Polls struct {
+			Cache struct {
+				Size int           `envconfig:"DB_TABLE_POLLS_CACHE_SIZE" default:"1024" required:"true"`
+				Ttl  time.Duration `envconfig:"DB_TABLE_POLLS_CACHE_TTL" default:"1h" required:"true"`
+			}
+			Name            string        `envconfig:"DB_TABLE_NAME_POLLS" default:"polls" required:"true"`
+			RetentionPeriod time.Duration `envconfig:"DB_TABLE_RETENTION_PERIOD_POLLS" default:"720h" required:"true"`
+		}
 	}
 	Tls struct {
 		Enabled  bool `envconfig:"DB_TLS_ENABLED" default:"false" required:"true"`
@@ -87,6 +154,26 @@ type DbConfig struct {
 	}
 }
 
+type HttpClientConfig struct {
+	// AllowHttp is the list of the trusted hosts allowed to be requested using plain http and to resolve to any address.
+	AllowHttp    []string `envconfig:"API_HTTP_CLIENT_ALLOW_HTTP" default:""`
+	ConnsPerHost int      `envconfig:"API_HTTP_CLIENT_CONNS_PER_HOST" default:"16" required:"true"`
+	RedirectsMax int      `envconfig:"API_HTTP_CLIENT_REDIRECTS_MAX" default:"3" required:"true"`
+	Timeout      struct {
+		Dial   time.Duration `envconfig:"API_HTTP_CLIENT_TIMEOUT_DIAL" default:"5s" required:"true"`
+		Header time.Duration `envconfig:"API_HTTP_CLIENT_TIMEOUT_HEADER" default:"10s" required:"true"`
+		Total  time.Duration `envconfig:"API_HTTP_CLIENT_TIMEOUT_TOTAL" default:"30s" required:"true"`
+	}
+}
+
+type HttpClientInternalConfig struct {
+	ConnsPerHost int `envconfig:"API_HTTP_CLIENT_INTERNAL_CONNS_PER_HOST" default:"100" required:"true"`
+	Timeout      struct {
+		Dial  time.Duration `envconfig:"API_HTTP_CLIENT_INTERNAL_TIMEOUT_DIAL" default:"5s" required:"true"`
+		Total time.Duration `envconfig:"API_HTTP_CLIENT_INTERNAL_TIMEOUT_TOTAL" default:"1m" required:"true"`
+	}
+}
+
 type PrometheusConfig struct {
 	Uri string `envconfig:"API_PROMETHEUS_URI" default:"http://prometheus-server:80" required:"true"`
 }
@@ -106,6 +193,47 @@ type EventTypeConfig struct {
 	InterestsUpdated string `envconfig:"API_EVENT_TYPE_INTERESTS_UPDATED" required:"true" default:"interests-updated"`
 }
 
+// VisibilityConfig is the policy for the inbound posts depending on the visibility the source set.
+// The actions are "publish", "undiscoverable" (publish but mark as not discoverable) and "drop".
+type VisibilityConfig struct {
+	Policy             map[string]string `envconfig:"API_VISIBILITY_POLICY" default:"public:publish,unlisted:publish,followers:drop,direct:drop,local:drop" required:"true"`
+	AnnounceRestricted string            `envconfig:"API_VISIBILITY_POLICY_ANNOUNCE_RESTRICTED" default:"undiscoverable" required:"true"`
+}
+
+// OutboundConfig defines how the interest actors publish the matching events: "note" or "announce".
+// The announce mode boosts the original object when the event comes from the Fediverse.
+type OutboundConfig struct {
+	Mode      string            `envconfig:"API_OUTBOUND_MODE" default:"note" required:"true"`
+	Interests map[string]string `envconfig:"API_OUTBOUND_MODE_INTERESTS" default:""`
+	// Note is how the published Note is rendered. The empty templates and labels dir mean the built-in ones.
+	Note struct {
+		TemplateContent string `envconfig:"API_OUTBOUND_NOTE_TEMPLATE_CONTENT" default:""`
+		TemplateSummary string `envconfig:"API_OUTBOUND_NOTE_TEMPLATE_SUMMARY" default:""`
+		LabelsDir       string `envconfig:"API_OUTBOUND_NOTE_LABELS_DIR" default:""`
+		LenMaxText      int    `envconfig:"API_OUTBOUND_NOTE_LEN_MAX_TEXT" default:"200" required:"true"`
+	}
+	// Media is the optional probe resolving the missing attachment metadata: media type, dimensions and blurhash.
+	Media struct {
+		Probe struct {
+			Enabled bool          `envconfig:"API_OUTBOUND_MEDIA_PROBE_ENABLED" default:"false"`
+			Timeout time.Duration `envconfig:"API_OUTBOUND_MEDIA_PROBE_TIMEOUT" default:"5s" required:"true"`
+			LenMax  int64         `envconfig:"API_OUTBOUND_MEDIA_PROBE_LEN_MAX" default:"1048576" required:"true"`
+			Cache   struct {
+				Size int           `envconfig:"API_OUTBOUND_MEDIA_PROBE_CACHE_SIZE" default:"1024" required:"true"`
+				Ttl  time.Duration `envconfig:"API_OUTBOUND_MEDIA_PROBE_CACHE_TTL" default:"1h" required:"true"`
+			}
+		}
+	}
+}
+
+// ConsentConfig is the cache for the instance level opt-out decisions resolved from the nodeinfo.
+type ConsentConfig struct {
+	Cache struct {
+		Size int           `envconfig:"API_CONSENT_CACHE_SIZE" default:"1024" required:"true"`
+		Ttl  time.Duration `envconfig:"API_CONSENT_CACHE_TTL" default:"24h" required:"true"`
+	}
+}
+
 type QueueConfig struct {
 	Uri              string `envconfig:"API_QUEUE_URI" default:"queue:50051" required:"true"`
 	InterestsCreated struct {
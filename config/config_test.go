@@ -19,6 +19,10 @@ func TestConfig(t *testing.T) {
 	os.Setenv("DB_TABLE_FOLLOWING_CACHE_TTL", "89s")
 	os.Setenv("API_NODE_NAME", "awakari.com")
 	os.Setenv("API_TOKEN_INTERNAL", "foo")
+	os.Setenv("API_HTTP_CLIENT_ALLOW_HTTP", "localhost,relay.internal")
+	os.Setenv("API_INBOX_NORMALIZE_JSONLD", "true")
+	os.Setenv("API_VISIBILITY_POLICY", "public:publish,unlisted:publish")
+	os.Setenv("DB_TABLE_KEYS_KEK", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
 	cfg, err := NewConfigFromEnv()
 	assert.Nil(t, err)
 	assert.Equal(t, 23*time.Hour, cfg.Api.Writer.Backoff)
@@ -27,4 +31,21 @@ func TestConfig(t *testing.T) {
 	assert.Equal(t, 1234567, cfg.Db.Table.Following.Cache.Size)
 	assert.Equal(t, time.Second*89, cfg.Db.Table.Following.Cache.Ttl)
 	assert.Equal(t, time.Hour*720, cfg.Db.Table.Following.RetentionPeriod)
+	assert.Equal(t, []string{"localhost", "relay.internal"}, cfg.Api.Http.Client.AllowHttp)
+	assert.Equal(t, 30*time.Second, cfg.Api.Http.Client.Timeout.Total)
+	assert.Equal(t, 100, cfg.Api.Http.ClientInternal.ConnsPerHost)
+	assert.Equal(t, time.Minute, cfg.Api.Http.ClientInternal.Timeout.Total)
+	assert.True(t, cfg.Api.Inbox.NormalizeJsonLd)
+	assert.Equal(t, 10000, cfg.Api.Inbox.ArticleLenMax)
+	assert.True(t, cfg.Api.Inbox.UrlRewrites.Builtin)
+	assert.Equal(t, "", cfg.Api.Inbox.UrlRewrites.Rules)
+	assert.Equal(t, map[string]string{"public": "publish", "unlisted": "publish"}, cfg.Api.Visibility.Policy)
+	assert.Equal(t, "undiscoverable", cfg.Api.Visibility.AnnounceRestricted)
+	assert.Equal(t, time.Hour, cfg.Api.Interests.SweepInterval)
+	assert.Equal(t, "followers", cfg.Db.Table.Followers.Name)
+	assert.Equal(t, "announcements", cfg.Db.Table.Announcements.Name)
+	assert.Equal(t, "blocks", cfg.Db.Table.Blocks.Name)
+	assert.Equal(t, time.Hour, cfg.Db.Table.Tags.Cache.Ttl)
+	assert.Equal(t, "polls", cfg.Db.Table.Polls.Name)
+	assert.Equal(t, 720*time.Hour, cfg.Db.Table.Polls.RetentionPeriod)
 }
@@ -8,6 +8,7 @@ import (
 	apiHttp "github.com/awakari/int-activitypub/api/http"
 	"github.com/awakari/int-activitypub/api/http/handler"
 	"github.com/awakari/int-activitypub/api/http/interests"
+	"github.com/awakari/int-activitypub/api/http/media"
 	"github.com/awakari/int-activitypub/api/http/pub"
 	"github.com/awakari/int-activitypub/api/http/reader"
 	"github.com/awakari/int-activitypub/api/http/subscriptions"
@@ -15,8 +16,19 @@ import (
 	"github.com/awakari/int-activitypub/model"
 	"github.com/awakari/int-activitypub/service"
 	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/service/consent"
 	"github.com/awakari/int-activitypub/service/converter"
+	"github.com/awakari/int-activitypub/service/keys"
+	"github.com/awakari/int-activitypub/service/moderation"
+	"github.com/awakari/int-activitypub/service/signer"
 	"github.com/awakari/int-activitypub/storage"
+	storageAudit "github.com/awakari/int-activitypub/storage/audit"
+	storageBlocks "github.com/awakari/int-activitypub/storage/blocks"
+	storageFollowers "github.com/awakari/int-activitypub/storage/followers"
+	storageKeys "github.com/awakari/int-activitypub/storage/keys"
+	storageModeration "github.com/awakari/int-activitypub/storage/moderation"
+	storagePolls "github.com/awakari/int-activitypub/storage/polls"
+	storageTags "github.com/awakari/int-activitypub/storage/tags"
 	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
 	"github.com/gin-gonic/gin"
 	vocab "github.com/go-ap/activitypub"
@@ -31,6 +43,7 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 )
 
 const ceKeyGroupId = "awakarigroupid"
@@ -50,9 +63,14 @@ func main() {
 	log := slog.New(slog.NewTextHandler(os.Stdout, &opts))
 	log.Info("starting the update for the feeds")
 
-	// init storage
+	// init storage, all the storages share the single database client
+	db, err := storage.Connect(context.TODO(), cfg.Db)
+	if err != nil {
+		panic(fmt.Sprintf("failed to connect the database: %s", err))
+	}
+	defer db.Client().Disconnect(context.TODO())
 	var stor storage.Storage
-	stor, err = storage.NewStorage(context.TODO(), cfg.Db)
+	stor, err = storage.NewStorage(context.TODO(), db, cfg.Db)
 	if err != nil {
 		panic(fmt.Sprintf("failed to initialize the storage: %s", err))
 	}
@@ -71,17 +89,22 @@ func main() {
 		return float64(count)
 	})
 
-	svcPub := pub.NewService(http.DefaultClient, cfg.Api.Writer.Uri, cfg.Api.Token.Internal, cfg.Api.Writer.Timeout)
+	// every request to a remote party goes through the hardened client, the internal services are requested apart
+	clientHttp := apiHttp.NewClient(cfg.Api.Http.Client)
+	clientHttpInternal := apiHttp.NewClientInternal(cfg.Api.Http.ClientInternal)
+
+	svcPub := pub.NewService(clientHttpInternal, cfg.Api.Writer.Uri, cfg.Api.Token.Internal, cfg.Api.Writer.Timeout)
 	svcPub = pub.NewLogging(svcPub, log)
 	log.Info("initialized the Awakari publish API client")
 
-	svcInterests := interests.NewService(http.DefaultClient, cfg.Api.Interests.Uri, cfg.Api.Token.Internal)
+	svcInterests := interests.NewService(clientHttpInternal, cfg.Api.Interests.Uri, cfg.Api.Token.Internal)
 	svcInterests = interests.NewLogging(svcInterests, log)
 	log.Info("initialized the Awakari interests API client")
 
 	// prometheus client
 	clientProm, err := apiProm.NewClient(apiProm.Config{
 		Address: cfg.Api.Prometheus.Uri,
+		Client:  clientHttpInternal,
 	})
 	var ap apiPromV1.API
 	switch err {
@@ -92,24 +115,116 @@ func main() {
 		err = nil
 	}
 
-	clientHttp := &http.Client{}
-	svcActivityPub := activitypub.NewService(clientHttp, cfg.Api.Http.Host, []byte(cfg.Api.Key.Private), ap)
+	// init keys
+	var storKeys storageKeys.Storage
+	storKeys, err = storageKeys.NewStorage(context.TODO(), db, cfg.Db)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize the keys storage: %s", err))
+	}
+	storKeys = storageKeys.NewLocalCache(storKeys, cfg.Db.Table.Keys.Cache.Size, cfg.Db.Table.Keys.Cache.Ttl)
+	defer storKeys.Close()
+	storAudit, err := storageAudit.NewStorage(context.TODO(), db, cfg.Db)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize the audit storage: %s", err))
+	}
+	defer storAudit.Close()
+	storMod, err := storageModeration.NewStorage(context.TODO(), db, cfg.Db)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize the moderation storage: %s", err))
+	}
+	defer storMod.Close()
+	storTags, err := storageTags.NewStorage(context.TODO(), db, cfg.Db)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize the tags storage: %s", err))
+	}
+	storTags = storageTags.NewLocalCache(storTags, cfg.Db.Table.Tags.Cache.Size, cfg.Db.Table.Tags.Cache.Ttl)
+	defer storTags.Close()
+	storPolls, err := storagePolls.NewStorage(context.TODO(), db, cfg.Db)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize the polls storage: %s", err))
+	}
+	storPolls = storagePolls.NewLocalCache(storPolls, cfg.Db.Table.Polls.Cache.Size, cfg.Db.Table.Polls.Cache.Ttl)
+	defer storPolls.Close()
+	storFollowers, err := storageFollowers.NewStorage(context.TODO(), db, cfg.Db)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize the followers storage: %s", err))
+	}
+	defer storFollowers.Close()
+	storBlocks, err := storageBlocks.NewStorage(context.TODO(), db, cfg.Db)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize the blocks storage: %s", err))
+	}
+	defer storBlocks.Close()
+
+	svcKeys := keys.NewService(storKeys, fmt.Sprintf("https://%s/actor", cfg.Api.Http.Host))
+	svcKeys = keys.NewLogging(svcKeys, log)
+	err = svcKeys.Seed(context.TODO(), cfg.Api.Key.Public, cfg.Api.Key.Private)
+	if err != nil {
+		panic(fmt.Sprintf("failed to seed the instance key: %s", err))
+	}
+
+	svcSigner, err := signer.NewService(svcKeys, cfg.Db.Table.Keys.Cache.Size, cfg.Db.Table.Keys.Cache.Ttl)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize the signer: %s", err))
+	}
+	svcSigner = signer.NewLogging(svcSigner, log)
+	err = svcSigner.Load(context.TODO(), fmt.Sprintf("https://%s/actor#main-key", cfg.Api.Http.Host))
+	if err != nil {
+		panic(fmt.Sprintf("failed to load the instance key: %s", err))
+	}
+
+	svcActivityPub := activitypub.NewService(clientHttp, cfg.Api.Http.Host, svcSigner, ap)
 	svcActivityPub = activitypub.NewServiceLogging(svcActivityPub, log)
 
-	svcConv := converter.NewService(
-		cfg.Api.EventType.Self,
-		fmt.Sprintf("https://%s", cfg.Api.Http.Host),
-		cfg.Api.Interests.DetailsUriPrefix,
-		cfg.Api.Reader.UriEventBase,
-		vocab.ActivityVocabularyType(cfg.Api.Actor.Type),
+	visibilityPolicy, err := model.NewVisibilityPolicy(cfg.Api.Visibility.Policy, cfg.Api.Visibility.AnnounceRestricted)
+	if err != nil {
+		panic(err)
+	}
+	outboundPolicy, err := model.NewOutboundPolicy(cfg.Api.Outbound.Mode, cfg.Api.Outbound.Interests)
+	if err != nil {
+		panic(err)
+	}
+	noteFormat, err := converter.NewNoteFormat(
+		cfg.Api.Outbound.Note.TemplateContent,
+		cfg.Api.Outbound.Note.TemplateSummary,
+		cfg.Api.Outbound.Note.LabelsDir,
+		cfg.Api.Outbound.Note.LenMaxText,
 	)
+	if err != nil {
+		panic(err)
+	}
+	urlRewrites, err := model.NewUrlRewrites(cfg.Api.Inbox.UrlRewrites.Rules, cfg.Api.Inbox.UrlRewrites.Builtin)
+	if err != nil {
+		panic(err)
+	}
+	var svcMedia media.Service
+	if cfg.Api.Outbound.Media.Probe.Enabled {
+		// the media files are remote too but should not compete with the federation for the connections
+		clientHttpMedia := apiHttp.NewClient(cfg.Api.Http.Client)
+		svcMedia = media.NewService(clientHttpMedia, cfg.Api.Outbound.Media.Probe.Timeout, cfg.Api.Outbound.Media.Probe.LenMax)
+		svcMedia = media.NewLogging(svcMedia, log)
+		svcMedia = media.NewCache(svcMedia, cfg.Api.Outbound.Media.Probe.Cache.Size, cfg.Api.Outbound.Media.Probe.Cache.Ttl)
+	}
+	optsConv := converter.Options{
+		CeType:          cfg.Api.EventType.Self,
+		UrlBase:         fmt.Sprintf("https://%s", cfg.Api.Http.Host),
+		UrlInterestBase: cfg.Api.Interests.DetailsUriPrefix,
+		EvtReaderBase:   cfg.Api.Reader.UriEventBase,
+		ActorType:       vocab.ActivityVocabularyType(cfg.Api.Actor.Type),
+		Policy:          visibilityPolicy,
+		Outbound:        outboundPolicy,
+		Format:          noteFormat,
+		ArticleLenMax:   cfg.Api.Inbox.ArticleLenMax,
+		Rewrites:        urlRewrites,
+	}
+	svcConv := converter.NewService(optsConv, svcMedia)
 	svcConv = converter.NewLogging(svcConv, log)
 
-	svcReader := reader.NewService(clientHttp, cfg.Api.Reader.Uri)
+	svcReader := reader.NewService(clientHttpInternal, cfg.Api.Reader.Uri)
 	svcReader = reader.NewLogging(svcReader, log)
 
 	// init websub
-	svcSubs := subscriptions.NewService(clientHttp, cfg.Api.Subscriptions.Uri, cfg.Api.Token.Internal)
+	svcSubs := subscriptions.NewService(clientHttpInternal, cfg.Api.Subscriptions.Uri, cfg.Api.Token.Internal)
 	svcSubs = subscriptions.NewServiceLogging(svcSubs, log)
 	urlCallbackBase := fmt.Sprintf(
 		"%s://%s:%d%s",
@@ -119,9 +234,26 @@ func main() {
 		cfg.Api.Subscriptions.CallBack.Path,
 	)
 
-	svc := service.NewService(stor, svcActivityPub, cfg.Api.Http.Host, svcConv, svcPub, cfg.Api.Writer.Backoff, svcSubs, urlCallbackBase)
+	svcConsent := consent.NewService(svcActivityPub, cfg.Api.Consent.Cache.Size, cfg.Api.Consent.Cache.Ttl)
+	svcConsent = consent.NewLogging(svcConsent, log)
+
+	svcMod := moderation.NewService(storMod, svcActivityPub, cfg.Api.Http.Host)
+	svcMod = moderation.NewLogging(svcMod, log)
+
+	svc := service.NewService(stor, svcActivityPub, cfg.Api.Http.Host, svcConv, svcPub, cfg.Api.Writer.Backoff, svcSubs, urlCallbackBase, svcKeys, svcConsent, storAudit, svcMod, svcInterests, storFollowers, storBlocks)
 	svc = service.NewLogging(svc, log)
 
+	// the followers of the deleted and expired interests receive the Delete{Actor},
+	// the followers of the interests having the key not announced yet receive the Update{Actor}
+	go func() {
+		t := time.NewTicker(cfg.Api.Interests.SweepInterval)
+		defer t.Stop()
+		for range t.C {
+			_, _ = svc.SweepInterests(context.Background())
+			_, _ = svc.AnnounceKeys(context.Background())
+		}
+	}()
+
 	log.Info(fmt.Sprintf("starting to listen the gRPC API @ port #%d...", cfg.Api.Port))
 	go func() {
 		if err = apiGrpc.Serve(cfg.Api.Port, svc); err != nil {
@@ -211,9 +343,7 @@ func main() {
 			SharedInbox: vocab.IRI(fmt.Sprintf("https://%s/inbox", cfg.Api.Http.Host)),
 		},
 		PublicKey: vocab.PublicKey{
-			ID:           vocab.ID(fmt.Sprintf("https://%s/actor#main-key", cfg.Api.Http.Host)),
-			Owner:        vocab.IRI(fmt.Sprintf("https://%s/actor", cfg.Api.Http.Host)),
-			PublicKeyPem: cfg.Api.Key.Public,
+			Owner: vocab.IRI(fmt.Sprintf("https://%s/actor", cfg.Api.Http.Host)),
 		},
 		Attachment: vocab.ItemCollection{
 			vocab.Page{
@@ -239,7 +369,7 @@ func main() {
 		"indexable":                 true,
 		"memorial":                  false,
 	}
-	ha := handler.NewActorHandler(actor, actorExtraAttrs, svcInterests, cfg.Api.Interests.DetailsUriPrefix, cfg.Api)
+	ha := handler.NewActorHandler(actor, actorExtraAttrs, svcInterests, svcKeys, cfg.Api.Interests.DetailsUriPrefix, cfg.Api)
 
 	// WebFinger
 	wfDefault := apiHttp.WebFinger{
@@ -255,8 +385,8 @@ func main() {
 	hwf := handler.NewWebFingerHandler(wfDefault, cfg.Api.Http.Host, svcInterests)
 
 	// handlers for inbox, outbox, following, followers
-	hi := handler.NewInboxHandler(svcActivityPub, svc, cfg.Api.Http.Host)
-	ho := handler.NewOutboxHandler(svcReader, svcConv, fmt.Sprintf("https://%s/outbox", cfg.Api.Http.Host))
+	hi := handler.NewInboxHandler(svcActivityPub, svc, svcInterests, cfg.Api.Http.Host, cfg.Api.Inbox.NormalizeJsonLd)
+	ho := handler.NewOutboxHandler(svcReader, svcConv, svcInterests, fmt.Sprintf("https://%s/outbox", cfg.Api.Http.Host))
 	hoDummy := handler.NewDummyCollectionHandler(vocab.OrderedCollectionPage{
 		ID:      vocab.IRI(fmt.Sprintf("https://%s/outbox", cfg.Api.Http.Host)),
 		Context: vocab.IRI("https://www.w3.org/ns/activitystreams"),
@@ -281,6 +411,8 @@ func main() {
 		First:   vocab.IRI(fmt.Sprintf("https://%s/dummy/inbox?page=1", cfg.Api.Http.Host)),
 	}).Handle)
 	r.GET("/following", hFollowing.Handle)
+	r.GET("/tags/:name", handler.NewTagsHandler(storTags, fmt.Sprintf("https://%s/tags", cfg.Api.Http.Host)).Handle)
+	r.GET("/poll/:id", handler.NewPollsHandler(storPolls, fmt.Sprintf("https://%s/poll", cfg.Api.Http.Host)).Handle)
 	r.GET("/followers/:id", hFollowers.Handle)
 	r.GET(nodeinfo.NodeInfoPath, func(ctx *gin.Context) {
 		nodeInfo.NodeInfoDiscover(ctx.Writer, ctx.Request)
@@ -299,7 +431,7 @@ func main() {
 		}
 	}()
 
-	hc := handler.NewCallbackHandler(cfg.Api.Subscriptions.Uri+"/v1", cfg.Api.Http.Host, svcConv, svcActivityPub, cfg.Api.EventType)
+	hc := handler.NewCallbackHandler(cfg.Api.Subscriptions.Uri+"/v1", cfg.Api.Http.Host, svcConv, svcActivityPub, svc, svcInterests, svcMod, storTags, storPolls, cfg.Api.EventType)
 
 	log.Info(fmt.Sprintf("starting to listen the HTTP API @ port #%d...", cfg.Api.Subscriptions.CallBack.Port))
 	internalCallbacks := gin.Default()
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// AuditKind is the kind of the recorded follow or publish decision.
+type AuditKind string
+
+const (
+	// AuditKindFollow means the follow request has been sent to the source on behalf of the group/user/interest.
+	AuditKindFollow AuditKind = "follow"
+	// AuditKindFollowDenied means the follow request has been refused, e.g. the source opts out.
+	AuditKindFollowDenied AuditKind = "follow_denied"
+	// AuditKindUnfollow means the source has been unfollowed on request.
+	AuditKindUnfollow AuditKind = "unfollow"
+	AuditKindAccept   AuditKind = "accept"
+	AuditKindReject   AuditKind = "reject"
+	AuditKindUndo     AuditKind = "undo"
+	AuditKindBlock    AuditKind = "block"
+	AuditKindDelete   AuditKind = "delete"
+	// AuditKindFlag means the remote moderator has reported the local interest actor or its notes.
+	AuditKindFlag AuditKind = "flag"
+	// AuditKindDrop means the inbound activity has not been published, the reason explains why.
+	AuditKindDrop AuditKind = "drop"
+)
+
+// drop reason prefixes
+const (
+	AuditReasonOptOut  = "nobot"
+	AuditReasonPrivate = "private"
+)
+
+// AuditEvent is the immutable record of the follow or publish decision.
+type AuditEvent struct {
+	Id   string
+	Time time.Time
+	Kind AuditKind
+
+	// ActorId is the remote source actor.
+	ActorId string
+
+	// ActivityId is the inbound activity caused the event, if any.
+	ActivityId string
+
+	// GroupId, UserId and SubId identify who requested the follow.
+	GroupId string
+	UserId  string
+	SubId   string
+
+	Reason string
+}
+
+type AuditFilter struct {
+	ActorId string
+	GroupId string
+	UserId  string
+	SubId   string
+
+	// Kinds to include, any when empty.
+	Kinds []AuditKind
+
+	// Since and Until bound the event time, unbounded when zero.
+	Since time.Time
+	Until time.Time
+}
@@ -0,0 +1,11 @@
+package model
+
+// Consent is the decision whether the actor may be followed and have the posts published.
+type Consent struct {
+	Allowed bool
+
+	// Reason explains the decision, e.g. which opt-out signal was found.
+	Reason string
+}
+
+const ConsentReasonNoOptOut = "no opt-out signal found"
@@ -0,0 +1,67 @@
+package model
+
+import (
+	vocab "github.com/go-ap/activitypub"
+)
+
+// Event is the outbound Event object having the Mobilizon extension properties,
+// see https://docs.joinmobilizon.org/contribute/activity_pub/
+type Event struct {
+	vocab.Object
+	Timezone         string
+	JoinMode         string
+	ParticipantCount int
+	// Organizer is the organizing actor IRI, Mobilizon reads it from the "actor" property.
+	Organizer string
+	Place     *EventPlace
+}
+
+// EventPlace is the event location, the address is the plain text.
+type EventPlace struct {
+	Name      string
+	Address   string
+	Latitude  float64
+	Longitude float64
+	HasCoords bool
+}
+
+func (e Event) MarshalJSON() (data []byte, err error) {
+	obj := e.Object
+	obj.Type = vocab.EventType
+	obj.Location = nil // the activitypub library's Place has no address
+	data, err = obj.MarshalJSON()
+	if err != nil || len(data) < 2 {
+		return
+	}
+	ext := map[string]any{}
+	if e.Timezone != "" {
+		ext["timezone"] = e.Timezone
+	}
+	if e.JoinMode != "" {
+		ext["joinMode"] = e.JoinMode
+	}
+	if e.ParticipantCount > 0 {
+		ext["participantCount"] = e.ParticipantCount
+	}
+	if e.Organizer != "" {
+		ext["actor"] = e.Organizer
+	}
+	if p := e.Place; p != nil {
+		loc := map[string]any{
+			"type": vocab.PlaceType,
+		}
+		if p.Name != "" {
+			loc["name"] = p.Name
+		}
+		if p.Address != "" {
+			loc["address"] = p.Address
+		}
+		if p.HasCoords {
+			loc["latitude"] = p.Latitude
+			loc["longitude"] = p.Longitude
+		}
+		ext["location"] = loc
+	}
+	data, err = spliceJSON(data, ext)
+	return
+}
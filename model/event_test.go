@@ -0,0 +1,78 @@
+package model
+
+import (
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEvent_MarshalJSON(t *testing.T) {
+	cases := map[string]struct {
+		in  Event
+		out string
+	}{
+		"minimal": {
+			in: Event{
+				Object: vocab.Object{
+					ID:        "https://host/evt1",
+					Name:      vocab.DefaultNaturalLanguageValue("Go meetup"),
+					StartTime: time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC),
+				},
+			},
+			out: `{
+				"id":"https://host/evt1",
+				"type":"Event",
+				"name":"Go meetup",
+				"startTime":"2024-05-01T16:00:00Z"
+			}`,
+		},
+		"full": {
+			in: Event{
+				Object: vocab.Object{
+					ID:        "https://host/evt2",
+					Name:      vocab.DefaultNaturalLanguageValue("Go meetup"),
+					StartTime: time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC),
+					EndTime:   time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC),
+					Location:  &vocab.Place{ID: "ignored", Type: vocab.PlaceType},
+				},
+				Timezone:         "Europe/Paris",
+				JoinMode:         "free",
+				ParticipantCount: 12,
+				Organizer:        "https://mobilizon.fr/@akurilov",
+				Place: &EventPlace{
+					Name:      "La Cantine",
+					Address:   "10 Rue de Rivoli, Paris",
+					Latitude:  48.8566,
+					Longitude: 2.3522,
+					HasCoords: true,
+				},
+			},
+			out: `{
+				"id":"https://host/evt2",
+				"type":"Event",
+				"name":"Go meetup",
+				"startTime":"2024-05-01T16:00:00Z",
+				"endTime":"2024-05-01T19:00:00Z",
+				"timezone":"Europe/Paris",
+				"joinMode":"free",
+				"participantCount":12,
+				"actor":"https://mobilizon.fr/@akurilov",
+				"location":{
+					"type":"Place",
+					"name":"La Cantine",
+					"address":"10 Rue de Rivoli, Paris",
+					"latitude":48.8566,
+					"longitude":2.3522
+				}
+			}`,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			data, err := c.in.MarshalJSON()
+			assert.Nil(t, err)
+			assert.JSONEq(t, c.out, string(data))
+		})
+	}
+}
@@ -23,3 +23,8 @@ type Data struct {
 
 	Followers int64
 }
+
+// Expired returns true when the interest has a deadline and it's already passed at the given moment.
+func (d Data) Expired(t time.Time) bool {
+	return !d.Expires.IsZero() && d.Expires.Before(t)
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Key is an actor's key pair used to sign the outgoing requests.
+type Key struct {
+
+	// Id is the public key id, e.g. "https://activitypub.awakari.com/actor/interest1#main-key".
+	Id string
+
+	// Public is the PEM encoded public key.
+	Public string
+
+	// Private is the PEM encoded private key.
+	Private string
+
+	Created time.Time
+
+	// Activated is the time since when the instance key is used for signing. Not used for the interest keys.
+	Activated time.Time
+
+	// Deprecated is the time when the instance key was replaced by the newer one. Zero if not deprecated.
+	Deprecated time.Time
+
+	// Announced is the time when the interest key was announced to all the interest followers. Zero if not yet.
+	Announced time.Time
+}
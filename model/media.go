@@ -0,0 +1,95 @@
+package model
+
+import (
+	"bytes"
+	"github.com/bytedance/sonic"
+	vocab "github.com/go-ap/activitypub"
+	"mime"
+	"strings"
+)
+
+// MediaMeta is the attachment metadata resolved by the media probe.
+type MediaMeta struct {
+	MediaType string
+	Width     int
+	Height    int
+	Blurhash  string
+}
+
+// Media is the outbound attachment object with the properties the activitypub library doesn't support,
+// see https://docs.joinmastodon.org/spec/activitypub/#as
+type Media struct {
+	vocab.Object
+	Width      int
+	Height     int
+	Blurhash   string
+	FocalPoint []float64
+}
+
+// ParseMediaType returns the normalized "type/subtype" or an empty string when the value is not a valid media type.
+func ParseMediaType(v string) (mediaType string) {
+	t, _, err := mime.ParseMediaType(v)
+	if err == nil && strings.Count(t, "/") == 1 && !strings.HasSuffix(t, "/") && !strings.HasPrefix(t, "/") {
+		mediaType = t
+	}
+	return
+}
+
+// MediaObjectType returns the attachment object type for the media type, the fallback type when the media type is
+// unknown or is not an image, video or audio.
+func MediaObjectType(mediaType string, fallback vocab.ActivityVocabularyType) (t vocab.ActivityVocabularyType) {
+	switch {
+	case strings.HasPrefix(mediaType, "image/"):
+		t = vocab.ImageType
+	case strings.HasPrefix(mediaType, "video/"):
+		t = vocab.VideoType
+	case strings.HasPrefix(mediaType, "audio/"):
+		t = vocab.AudioType
+	default:
+		t = fallback
+	}
+	return
+}
+
+// HasExt returns true when any of the Mastodon extension properties is set.
+func (m Media) HasExt() bool {
+	return m.Blurhash != "" || len(m.FocalPoint) == 2
+}
+
+func (m Media) MarshalJSON() (data []byte, err error) {
+	data, err = m.Object.MarshalJSON()
+	if err != nil || len(data) < 2 {
+		return
+	}
+	ext := map[string]any{}
+	if m.Width > 0 && m.Height > 0 {
+		ext["width"] = m.Width
+		ext["height"] = m.Height
+	}
+	if m.Blurhash != "" {
+		ext["blurhash"] = m.Blurhash
+	}
+	if len(m.FocalPoint) == 2 {
+		ext["focalPoint"] = m.FocalPoint
+	}
+	data, err = spliceJSON(data, ext)
+	return
+}
+
+// spliceJSON appends the extension properties to the JSON object encoded by the activitypub library.
+func spliceJSON(data []byte, ext map[string]any) (out []byte, err error) {
+	out = data
+	if len(ext) == 0 {
+		return
+	}
+	var extData []byte
+	extData, err = sonic.ConfigStd.Marshal(ext)
+	if err == nil {
+		obj := bytes.TrimSuffix(bytes.TrimSpace(data), []byte("}"))
+		if len(bytes.TrimSpace(obj)) > 1 {
+			obj = append(obj, ',')
+		}
+		out = append(obj, extData[1:]...)
+	}
+	return
+}
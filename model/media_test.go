@@ -0,0 +1,87 @@
+package model
+
+import (
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseMediaType(t *testing.T) {
+	cases := map[string]string{
+		"image/png":                "image/png",
+		"Image/JPEG; charset=utf8": "image/jpeg",
+		"png":                      "",
+		"image/":                   "",
+		"":                         "",
+	}
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			assert.Equal(t, out, ParseMediaType(in))
+		})
+	}
+}
+
+func TestMediaObjectType(t *testing.T) {
+	cases := map[string]vocab.ActivityVocabularyType{
+		"image/webp":      vocab.ImageType,
+		"video/mp4":       vocab.VideoType,
+		"audio/ogg":       vocab.AudioType,
+		"application/pdf": vocab.DocumentType,
+		"":                vocab.DocumentType,
+	}
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			assert.Equal(t, out, MediaObjectType(in, vocab.DocumentType))
+		})
+	}
+}
+
+func TestMedia_MarshalJSON(t *testing.T) {
+	cases := map[string]struct {
+		in  Media
+		out string
+	}{
+		"plain": {
+			in: Media{
+				Object: vocab.Object{
+					Type:      vocab.DocumentType,
+					MediaType: "application/pdf",
+					URL:       vocab.IRI("https://host/doc.pdf"),
+				},
+			},
+			out: `{"type":"Document","mediaType":"application/pdf","url":"https://host/doc.pdf"}`,
+		},
+		"ext": {
+			in: Media{
+				Object: vocab.Object{
+					Type:      vocab.ImageType,
+					MediaType: "image/png",
+					Name:      vocab.DefaultNaturalLanguageValue("a cat"),
+					URL:       vocab.IRI("https://host/cat.png"),
+				},
+				Width:      640,
+				Height:     480,
+				Blurhash:   "L00000fQfQfQfQfQfQfQfQfQfQfQ",
+				FocalPoint: []float64{-0.5, 0.25},
+			},
+			out: `{
+				"type":"Image",
+				"mediaType":"image/png",
+				"name":"a cat",
+				"url":"https://host/cat.png",
+				"width":640,
+				"height":480,
+				"blurhash":"L00000fQfQfQfQfQfQfQfQfQfQfQ",
+				"focalPoint":[-0.5,0.25]
+			}`,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			data, err := c.in.MarshalJSON()
+			assert.Nil(t, err)
+			assert.JSONEq(t, c.out, string(data))
+			assert.Equal(t, c.in.Blurhash != "", c.in.HasExt())
+		})
+	}
+}
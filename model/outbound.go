@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OutboundMode defines how the interest actor publishes the matching event.
+type OutboundMode string
+
+const (
+	// OutboundModeNote means the new Note summarizing the event is created for every event.
+	OutboundModeNote OutboundMode = "note"
+	// OutboundModeAnnounce means the original object is boosted when the event comes from the Fediverse.
+	// Other events are published as Notes.
+	OutboundModeAnnounce OutboundMode = "announce"
+)
+
+func ParseOutboundMode(s string) (m OutboundMode, err error) {
+	switch OutboundMode(s) {
+	case OutboundModeNote, OutboundModeAnnounce:
+		m = OutboundMode(s)
+	default:
+		err = fmt.Errorf("%w: unknown mode \"%s\"", ErrOutboundPolicy, s)
+	}
+	return
+}
+
+type OutboundPolicy struct {
+	Default OutboundMode
+
+	// Interests are the modes overriding the default one for the specific interests.
+	Interests map[string]OutboundMode
+}
+
+var ErrOutboundPolicy = errors.New("invalid outbound policy")
+
+// NewOutboundPolicy parses the default mode and the interest id -> mode pairs like "interest0:announce,interest1:note".
+func NewOutboundPolicy(mode string, interests map[string]string) (p OutboundPolicy, err error) {
+	p.Default, err = ParseOutboundMode(strings.TrimSpace(mode))
+	if err == nil {
+		p.Interests = make(map[string]OutboundMode, len(interests))
+		for k, v := range interests {
+			var m OutboundMode
+			m, err = ParseOutboundMode(strings.TrimSpace(v))
+			if err != nil {
+				return
+			}
+			p.Interests[strings.TrimSpace(k)] = m
+		}
+	}
+	return
+}
+
+func (p OutboundPolicy) Mode(interestId string) (m OutboundMode) {
+	m, found := p.Interests[interestId]
+	if !found {
+		m = p.Default
+	}
+	if m == "" {
+		m = OutboundModeNote
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewOutboundPolicy(t *testing.T) {
+	cases := map[string]struct {
+		mode      string
+		interests map[string]string
+		out       OutboundPolicy
+		err       error
+	}{
+		"ok": {
+			mode: "note",
+			interests: map[string]string{
+				"interest0": " announce",
+			},
+			out: OutboundPolicy{
+				Default: OutboundModeNote,
+				Interests: map[string]OutboundMode{
+					"interest0": OutboundModeAnnounce,
+				},
+			},
+		},
+		"unknown default mode": {
+			mode: "boost",
+			err:  ErrOutboundPolicy,
+		},
+		"unknown interest mode": {
+			mode: "announce",
+			interests: map[string]string{
+				"interest0": "boost",
+			},
+			err: ErrOutboundPolicy,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			p, err := NewOutboundPolicy(c.mode, c.interests)
+			assert.ErrorIs(t, err, c.err)
+			if c.err == nil {
+				assert.Equal(t, c.out, p)
+			}
+		})
+	}
+}
+
+func TestOutboundPolicy_Mode(t *testing.T) {
+	p := OutboundPolicy{
+		Default: OutboundModeAnnounce,
+		Interests: map[string]OutboundMode{
+			"interest0": OutboundModeNote,
+		},
+	}
+	assert.Equal(t, OutboundModeNote, p.Mode("interest0"))
+	assert.Equal(t, OutboundModeAnnounce, p.Mode("interest1"))
+	assert.Equal(t, OutboundModeNote, OutboundPolicy{}.Mode("interest1"))
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	vocab "github.com/go-ap/activitypub"
+	"time"
+)
+
+// PollOption is the poll choice with the count of the votes for it.
+type PollOption struct {
+	Name  string `json:"name"`
+	Votes int    `json:"votes"`
+}
+
+// Poll is the outbound Question object. The activitypub library decodes neither the options' vote counts nor the
+// Mastodon's "closed" timestamp and "votersCount", see https://docs.joinmastodon.org/spec/activitypub/#Question
+type Poll struct {
+	vocab.Object
+	Options  []PollOption
+	Multiple bool
+	Voters   int
+	ClosedAt time.Time
+}
+
+func (p Poll) MarshalJSON() (data []byte, err error) {
+	obj := p.Object
+	obj.Type = vocab.QuestionType
+	data, err = obj.MarshalJSON()
+	if err != nil || len(data) < 2 {
+		return
+	}
+	options := make([]map[string]any, 0, len(p.Options))
+	for _, o := range p.Options {
+		options = append(options, map[string]any{
+			"type": vocab.NoteType,
+			"name": o.Name,
+			"replies": map[string]any{
+				"type":       vocab.CollectionType,
+				"totalItems": o.Votes,
+			},
+		})
+	}
+	ext := map[string]any{}
+	switch p.Multiple {
+	case true:
+		ext["anyOf"] = options
+	default:
+		ext["oneOf"] = options
+	}
+	if p.Voters > 0 {
+		ext["votersCount"] = p.Voters
+	}
+	if !p.ClosedAt.IsZero() {
+		ext["closed"] = p.ClosedAt.UTC().Format(time.RFC3339)
+	}
+	data, err = spliceJSON(data, ext)
+	return
+}
+
+// PublishedPoll is the public Question published by the interest actor, resolvable by its id.
+type PublishedPoll struct {
+	Id string
+
+	// Data is the Question JSON as it's been delivered last time, addressed to the public only.
+	Data string
+
+	Updated time.Time
+}
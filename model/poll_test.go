@@ -0,0 +1,68 @@
+package model
+
+import (
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPoll_MarshalJSON(t *testing.T) {
+	cases := map[string]struct {
+		in  Poll
+		out string
+	}{
+		"open": {
+			in: Poll{
+				Object: vocab.Object{
+					ID:      "https://host/poll/1",
+					Content: vocab.DefaultNaturalLanguageValue("Tabs or spaces?"),
+					EndTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+				},
+				Options: []PollOption{
+					{Name: "tabs", Votes: 2},
+					{Name: "spaces", Votes: 3},
+				},
+				Voters: 5,
+			},
+			out: `{
+				"id":"https://host/poll/1",
+				"type":"Question",
+				"content":"Tabs or spaces?",
+				"endTime":"2024-05-01T12:00:00Z",
+				"oneOf":[
+					{"type":"Note","name":"tabs","replies":{"type":"Collection","totalItems":2}},
+					{"type":"Note","name":"spaces","replies":{"type":"Collection","totalItems":3}}
+				],
+				"votersCount":5
+			}`,
+		},
+		"closed multiple": {
+			in: Poll{
+				Object: vocab.Object{
+					ID: "https://host/poll/2",
+				},
+				Options: []PollOption{
+					{Name: "a"},
+				},
+				Multiple: true,
+				ClosedAt: time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC),
+			},
+			out: `{
+				"id":"https://host/poll/2",
+				"type":"Question",
+				"anyOf":[
+					{"type":"Note","name":"a","replies":{"type":"Collection","totalItems":0}}
+				],
+				"closed":"2024-05-01T12:00:01Z"
+			}`,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			data, err := c.in.MarshalJSON()
+			assert.Nil(t, err)
+			assert.JSONEq(t, c.out, string(data))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// Report is the inbound Flag activity sent by the remote moderator.
+type Report struct {
+	// Id is assigned by the storage and is time sortable.
+	Id string
+
+	// ActivityId is the Flag activity id.
+	ActivityId string
+
+	// ReporterId is the actor sent the Flag, usually the remote instance actor.
+	ReporterId string
+
+	// InterestId is the reported local interest actor, empty when unknown.
+	InterestId string
+
+	// Objects are the reported object IRIs, e.g. the delivered notes and the interest actor.
+	Objects []string
+
+	// Content is the moderator's comment.
+	Content string
+
+	Created time.Time
+
+	// Resolution is the last action taken, empty while unresolved.
+	Resolution ReportAction
+	Resolved   time.Time
+}
+
+type ReportAction string
+
+const (
+	// ReportActionDismiss resolves the report without any action.
+	ReportActionDismiss ReportAction = "dismiss"
+	// ReportActionSuspend stops the reported interest actor's federation: nothing is delivered, no new followers.
+	ReportActionSuspend ReportAction = "suspend"
+	// ReportActionReinstate lifts the suspension.
+	ReportActionReinstate ReportAction = "reinstate"
+	// ReportActionDeleteNotes sends Delete of the reported notes to the inboxes received these.
+	// The boosts are undone instead.
+	ReportActionDeleteNotes ReportAction = "delete_notes"
+)
+
+type ReportFilter struct {
+	InterestId string
+	ReporterId string
+	// Unresolved includes only the reports without any resolution.
+	Unresolved bool
+}
+
+// Delivery is the note delivered on behalf of the interest actor to the follower's inbox.
+type Delivery struct {
+	// NoteId is the local Note id, or the Announce activity id when the original object is boosted.
+	NoteId     string
+	InterestId string
+	Inbox      string
+	// Announced is the boosted original object IRI, empty when the Note is delivered.
+	Announced string
+	Created   time.Time
+	// Retracted is the time the Delete or Undo was sent to the inbox, zero while not retracted.
+	Retracted time.Time
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// UrlRewrite replaces the bridged account or post address with the one in the network of origin.
+type UrlRewrite struct {
+	// Bridge names the network of origin, e.g. "bluesky".
+	Bridge string
+
+	Pattern *regexp.Regexp
+
+	// Template is the whole replacement address expanded with the pattern's submatches, e.g.
+	// "https://bsky.app/profile/$1". Empty template keeps the address as is, only the network of origin is known.
+	Template string
+}
+
+// UrlRewrites is the ordered list of the rules, the 1st matching rule applies.
+type UrlRewrites []UrlRewrite
+
+var ErrUrlRewrite = errors.New("invalid url rewrite")
+
+// UrlRewritesBuiltin are the rules for the common bridges into the Fediverse.
+var UrlRewritesBuiltin = UrlRewrites{
+	// Bridgy Fed: Bluesky
+	mustUrlRewrite("bluesky", `^https://bsky\.brid\.gy/ap/(did:plc:.+)$`, "https://bsky.app/profile/$1"),
+	mustUrlRewrite("bluesky", `^https://bsky\.brid\.gy/convert/ap/at://(did:plc:[^/]+)/app\.bsky\.feed\.post/(.+)$`, "https://bsky.app/profile/$1/post/$2"),
+	mustUrlRewrite("bluesky", `^https://bsky\.brid\.gy/convert/ap/at://(did:plc:.+)$`, "https://bsky.app/profile/$1"),
+	mustUrlRewrite("bluesky", `^https://bsky\.brid\.gy/`, ""),
+	// Bridgy Fed: websites
+	mustUrlRewrite("web", `^https://web\.brid\.gy/r/(https?://.+)$`, "$1"),
+	mustUrlRewrite("web", `^https://web\.brid\.gy/`, ""),
+	// Mostr: Nostr public keys and events
+	mustUrlRewrite("nostr", `^https://mostr\.pub/(?:users|objects)/([0-9a-f]{64})$`, "https://njump.me/$1"),
+	mustUrlRewrite("nostr", `^https://mostr\.pub/`, ""),
+	mustUrlRewrite("threads", `^https://(?:www\.)?threads\.(?:net|com)/`, ""),
+	mustUrlRewrite("flipboard", `^https://(?:[a-z0-9-]+\.)?flipboard\.(?:com|social)/`, ""),
+	mustUrlRewrite("tumblr", `^https://(?:www\.)?tumblr\.com/`, ""),
+	// RSS Parrot: the account is named after the feed's site
+	mustUrlRewrite("rss", `^https://rss-parrot\.net/u/([^/]+)$`, "https://$1"),
+	mustUrlRewrite("rss", `^https://rss-parrot\.net/`, ""),
+}
+
+func mustUrlRewrite(bridge, pattern, template string) (r UrlRewrite) {
+	r = UrlRewrite{
+		Bridge:   bridge,
+		Pattern:  regexp.MustCompile(pattern),
+		Template: template,
+	}
+	return
+}
+
+// NewUrlRewrites parses the rules separated by ";", every rule is "<bridge> <pattern> [<template>]", e.g.
+// "nostr ^https://mostr\.pub/users/([0-9a-f]{64})$ https://njump.me/$1". The parsed rules precede the built-in ones
+// when these are enabled.
+func NewUrlRewrites(rules string, builtin bool) (rr UrlRewrites, err error) {
+	for _, rule := range strings.Split(rules, ";") {
+		fields := strings.Fields(rule)
+		var r UrlRewrite
+		switch len(fields) {
+		case 0:
+			continue
+		case 2, 3:
+			r.Bridge = fields[0]
+			r.Pattern, err = regexp.Compile(fields[1])
+			if len(fields) == 3 {
+				r.Template = fields[2]
+			}
+		default:
+			err = fmt.Errorf("expected \"<bridge> <pattern> [<template>]\", got \"%s\"", strings.TrimSpace(rule))
+		}
+		if err != nil {
+			err = fmt.Errorf("%w: %s", ErrUrlRewrite, err)
+			return
+		}
+		rr = append(rr, r)
+	}
+	if builtin {
+		rr = append(rr, UrlRewritesBuiltin...)
+	}
+	return
+}
+
+// Rewrite returns the address in the network of origin and the network name when any rule matches the address.
+// Otherwise, returns the address as is and the empty bridge.
+func (rr UrlRewrites) Rewrite(addr string) (dst, bridge string) {
+	dst = addr
+	for _, r := range rr {
+		m := r.Pattern.FindStringSubmatchIndex(addr)
+		if m == nil {
+			continue
+		}
+		bridge = r.Bridge
+		if r.Template != "" {
+			dst = string(r.Pattern.ExpandString(nil, r.Template, addr, m))
+		}
+		break
+	}
+	return
+}
+
+// Rewritten returns true when the address is in the network of origin some rule rewrites to, e.g. the Bluesky web
+// address. Such address is not resolvable as an ActivityPub object. The template should start with the scheme and
+// the host, otherwise it's not considered.
+func (rr UrlRewrites) Rewritten(addr string) (ok bool) {
+	for _, r := range rr {
+		prefix, _, _ := strings.Cut(r.Template, "$")
+		_, path, found := strings.Cut(prefix, "://")
+		if found && strings.Contains(path, "/") && strings.HasPrefix(addr, prefix) {
+			ok = true
+			break
+		}
+	}
+	return
+}
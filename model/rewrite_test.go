@@ -0,0 +1,145 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewUrlRewrites(t *testing.T) {
+	cases := map[string]struct {
+		rules   string
+		builtin bool
+		count   int
+		err     error
+	}{
+		"empty": {},
+		"builtin only": {
+			builtin: true,
+			count:   len(UrlRewritesBuiltin),
+		},
+		"custom and builtin": {
+			rules:   ` gotosocial ^https://gts\.example/users/([^/]+)$ https://gts.example/@$1 ; ; pixelfed ^https://pixelfed\.example/`,
+			builtin: true,
+			count:   2 + len(UrlRewritesBuiltin),
+		},
+		"invalid pattern": {
+			rules: `foo ^https://(foo`,
+			err:   ErrUrlRewrite,
+		},
+		"missing pattern": {
+			rules: `foo`,
+			err:   ErrUrlRewrite,
+		},
+		"too many fields": {
+			rules: `foo ^https://foo https://bar https://baz`,
+			err:   ErrUrlRewrite,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			rr, err := NewUrlRewrites(c.rules, c.builtin)
+			assert.ErrorIs(t, err, c.err)
+			assert.Len(t, rr, c.count)
+		})
+	}
+}
+
+func TestUrlRewrites_Rewrite(t *testing.T) {
+	rr, err := NewUrlRewrites(`custom ^https://mostr\.pub/users/alice$ https://alice.example`, true)
+	require.Nil(t, err)
+	cases := map[string]struct {
+		addr   string
+		dst    string
+		bridge string
+	}{
+		"not bridged": {
+			addr: "https://mastodon.social/users/alice",
+			dst:  "https://mastodon.social/users/alice",
+		},
+		"bluesky actor": {
+			addr:   "https://bsky.brid.gy/ap/did:plc:abc",
+			dst:    "https://bsky.app/profile/did:plc:abc",
+			bridge: "bluesky",
+		},
+		"bluesky post": {
+			addr:   "https://bsky.brid.gy/convert/ap/at://did:plc:abc/app.bsky.feed.post/3kxyz",
+			dst:    "https://bsky.app/profile/did:plc:abc/post/3kxyz",
+			bridge: "bluesky",
+		},
+		"bluesky other": {
+			addr:   "https://bsky.brid.gy/r/https://bsky.app/profile/abc",
+			dst:    "https://bsky.brid.gy/r/https://bsky.app/profile/abc",
+			bridge: "bluesky",
+		},
+		"website": {
+			addr:   "https://web.brid.gy/r/https://blog.example/posts/1",
+			dst:    "https://blog.example/posts/1",
+			bridge: "web",
+		},
+		"nostr note": {
+			addr:   "https://mostr.pub/objects/9f3c9d5f1e2a4b6c8d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e",
+			dst:    "https://njump.me/9f3c9d5f1e2a4b6c8d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e",
+			bridge: "nostr",
+		},
+		"custom rule precedes builtin": {
+			addr:   "https://mostr.pub/users/alice",
+			dst:    "https://alice.example",
+			bridge: "custom",
+		},
+		"threads": {
+			addr:   "https://threads.net/ap/users/17841400000000000/",
+			dst:    "https://threads.net/ap/users/17841400000000000/",
+			bridge: "threads",
+		},
+		"flipboard": {
+			addr:   "https://flipboard.com/users/theverge",
+			dst:    "https://flipboard.com/users/theverge",
+			bridge: "flipboard",
+		},
+		"tumblr": {
+			addr:   "https://www.tumblr.com/staff",
+			dst:    "https://www.tumblr.com/staff",
+			bridge: "tumblr",
+		},
+		"rss parrot": {
+			addr:   "https://rss-parrot.net/u/blog.example.com",
+			dst:    "https://blog.example.com",
+			bridge: "rss",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			dst, bridge := rr.Rewrite(c.addr)
+			assert.Equal(t, c.dst, dst)
+			assert.Equal(t, c.bridge, bridge)
+		})
+	}
+}
+
+func TestUrlRewrites_Rewritten(t *testing.T) {
+	cases := map[string]struct {
+		addr string
+		ok   bool
+	}{
+		"bluesky post": {
+			addr: "https://bsky.app/profile/did:plc:abc/post/123",
+			ok:   true,
+		},
+		"nostr": {
+			addr: "https://njump.me/9f3c",
+			ok:   true,
+		},
+		"bridged but kept": {
+			addr: "https://threads.net/ap/users/1/post/2/",
+		},
+		"any site is not the rss parrot result": {
+			addr: "https://blog.example.com/posts/1",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, c.ok, UrlRewritesBuiltin.Rewritten(c.addr))
+		})
+	}
+}
@@ -11,6 +11,7 @@ type Source struct {
 	Summary  string
 	Accepted bool
 	Rejected bool
+	Blocked  bool
 	Last     time.Time
 	Created  time.Time
 	SubId    string
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// TaggedNote is the public Note published by the interest actor with the hashtag.
+type TaggedNote struct {
+	NoteId     string
+	InterestId string
+
+	// Data is the Note JSON as it's been delivered, addressed to the public only.
+	Data string
+
+	Created time.Time
+}
+
+// NormalizeTag returns the hashtag name without the leading "#", lowercase.
+func NormalizeTag(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	cases := map[string]string{
+		"#GoLang": "golang",
+		" käse ":  "käse",
+		"#":       "",
+	}
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			assert.Equal(t, out, NormalizeTag(in))
+		})
+	}
+}
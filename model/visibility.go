@@ -0,0 +1,125 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Visibility of the inbound post, from the most to the least permissive.
+type Visibility int
+
+const (
+	// VisibilityPublic means addressed to the public collection directly.
+	VisibilityPublic Visibility = iota
+	// VisibilityUnlisted means the public collection is only in "cc", the author doesn't want the post to be discovered.
+	VisibilityUnlisted
+	// VisibilityFollowers means addressed to the author's followers only.
+	VisibilityFollowers
+	// VisibilityDirect means addressed to the specific actors only.
+	VisibilityDirect
+	// VisibilityLocal means the post is not supposed to leave the origin instance.
+	VisibilityLocal
+)
+
+var visibilityNames = [...]string{
+	"public",
+	"unlisted",
+	"followers",
+	"direct",
+	"local",
+}
+
+func (v Visibility) String() string {
+	return visibilityNames[v]
+}
+
+func ParseVisibility(s string) (v Visibility, err error) {
+	for i, name := range visibilityNames {
+		if name == s {
+			v = Visibility(i)
+			return
+		}
+	}
+	err = fmt.Errorf("%w: unknown visibility \"%s\"", ErrVisibilityPolicy, s)
+	return
+}
+
+// VisibilityAction is the policy decision about the inbound post, from the most to the least permissive.
+type VisibilityAction int
+
+const (
+	VisibilityActionPublish VisibilityAction = iota
+	VisibilityActionPublishUndiscoverable
+	VisibilityActionDrop
+)
+
+var visibilityActionNames = [...]string{
+	"publish",
+	"undiscoverable",
+	"drop",
+}
+
+func (a VisibilityAction) String() string {
+	return visibilityActionNames[a]
+}
+
+func ParseVisibilityAction(s string) (a VisibilityAction, err error) {
+	for i, name := range visibilityActionNames {
+		if name == s {
+			a = VisibilityAction(i)
+			return
+		}
+	}
+	err = fmt.Errorf("%w: unknown action \"%s\"", ErrVisibilityPolicy, s)
+	return
+}
+
+// VisibilityClass is the visibility classifier's result.
+type VisibilityClass struct {
+	Visibility Visibility
+
+	// AnnounceRestricted means the author doesn't allow anybody to boost the post, e.g. the GoToSocial interaction policy.
+	AnnounceRestricted bool
+}
+
+type VisibilityPolicy struct {
+	Actions map[Visibility]VisibilityAction
+
+	// AnnounceRestricted is the action to apply when the author restricts the boosts.
+	// The least permissive action wins when it's stricter than the action for the visibility.
+	AnnounceRestricted VisibilityAction
+}
+
+var ErrVisibilityPolicy = errors.New("invalid visibility policy")
+
+// NewVisibilityPolicy parses the visibility -> action pairs like "public:publish,unlisted:undiscoverable".
+// The visibilities missing in the pairs are dropped.
+func NewVisibilityPolicy(actions map[string]string, announceRestricted string) (p VisibilityPolicy, err error) {
+	p.Actions = make(map[Visibility]VisibilityAction, len(actions))
+	for k, v := range actions {
+		var vis Visibility
+		vis, err = ParseVisibility(strings.TrimSpace(k))
+		var a VisibilityAction
+		if err == nil {
+			a, err = ParseVisibilityAction(strings.TrimSpace(v))
+		}
+		if err != nil {
+			return
+		}
+		p.Actions[vis] = a
+	}
+	p.AnnounceRestricted, err = ParseVisibilityAction(announceRestricted)
+	return
+}
+
+func (p VisibilityPolicy) Decide(c VisibilityClass) (a VisibilityAction) {
+	a, found := p.Actions[c.Visibility]
+	if !found {
+		a = VisibilityActionDrop
+	}
+	if c.AnnounceRestricted && p.AnnounceRestricted > a {
+		a = p.AnnounceRestricted
+	}
+	return
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewVisibilityPolicy(t *testing.T) {
+	cases := map[string]struct {
+		actions            map[string]string
+		announceRestricted string
+		out                VisibilityPolicy
+		err                error
+	}{
+		"ok": {
+			actions: map[string]string{
+				"public":   "publish",
+				"unlisted": " undiscoverable ",
+				"local":    "drop",
+			},
+			announceRestricted: "undiscoverable",
+			out: VisibilityPolicy{
+				Actions: map[Visibility]VisibilityAction{
+					VisibilityPublic:   VisibilityActionPublish,
+					VisibilityUnlisted: VisibilityActionPublishUndiscoverable,
+					VisibilityLocal:    VisibilityActionDrop,
+				},
+				AnnounceRestricted: VisibilityActionPublishUndiscoverable,
+			},
+		},
+		"unknown visibility": {
+			actions: map[string]string{
+				"everybody": "publish",
+			},
+			announceRestricted: "drop",
+			err:                ErrVisibilityPolicy,
+		},
+		"unknown action": {
+			actions: map[string]string{
+				"public": "share",
+			},
+			announceRestricted: "drop",
+			err:                ErrVisibilityPolicy,
+		},
+		"missing announce restricted action": {
+			err: ErrVisibilityPolicy,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			p, err := NewVisibilityPolicy(c.actions, c.announceRestricted)
+			assert.ErrorIs(t, err, c.err)
+			if c.err == nil {
+				assert.Equal(t, c.out, p)
+			}
+		})
+	}
+}
+
+func TestVisibilityPolicy_Decide(t *testing.T) {
+	p := VisibilityPolicy{
+		Actions: map[Visibility]VisibilityAction{
+			VisibilityPublic:   VisibilityActionPublish,
+			VisibilityUnlisted: VisibilityActionPublishUndiscoverable,
+		},
+		AnnounceRestricted: VisibilityActionPublishUndiscoverable,
+	}
+	cases := map[string]struct {
+		in  VisibilityClass
+		out VisibilityAction
+	}{
+		"public": {
+			in:  VisibilityClass{Visibility: VisibilityPublic},
+			out: VisibilityActionPublish,
+		},
+		"public, announce restricted": {
+			in:  VisibilityClass{Visibility: VisibilityPublic, AnnounceRestricted: true},
+			out: VisibilityActionPublishUndiscoverable,
+		},
+		"unlisted": {
+			in:  VisibilityClass{Visibility: VisibilityUnlisted},
+			out: VisibilityActionPublishUndiscoverable,
+		},
+		"followers, not in the policy": {
+			in:  VisibilityClass{Visibility: VisibilityFollowers},
+			out: VisibilityActionDrop,
+		},
+		"local, announce restricted is less strict": {
+			in:  VisibilityClass{Visibility: VisibilityLocal, AnnounceRestricted: true},
+			out: VisibilityActionDrop,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, c.out, p.Decide(c.in))
+		})
+	}
+}
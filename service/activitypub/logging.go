@@ -3,6 +3,7 @@ package activitypub
 import (
 	"context"
 	"fmt"
+	apiHttp "github.com/awakari/int-activitypub/api/http"
 	"github.com/awakari/int-activitypub/util"
 	vocab "github.com/go-ap/activitypub"
 	"github.com/writeas/go-nodeinfo"
@@ -39,6 +40,12 @@ func (l logging) SendActivity(ctx context.Context, a vocab.Activity, inbox vocab
 	return
 }
 
+func (l logging) FetchNodeInfo(ctx context.Context, host string) (ni apiHttp.NodeInfo, err error) {
+	ni, err = l.svc.FetchNodeInfo(ctx, host)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("activitypub.FetchNodeInfo(host=%s): %+v, %s", host, ni, err))
+	return
+}
+
 func (l logging) IsOpenRegistration() (isOpen bool, err error) {
 	isOpen, err = l.svc.IsOpenRegistration()
 	l.log.Log(context.TODO(), util.LogLevel(err), fmt.Sprintf("activitypub.IsOpenRegistration(): %t, %s", isOpen, err))
@@ -3,6 +3,7 @@ package activitypub
 import (
 	"context"
 	"fmt"
+	apiHttp "github.com/awakari/int-activitypub/api/http"
 	"github.com/awakari/int-activitypub/util"
 	vocab "github.com/go-ap/activitypub"
 	"github.com/writeas/go-nodeinfo"
@@ -61,6 +62,21 @@ func (m mock) SendActivity(ctx context.Context, a vocab.Activity, inbox vocab.IR
 	return
 }
 
+func (m mock) FetchNodeInfo(ctx context.Context, host string) (ni apiHttp.NodeInfo, err error) {
+	switch host {
+	case "fail.social":
+		err = ErrNodeInfoFetch
+	case "noindex.social":
+		ni.Software.Name = "mastodon"
+		ni.Metadata = map[string]any{
+			"noindex": true,
+		}
+	default:
+		ni.Software.Name = "mastodon"
+	}
+	return
+}
+
 func (m mock) IsOpenRegistration() (bool, error) {
 	return true, nil
 }
@@ -7,17 +7,17 @@ import (
 	"fmt"
 	apiHttp "github.com/awakari/int-activitypub/api/http"
 	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/service/signer"
 	"github.com/awakari/int-activitypub/util"
 	"github.com/bytedance/sonic"
 	vocab "github.com/go-ap/activitypub"
 	apiPromV1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	modelProm "github.com/prometheus/common/model"
-	"github.com/superseriousbusiness/httpsig"
 	"github.com/writeas/go-nodeinfo"
-	"golang.org/x/crypto/ssh"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -25,40 +25,34 @@ type Service interface {
 	ResolveActorLink(ctx context.Context, host, name string) (self vocab.IRI, err error)
 	FetchActor(ctx context.Context, addr vocab.IRI, pubKeyId string) (a vocab.Actor, tags util.ObjectTags, err error)
 	SendActivity(ctx context.Context, a vocab.Activity, inbox vocab.IRI, pubKeyId string) (err error)
+
+	// FetchNodeInfo resolves the remote server's nodeinfo document using the well-known discovery.
+	FetchNodeInfo(ctx context.Context, host string) (ni apiHttp.NodeInfo, err error)
+
 	nodeinfo.Resolver
 }
 
 type service struct {
 	clientHttp *http.Client
 	hostname   string
-	privKey    []byte
+	signer     signer.Service
 	apiProm    apiPromV1.API
 }
 
 const limitRespBodyLen = 65_536
 const metricQuerySubscribers = "sum by (service) (awk_subscribers_total)"
 
-var prefs = []httpsig.Algorithm{
-	httpsig.RSA_SHA256,
-}
-var digestAlgorithm = httpsig.DigestSha256
-var headersToSign = []string{
-	httpsig.RequestTarget,
-	"host",
-	"date",
-	"digest",
-}
-
 var ErrActorWebFinger = errors.New("failed to get the webfinger data for actor")
 var ErrActorFetch = errors.New("failed to get the actor")
 var ErrActorGone = errors.New("actor gone")
 var ErrActivitySend = errors.New("failed to send activity")
+var ErrNodeInfoFetch = errors.New("failed to get the nodeinfo")
 
-func NewService(clientHttp *http.Client, hostname string, privKey []byte, apiProm apiPromV1.API) Service {
+func NewService(clientHttp *http.Client, hostname string, signer signer.Service, apiProm apiPromV1.API) Service {
 	return service{
 		clientHttp: clientHttp,
 		hostname:   hostname,
-		privKey:    privKey,
+		signer:     signer,
 		apiProm:    apiProm,
 	}
 }
@@ -113,7 +107,9 @@ func (svc service) FetchActor(ctx context.Context, addr vocab.IRI, pubKeyId stri
 		req.Header.Set("Date", now.Format(http.TimeFormat))
 	}
 	//
-	err = svc.signRequest(req, []byte{}, pubKeyId)
+	if err == nil {
+		err = svc.signer.Sign(ctx, req, []byte{}, pubKeyId)
+	}
 	//
 	if err == nil {
 		resp, err = svc.clientHttp.Do(req)
@@ -133,10 +129,7 @@ func (svc service) FetchActor(ctx context.Context, addr vocab.IRI, pubKeyId stri
 		}
 	}
 	if err == nil {
-		err = sonic.Unmarshal(data, &actor)
-	}
-	if err == nil {
-		err = sonic.Unmarshal(data, &tags)
+		actor, tags, err = util.DecodeActor(data)
 	}
 	//
 	if err != nil {
@@ -167,7 +160,9 @@ func (svc service) SendActivity(ctx context.Context, a vocab.Activity, inbox voc
 		req.Header.Set("Date", now.Format(http.TimeFormat))
 	}
 	//
-	err = svc.signRequest(req, d, pubKeyId)
+	if err == nil {
+		err = svc.signer.Sign(ctx, req, d, pubKeyId)
+	}
 	//
 	var resp *http.Response
 	if err == nil {
@@ -189,22 +184,49 @@ func (svc service) SendActivity(ctx context.Context, a vocab.Activity, inbox voc
 	return
 }
 
-func (svc service) signRequest(req *http.Request, data []byte, pubKeyId string) (err error) {
-	var signer httpsig.Signer
-	signer, _, err = httpsig.NewSigner(prefs, digestAlgorithm, headersToSign, httpsig.Signature, 120)
-	var privKey any
+func (svc service) FetchNodeInfo(ctx context.Context, host string) (ni apiHttp.NodeInfo, err error) {
+	var links apiHttp.NodeInfoLinks
+	err = svc.getJson(ctx, fmt.Sprintf(apiHttp.NodeInfoWellKnownFmtUrl, host), &links)
+	var addr string
 	if err == nil {
-		privKey, err = ssh.ParseRawPrivateKey(svc.privKey)
-		if err != nil {
-			err = fmt.Errorf("failed to parse the private key: %w", err)
+		// prefer the latest schema version, these are usually listed in the ascending order
+		for _, l := range links.Links {
+			if strings.HasPrefix(l.Rel, apiHttp.NodeInfoRelPrefix) {
+				addr = l.Href
+			}
+		}
+		if addr == "" {
+			err = errors.New("no nodeinfo link")
 		}
 	}
 	if err == nil {
-		//err = signer.SignRequest(privKey, fmt.Sprintf("https://%s/actor#main-key", svc.hostname), req, data)
-		err = signer.SignRequest(privKey, pubKeyId, req, data)
-		if err != nil {
-			err = fmt.Errorf("failed to sign the follow request: %w", err)
-		}
+		err = svc.getJson(ctx, addr, &ni)
+	}
+	if err != nil {
+		err = fmt.Errorf("%w @ %s: %s", ErrNodeInfoFetch, host, err)
+	}
+	return
+}
+
+func (svc service) getJson(ctx context.Context, addr string, dst any) (err error) {
+	var req *http.Request
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, addr, nil)
+	var resp *http.Response
+	if err == nil {
+		req.Header.Add("Accept", "application/json")
+		req.Header.Add("User-Agent", svc.hostname)
+		resp, err = svc.clientHttp.Do(req)
+	}
+	var data []byte
+	if err == nil {
+		defer resp.Body.Close()
+		data, err = io.ReadAll(io.LimitReader(resp.Body, limitRespBodyLen))
+	}
+	if err == nil && resp.StatusCode > 299 {
+		err = fmt.Errorf("response status %d from %s", resp.StatusCode, addr)
+	}
+	if err == nil {
+		err = sonic.Unmarshal(data, dst)
 	}
 	return
 }
@@ -3,18 +3,26 @@ package activitypub
 import (
 	"context"
 	"fmt"
+	"github.com/awakari/int-activitypub/service/keys"
+	"github.com/awakari/int-activitypub/service/signer"
+	storageKeys "github.com/awakari/int-activitypub/storage/keys"
 	vocab "github.com/go-ap/activitypub"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superseriousbusiness/httpsig"
+	"golang.org/x/crypto/ssh"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestService_ResolveActor(t *testing.T) {
 	if os.Getenv("CI") == "true" {
 		t.Skip()
 	}
-	svc := NewService(http.DefaultClient, "activitypub.awakari.com", []byte{}, nil)
+	svc := NewService(http.DefaultClient, "activitypub.awakari.com", signer.NewServiceMock(), nil)
 	self, err := svc.ResolveActorLink(context.TODO(), "mastodon.social", "akurilov")
 	assert.Equal(t, "https://mastodon.social/users/akurilov", self.String())
 	assert.Nil(t, err)
@@ -24,7 +32,7 @@ func TestService_FetchActor(t *testing.T) {
 	if os.Getenv("CI") == "true" {
 		t.Skip()
 	}
-	svc := NewService(http.DefaultClient, "activitypub.awakari.com", []byte{}, nil)
+	svc := NewService(http.DefaultClient, "activitypub.awakari.com", signer.NewServiceMock(), nil)
 	actor, _, err := svc.FetchActor(context.TODO(), "https://mastodon.social/users/akurilov", "https://activitypub.awakari.com/actor#main-key")
 	assert.Equal(t, "https://mastodon.social/users/akurilov/inbox", actor.Inbox.GetLink().String())
 	assert.Nil(t, err)
@@ -34,9 +42,11 @@ func TestService_RequestFollow(t *testing.T) {
 	if os.Getenv("CI") == "true" {
 		t.Skip("Skipping test in CI environment")
 	}
-	privKey := []byte(`TODO: put private key pem here to test`)
-	svc := NewService(http.DefaultClient, "activitypub.awakari.com", privKey, nil)
-	err := svc.SendActivity(
+	svcKeys := keys.NewService(storageKeys.NewStorageMock(), "https://activitypub.awakari.com/actor")
+	svcSigner, err := signer.NewService(svcKeys, 16, time.Hour)
+	require.Nil(t, err)
+	svc := NewService(http.DefaultClient, "activitypub.awakari.com", svcSigner, nil)
+	err = svc.SendActivity(
 		context.TODO(),
 		vocab.Activity{
 			Type:    vocab.FollowType,
@@ -49,3 +59,91 @@ func TestService_RequestFollow(t *testing.T) {
 	)
 	assert.Nil(t, err)
 }
+
+// signerReparsing reproduces the former signing: the key is parsed and the signer is created for every request.
+type signerReparsing struct {
+	svcKeys keys.Service
+}
+
+func (s signerReparsing) Sign(ctx context.Context, req *http.Request, body []byte, pubKeyId string) (err error) {
+	var hs httpsig.Signer
+	hs, _, err = httpsig.NewSigner([]httpsig.Algorithm{httpsig.RSA_SHA256}, httpsig.DigestSha256, []string{httpsig.RequestTarget, "host", "date", "digest"}, httpsig.Signature, 120)
+	var priv any
+	if err == nil {
+		k, _ := s.svcKeys.Signing(ctx, pubKeyId)
+		priv, err = ssh.ParseRawPrivateKey([]byte(k.Private))
+	}
+	if err == nil {
+		err = hs.SignRequest(priv, pubKeyId, req, body)
+	}
+	return
+}
+
+func (s signerReparsing) Load(ctx context.Context, pubKeyId string) (err error) {
+	return
+}
+
+func BenchmarkService_SendActivity(b *testing.B) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	svcKeys := keys.NewService(storageKeys.NewLocalCache(storageKeys.NewStorageMock(), 16, time.Hour), "https://test.social/actor")
+	pubKeyId := "https://test.social/actor/bench#main-key"
+	_, err := svcKeys.Signing(context.TODO(), pubKeyId) // generate once
+	require.Nil(b, err)
+	a := vocab.Activity{
+		Type:    vocab.CreateType,
+		Context: vocab.IRI("https://www.w3.org/ns/activitystreams"),
+		Actor:   vocab.IRI("https://test.social/actor/bench"),
+		Object: vocab.Note{
+			Type:    vocab.NoteType,
+			Content: vocab.DefaultNaturalLanguageValue("Hello, world!"),
+		},
+	}
+	svcSigner, err := signer.NewService(svcKeys, 16, time.Hour)
+	require.Nil(b, err)
+	cases := map[string]signer.Service{
+		"parse per request": signerReparsing{
+			svcKeys: svcKeys,
+		},
+		"cached signer": svcSigner,
+	}
+	for k, s := range cases {
+		b.Run(k, func(b *testing.B) {
+			svc := NewService(srv.Client(), "test.social", s, nil)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					err := svc.SendActivity(context.TODO(), a, vocab.IRI(srv.URL+"/inbox"), pubKeyId)
+					if err != nil {
+						b.Error(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestService_FetchNodeInfo(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/nodeinfo":
+			_, _ = fmt.Fprintf(w, `{"links":[{"rel":"http://nodeinfo.diaspora.software/ns/schema/2.0","href":"%[1]s/nodeinfo/2.0"},{"rel":"http://nodeinfo.diaspora.software/ns/schema/2.1","href":"%[1]s/nodeinfo/2.1"}]}`, srv.URL)
+		case "/nodeinfo/2.1":
+			_, _ = w.Write([]byte(`{"version":"2.1","software":{"name":"akkoma"},"metadata":{"noindex":true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	svc := NewService(srv.Client(), "activitypub.awakari.com", signer.NewServiceMock(), nil)
+	host := srv.Listener.Addr().String()
+	ni, err := svc.FetchNodeInfo(context.TODO(), host)
+	require.Nil(t, err)
+	assert.Equal(t, "akkoma", ni.Software.Name)
+	assert.Equal(t, map[string]any{"noindex": true}, ni.Metadata)
+	_, err = svc.FetchNodeInfo(context.TODO(), "127.0.0.1:1")
+	assert.ErrorIs(t, err, ErrNodeInfoFetch)
+}
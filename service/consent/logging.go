@@ -0,0 +1,28 @@
+package consent
+
+import (
+	"context"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	vocab "github.com/go-ap/activitypub"
+	"log/slog"
+)
+
+type logging struct {
+	svc Service
+	log *slog.Logger
+}
+
+func NewLogging(svc Service, log *slog.Logger) Service {
+	return logging{
+		svc: svc,
+		log: log,
+	}
+}
+
+func (l logging) Evaluate(ctx context.Context, actor vocab.Actor, actorTags util.ObjectTags) (c model.Consent, err error) {
+	c, err = l.svc.Evaluate(ctx, actor, actorTags)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("consent.Evaluate(actor.Id=%s): %+v, %s", actor.ID, c, err))
+	return
+}
@@ -0,0 +1,20 @@
+package consent
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	vocab "github.com/go-ap/activitypub"
+)
+
+type mock struct {
+}
+
+func NewServiceMock() Service {
+	return mock{}
+}
+
+func (m mock) Evaluate(ctx context.Context, actor vocab.Actor, actorTags util.ObjectTags) (c model.Consent, err error) {
+	c = evaluateActor(actor, actorTags)
+	return
+}
@@ -0,0 +1,152 @@
+package consent
+
+import (
+	"context"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/util"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/microcosm-cc/bluemonday"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Service evaluates whether the remote actor consents to be followed and indexed.
+// The instance level opt-out applies to every actor hosted there.
+type Service interface {
+	Evaluate(ctx context.Context, actor vocab.Actor, actorTags util.ObjectTags) (c model.Consent, err error)
+}
+
+type service struct {
+	ap        activitypub.Service
+	nodeInfos *expirable.LRU[string, model.Consent]
+}
+
+// reOptOutTag matches the hashtags meaning the author doesn't want to be crawled or indexed.
+var reOptOutTag = regexp.MustCompile(`(?i)#(nobot|noindex|nosearch)\b`)
+
+var htmlStripTags = bluemonday.StrictPolicy()
+
+// actor properties that are true unless the actor opts out explicitly
+var optOutPropsFalse = []string{
+	"indexable",
+	"discoverable",
+}
+
+// nodeinfo metadata flags meaning the whole instance opts out when true
+var optOutMetaTrue = []string{
+	"nobot",
+	"noindex",
+	"nosearch",
+}
+
+// nodeinfo metadata flags meaning the whole instance opts out when false
+var optOutMetaFalse = []string{
+	"indexable",
+	"discoverable",
+}
+
+// NewService returns the consent evaluator which keeps up to the cacheSize instance decisions for the cacheTtl.
+func NewService(ap activitypub.Service, cacheSize int, cacheTtl time.Duration) Service {
+	return service{
+		ap:        ap,
+		nodeInfos: expirable.NewLRU[string, model.Consent](cacheSize, nil, cacheTtl),
+	}
+}
+
+func (svc service) Evaluate(ctx context.Context, actor vocab.Actor, actorTags util.ObjectTags) (c model.Consent, err error) {
+	c = evaluateActor(actor, actorTags)
+	if c.Allowed {
+		var addr *url.URL
+		addr, err = url.Parse(actor.ID.String())
+		if err == nil && addr.Host != "" {
+			c = svc.evaluateInstance(ctx, addr.Host)
+		}
+	}
+	return
+}
+
+func evaluateActor(actor vocab.Actor, actorTags util.ObjectTags) (c model.Consent) {
+	for _, t := range actorTags.Tag {
+		if tag := findOptOutTag(t.Name); tag != "" {
+			c.Reason = fmt.Sprintf("actor has the %s tag", tag)
+			return
+		}
+	}
+	for _, k := range optOutPropsFalse {
+		if string(actorTags.Extra[k]) == "false" {
+			c.Reason = fmt.Sprintf("actor has %s=false", k)
+			return
+		}
+	}
+	if tag := findOptOutTag(actor.Summary.String()); tag != "" {
+		c.Reason = fmt.Sprintf("actor summary contains %s", tag)
+		return
+	}
+	for _, f := range actorTags.Fields {
+		if tag := findOptOutTag(f.Name + " " + f.Value); tag != "" {
+			c.Reason = fmt.Sprintf("actor profile field \"%s\" contains %s", f.Name, tag)
+			return
+		}
+	}
+	c.Allowed = true
+	c.Reason = model.ConsentReasonNoOptOut
+	return
+}
+
+// findOptOutTag returns the opt-out hashtag found in the HTML text, lower case.
+// Mastodon renders the hashtags like "#<span>nobot</span>", so the markup is stripped first.
+func findOptOutTag(txt string) (tag string) {
+	if txt != "" {
+		txt = html.UnescapeString(htmlStripTags.Sanitize(txt))
+		tag = strings.ToLower(reOptOutTag.FindString(txt))
+	}
+	return
+}
+
+func (svc service) evaluateInstance(ctx context.Context, host string) (c model.Consent) {
+	var found bool
+	c, found = svc.nodeInfos.Get(host)
+	if !found {
+		// many servers don't provide nodeinfo, this doesn't mean an opt-out
+		ni, err := svc.ap.FetchNodeInfo(ctx, host)
+		c.Allowed = true
+		c.Reason = model.ConsentReasonNoOptOut
+		if err == nil {
+			c = evaluateMetadata(host, ni.Metadata)
+		}
+		svc.nodeInfos.Add(host, c)
+	}
+	return
+}
+
+func evaluateMetadata(host string, meta map[string]any) (c model.Consent) {
+	for _, k := range optOutMetaTrue {
+		if v, ok := meta[k].(bool); ok && v {
+			c.Reason = fmt.Sprintf("instance %s nodeinfo metadata has %s=true", host, k)
+			return
+		}
+	}
+	for _, k := range optOutMetaFalse {
+		if v, ok := meta[k].(bool); ok && !v {
+			c.Reason = fmt.Sprintf("instance %s nodeinfo metadata has %s=false", host, k)
+			return
+		}
+	}
+	for _, k := range []string{"nodeDescription", "description"} {
+		if s, ok := meta[k].(string); ok {
+			if tag := findOptOutTag(s); tag != "" {
+				c.Reason = fmt.Sprintf("instance %s nodeinfo metadata %s contains %s", host, k, tag)
+				return
+			}
+		}
+	}
+	c.Allowed = true
+	c.Reason = model.ConsentReasonNoOptOut
+	return
+}
@@ -0,0 +1,192 @@
+package consent
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/util"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestService_Evaluate(t *testing.T) {
+	svc := NewService(activitypub.NewServiceMock(), 16, time.Minute)
+	svc = NewLogging(svc, slog.Default())
+	cases := map[string]struct {
+		actor vocab.Actor
+		tags  util.ObjectTags
+		out   model.Consent
+	}{
+		"allowed": {
+			actor: vocab.Actor{
+				ID:      "https://host.social/users/johndoe",
+				Summary: vocab.DefaultNaturalLanguageValue("<p>Gopher. <a href=\"https://host.social/tags/bots\">#<span>bots</span></a></p>"),
+			},
+			tags: util.ObjectTags{
+				Tag: []util.ActivityTag{
+					{
+						Name: "#nobotany",
+					},
+				},
+				Extra: map[string][]byte{
+					"indexable":    []byte("true"),
+					"discoverable": []byte("true"),
+				},
+			},
+			out: model.Consent{
+				Allowed: true,
+				Reason:  model.ConsentReasonNoOptOut,
+			},
+		},
+		"nobot tag": {
+			actor: vocab.Actor{
+				ID: "https://host.social/users/johndoe",
+			},
+			tags: util.ObjectTags{
+				Tag: []util.ActivityTag{
+					{
+						Name: "#NoBot",
+					},
+				},
+			},
+			out: model.Consent{
+				Reason: "actor has the #nobot tag",
+			},
+		},
+		"not indexable": {
+			actor: vocab.Actor{
+				ID: "https://host.social/users/johndoe",
+			},
+			tags: util.ObjectTags{
+				Extra: map[string][]byte{
+					"indexable": []byte("false"),
+				},
+			},
+			out: model.Consent{
+				Reason: "actor has indexable=false",
+			},
+		},
+		"not discoverable": {
+			actor: vocab.Actor{
+				ID: "https://host.social/users/johndoe",
+			},
+			tags: util.ObjectTags{
+				Extra: map[string][]byte{
+					"discoverable": []byte("false"),
+				},
+			},
+			out: model.Consent{
+				Reason: "actor has discoverable=false",
+			},
+		},
+		"noindex in summary html": {
+			actor: vocab.Actor{
+				ID:      "https://host.social/users/johndoe",
+				Summary: vocab.DefaultNaturalLanguageValue("<p>Please <a href=\"https://host.social/tags/noindex\" class=\"mention hashtag\" rel=\"tag\">#<span>noindex</span></a></p>"),
+			},
+			out: model.Consent{
+				Reason: "actor summary contains #noindex",
+			},
+		},
+		"nosearch in profile field": {
+			actor: vocab.Actor{
+				ID: "https://host.social/users/johndoe",
+			},
+			tags: util.ObjectTags{
+				Fields: []util.ActorField{
+					{
+						Name:  "Website",
+						Value: "https://example.com",
+					},
+					{
+						Name:  "Search",
+						Value: "#NoSearch please",
+					},
+				},
+			},
+			out: model.Consent{
+				Reason: "actor profile field \"Search\" contains #nosearch",
+			},
+		},
+		"instance opts out": {
+			actor: vocab.Actor{
+				ID: "https://noindex.social/users/johndoe",
+			},
+			out: model.Consent{
+				Reason: "instance noindex.social nodeinfo metadata has noindex=true",
+			},
+		},
+		"instance nodeinfo fails": {
+			actor: vocab.Actor{
+				ID: "https://fail.social/users/johndoe",
+			},
+			out: model.Consent{
+				Allowed: true,
+				Reason:  model.ConsentReasonNoOptOut,
+			},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			out, err := svc.Evaluate(context.TODO(), c.actor, c.tags)
+			assert.Nil(t, err)
+			assert.Equal(t, c.out, out)
+		})
+	}
+}
+
+func TestEvaluateMetadata(t *testing.T) {
+	cases := map[string]struct {
+		meta map[string]any
+		out  model.Consent
+	}{
+		"empty": {
+			out: model.Consent{
+				Allowed: true,
+				Reason:  model.ConsentReasonNoOptOut,
+			},
+		},
+		"not a bool": {
+			meta: map[string]any{
+				"nobot":     "true",
+				"indexable": 0,
+			},
+			out: model.Consent{
+				Allowed: true,
+				Reason:  model.ConsentReasonNoOptOut,
+			},
+		},
+		"nobot": {
+			meta: map[string]any{
+				"nobot": true,
+			},
+			out: model.Consent{
+				Reason: "instance host.social nodeinfo metadata has nobot=true",
+			},
+		},
+		"not discoverable": {
+			meta: map[string]any{
+				"discoverable": false,
+			},
+			out: model.Consent{
+				Reason: "instance host.social nodeinfo metadata has discoverable=false",
+			},
+		},
+		"tag in description": {
+			meta: map[string]any{
+				"nodeDescription": "Small instance, #nobot",
+			},
+			out: model.Consent{
+				Reason: "instance host.social nodeinfo metadata nodeDescription contains #nobot",
+			},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, c.out, evaluateMetadata("host.social", c.meta))
+		})
+	}
+}
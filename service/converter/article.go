@@ -0,0 +1,85 @@
+package converter
+
+import (
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"strings"
+)
+
+// convertArticle separates the long-form article's title and summary from the body, converts the body HTML to the
+// plain text limited by the configured length and uses the article's url as the canonical link, e.g. WriteFreely,
+// Plume, WordPress and Ghost set the "id" to the JSON endpoint.
+func (svc service) convertArticle(obj *vocab.Object, evt *pb.CloudEvent) {
+	if name := htmlValueToText(obj.Name); name != "" {
+		evt.Attributes[CeKeyTitle] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: name,
+			},
+		}
+	}
+	summ := htmlValueToText(obj.Summary)
+	if summ != "" {
+		evt.Attributes[CeKeySummary] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: summ,
+			},
+		}
+	}
+	txt := htmlValueToText(obj.Content)
+	if txt == "" {
+		txt = summ
+	}
+	if svc.articleLenMax > 0 {
+		txt = truncateStringUtf8(txt, svc.articleLenMax)
+	}
+	if txt != "" {
+		evt.Data = &pb.CloudEvent_TextData{
+			TextData: txt,
+		}
+	}
+	if addr := canonicalUrl(obj.URL); addr != "" {
+		evt.Attributes[CeKeyObjectUrl] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeUri{
+				CeUri: addr,
+			},
+		}
+	}
+}
+
+func htmlValueToText(v vocab.NaturalLanguageValues) (txt string) {
+	if len(v) > 0 {
+		txt = util.HtmlToText(v.String())
+	}
+	return
+}
+
+// canonicalUrl returns the object's HTML page address, the 1st one when the media types are unknown.
+func canonicalUrl(u vocab.Item) (addr string) {
+	if u == nil {
+		return
+	}
+	switch uT := u.(type) {
+	case vocab.ItemCollection:
+		for _, item := range uT {
+			if item == nil {
+				continue
+			}
+			if l, isLink := item.(*vocab.Link); isLink && strings.HasPrefix(string(l.MediaType), "text/html") {
+				addr = l.Href.String()
+				break
+			}
+			if addr == "" {
+				addr = canonicalUrl(item)
+			}
+		}
+	case *vocab.Link:
+		addr = uT.Href.String()
+	default:
+		addr = u.GetLink().String()
+	}
+	if !strings.HasPrefix(addr, "https://") && !strings.HasPrefix(addr, "http://") {
+		addr = ""
+	}
+	return
+}
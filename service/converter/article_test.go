@@ -0,0 +1,137 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestService_ConvertActivityToEvent_Article(t *testing.T) {
+	cases := map[string]struct {
+		in      string
+		lenMax  int
+		txt     string
+		attrs   map[string]*pb.CloudEventAttributeValue
+		missing []string
+	}{
+		"wordpress": {
+			in: `{
+				"id": "https://blog.example/?p=42#create",
+				"type": "Create",
+				"actor": "https://blog.example/author/jane/",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://blog.example/?p=42",
+					"type": "Article",
+					"name": "Generics &amp; iterators",
+					"summary": "<p>How the range-over-func <em>works</em>.</p>",
+					"content": "<h2>Intro</h2><p>Go 1.23 added the iterators.</p><ul><li>push</li><li>pull</li></ul><script>track()</script>",
+					"url": "https://blog.example/2024/06/generics-iterators/",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"]
+				}
+			}`,
+			txt: "Intro\n\nGo 1.23 added the iterators.\n\n- push\n- pull",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyTitle: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "Generics & iterators",
+					},
+				},
+				CeKeySummary: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "How the range-over-func works.",
+					},
+				},
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://blog.example/2024/06/generics-iterators/",
+					},
+				},
+			},
+			missing: []string{CeKeyContentWarning},
+		},
+		"plume url links, truncated": {
+			in: `{
+				"id": "https://plume.example/~/Blog/post/activity",
+				"type": "Create",
+				"actor": "https://plume.example/@/jane/",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://plume.example/~/Blog/post/",
+					"type": "Article",
+					"name": "Long read",
+					"content": "<p>0123456789 0123456789</p>",
+					"url": [
+						{"type": "Link", "mediaType": "application/activity+json", "href": "https://plume.example/~/Blog/post.json"},
+						{"type": "Link", "mediaType": "text/html", "href": "https://plume.example/~/Blog/post"}
+					],
+					"to": ["https://www.w3.org/ns/activitystreams#Public"]
+				}
+			}`,
+			lenMax: 13,
+			txt:    "0123456789...",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://plume.example/~/Blog/post",
+					},
+				},
+			},
+			missing: []string{CeKeySummary},
+		},
+		"summary only": {
+			in: `{
+				"id": "https://ghost.example/p/1/activity",
+				"type": "Create",
+				"actor": "https://ghost.example/.ghost/activitypub/users/index",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://ghost.example/.ghost/activitypub/article/1",
+					"type": "Article",
+					"name": "Teaser",
+					"summary": "Read the full post on the site",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"]
+				}
+			}`,
+			txt: "Read the full post on the site",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://ghost.example/.ghost/activitypub/article/1",
+					},
+				},
+			},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			svc := NewService(Options{
+				CeType:        "foo",
+				UrlBase:       "urlBase",
+				ActorType:     vocab.ServiceType,
+				Policy:        policyTest,
+				ArticleLenMax: c.lenMax,
+				Rewrites:      model.UrlRewritesBuiltin,
+			}, nil)
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID: env.Activity.Actor.GetLink(),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			require.Nil(t, err)
+			assert.Equal(t, c.txt, evt.GetTextData())
+			for k, v := range c.attrs {
+				assert.Equal(t, v, evt.Attributes[k], k)
+			}
+			for _, k := range c.missing {
+				assert.NotContains(t, evt.Attributes, k)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	"strings"
+)
+
+// bridgedUriKeys are the attributes having the addresses of the posts and the actors the bridge rewrites.
+var bridgedUriKeys = []string{
+	CeKeyObjectUrl,
+	CeKeyInReplyTo,
+	CeKeyQuoteOf,
+	CeKeyOrganizer,
+}
+
+// convertBridged points the source, the object, the author and the referenced posts addresses of the bridged
+// account to the network of origin and names this network in the "bridge" attribute.
+func (svc service) convertBridged(evt *pb.CloudEvent) {
+	var bridge, b string
+	evt.Source, bridge = svc.rewrites.Rewrite(evt.Source)
+	for _, k := range bridgedUriKeys {
+		addr := evt.Attributes[k].GetCeUri()
+		if addr == "" {
+			continue
+		}
+		addr, b = svc.rewrites.Rewrite(addr)
+		if bridge == "" && k == CeKeyObjectUrl {
+			bridge = b
+		}
+		evt.Attributes[k] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeUri{
+				CeUri: addr,
+			},
+		}
+	}
+	if attrMentions, present := evt.Attributes[CeKeyMentions]; present {
+		mentions := strings.Fields(attrMentions.GetCeString())
+		for i, m := range mentions {
+			mentions[i], _ = svc.rewrites.Rewrite(m)
+		}
+		evt.Attributes[CeKeyMentions] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: strings.Join(mentions, " "),
+			},
+		}
+	}
+	if bridge != "" {
+		evt.Attributes[CeKeyBridge] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: bridge,
+			},
+		}
+	}
+}
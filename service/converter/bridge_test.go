@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestService_ConvertActivityToEvent_Bridged(t *testing.T) {
+	rewrites, err := model.NewUrlRewrites(`gts ^https://gts\.example/users/([^/]+)$ https://gts.example/@$1`, true)
+	require.Nil(t, err)
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "urlBase",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  rewrites,
+	}, nil)
+	cases := map[string]struct {
+		in     string
+		src    string
+		attrs  map[string]*pb.CloudEventAttributeValue
+		bridge string
+	}{
+		"bluesky": {
+			in: `{
+				"id": "https://bsky.brid.gy/convert/ap/at://did:plc:abc/app.bsky.feed.post/3kxyz#create",
+				"type": "Create",
+				"actor": "https://bsky.brid.gy/ap/did:plc:abc",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://bsky.brid.gy/convert/ap/at://did:plc:abc/app.bsky.feed.post/3kxyz",
+					"type": "Note",
+					"content": "hello from bluesky",
+					"inReplyTo": "https://bsky.brid.gy/convert/ap/at://did:plc:def/app.bsky.feed.post/3kabc",
+					"tag": [
+						{"type": "Mention", "name": "@def.bsky.social", "href": "https://bsky.brid.gy/ap/did:plc:def"},
+						{"type": "Mention", "name": "@alice@mastodon.social", "href": "https://mastodon.social/users/alice"}
+					]
+				}
+			}`,
+			src: "https://bsky.app/profile/did:plc:abc",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://bsky.app/profile/did:plc:abc/post/3kxyz",
+					},
+				},
+				CeKeyInReplyTo: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://bsky.app/profile/did:plc:def/post/3kabc",
+					},
+				},
+				CeKeyMentions: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "https://bsky.app/profile/did:plc:def https://mastodon.social/users/alice",
+					},
+				},
+			},
+			bridge: "bluesky",
+		},
+		"nostr": {
+			in: `{
+				"id": "https://mostr.pub/objects/9f3c9d5f1e2a4b6c8d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e/activity",
+				"type": "Create",
+				"actor": "https://mostr.pub/users/0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mostr.pub/objects/9f3c9d5f1e2a4b6c8d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e",
+					"type": "Note",
+					"content": "gm"
+				}
+			}`,
+			src: "https://njump.me/0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://njump.me/9f3c9d5f1e2a4b6c8d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e",
+					},
+				},
+			},
+			bridge: "nostr",
+		},
+		"threads, kept as is": {
+			in: `{
+				"id": "https://threads.net/ap/users/17841400000000000/post/17900000000000000/activity",
+				"type": "Create",
+				"actor": "https://threads.net/ap/users/17841400000000000/",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://threads.net/ap/users/17841400000000000/post/17900000000000000/",
+					"type": "Note",
+					"content": "hello from threads"
+				}
+			}`,
+			src: "https://threads.net/ap/users/17841400000000000/",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://threads.net/ap/users/17841400000000000/post/17900000000000000/",
+					},
+				},
+			},
+			bridge: "threads",
+		},
+		"custom rule": {
+			in: `{
+				"id": "https://gts.example/users/bob/statuses/1/activity",
+				"type": "Create",
+				"actor": "https://gts.example/users/bob",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://gts.example/users/bob/statuses/1",
+					"type": "Note",
+					"content": "hi"
+				}
+			}`,
+			src:    "https://gts.example/@bob",
+			bridge: "gts",
+		},
+		"not bridged": {
+			in: `{
+				"id": "https://mastodon.social/users/alice/statuses/1/activity",
+				"type": "Create",
+				"actor": "https://mastodon.social/users/alice",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.social/users/alice/statuses/1",
+					"type": "Note",
+					"content": "hi"
+				}
+			}`,
+			src: "https://mastodon.social/users/alice",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID: env.Activity.Actor.GetLink(),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			require.Nil(t, err)
+			assert.Equal(t, c.src, evt.Source)
+			for k, v := range c.attrs {
+				assert.Equal(t, v, evt.Attributes[k], k)
+			}
+			switch c.bridge {
+			case "":
+				assert.NotContains(t, evt.Attributes, CeKeyBridge)
+			default:
+				assert.Equal(t, c.bridge, evt.Attributes[CeKeyBridge].GetCeString())
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package converter
+
+import (
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"strconv"
+	"strings"
+)
+
+// convertEvent adds the Event's properties the activitypub library doesn't decode: the timezone, the join mode,
+// the participant count, the organizer and the place details.
+func convertEvent(e util.ActivityEvent, evt *pb.CloudEvent) {
+	if e.Timezone != "" {
+		evt.Attributes[CeKeyTimezone] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: e.Timezone,
+			},
+		}
+	}
+	if e.JoinMode != "" {
+		evt.Attributes[CeKeyJoinMode] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: e.JoinMode,
+			},
+		}
+	}
+	if e.ParticipantCount > 0 {
+		evt.Attributes[CeKeyParticipants] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeInteger{
+				CeInteger: int32(e.ParticipantCount),
+			},
+		}
+	}
+	if strings.HasPrefix(e.Organizer, "https://") || strings.HasPrefix(e.Organizer, "http://") {
+		evt.Attributes[CeKeyOrganizer] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeUri{
+				CeUri: e.Organizer,
+			},
+		}
+	}
+	p := e.Place
+	if p == nil {
+		return
+	}
+	if p.Name != "" {
+		evt.Attributes[CeKeyPlace] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: p.Name,
+			},
+		}
+	}
+	if p.Address != "" {
+		evt.Attributes[CeKeyAddress] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: p.Address,
+			},
+		}
+	}
+	switch p.HasCoords {
+	case true:
+		evt.Attributes[CeKeyLatitude] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: fmt.Sprintf("%f", p.Latitude),
+			},
+		}
+		evt.Attributes[CeKeyLongitude] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: fmt.Sprintf("%f", p.Longitude),
+			},
+		}
+	default:
+		// the place without the coordinates is decoded by the activitypub library as the zero ones
+		delete(evt.Attributes, CeKeyLatitude)
+		delete(evt.Attributes, CeKeyLongitude)
+	}
+}
+
+// outboundEventAttrs are the event-specific attributes, at least one is required besides the start time
+// to publish the Event.
+var outboundEventAttrs = []string{
+	CeKeyEnds,
+	CeKeyPlace,
+	CeKeyAddress,
+	CeKeyLatitude,
+	CeKeyTimezone,
+	CeKeyJoinMode,
+	CeKeyParticipants,
+	CeKeyOrganizer,
+}
+
+// isOutboundEvent returns true when the event has the start time and either is the Event originally or has some
+// other event-specific attribute. The start time alone is also a scheduled post's or a stream's one.
+func isOutboundEvent(evt *pb.CloudEvent, ceObj string) (ok bool) {
+	if attr, present := evt.Attributes[CeKeyStarts]; !present || attr.GetCeTimestamp() == nil {
+		return
+	}
+	ok = ceObj == string(vocab.EventType)
+	for _, k := range outboundEventAttrs {
+		if ok {
+			break
+		}
+		_, ok = evt.Attributes[k]
+	}
+	return
+}
+
+// outboundEvent wraps the object into the Event having the event's schedule and place.
+func outboundEvent(evt *pb.CloudEvent, obj vocab.Object, name string) (e model.Event) {
+	e.Object = obj
+	e.Type = vocab.EventType
+	if title := attrString(evt, CeKeyTitle); title != "" {
+		name = title
+	}
+	if name != "" {
+		e.Name = vocab.DefaultNaturalLanguageValue(name)
+	}
+	if attr, present := evt.Attributes[CeKeyStarts]; present && attr.GetCeTimestamp() != nil {
+		e.StartTime = attr.GetCeTimestamp().AsTime()
+	}
+	if attr, present := evt.Attributes[CeKeyEnds]; present && attr.GetCeTimestamp() != nil {
+		e.EndTime = attr.GetCeTimestamp().AsTime()
+	}
+	e.Timezone = attrString(evt, CeKeyTimezone)
+	e.JoinMode = attrString(evt, CeKeyJoinMode)
+	e.ParticipantCount = attrInt(evt, CeKeyParticipants)
+	if org := attrString(evt, CeKeyOrganizer); strings.HasPrefix(org, "https://") || strings.HasPrefix(org, "http://") {
+		e.Organizer = org
+	}
+	p := model.EventPlace{
+		Name:    attrString(evt, CeKeyPlace),
+		Address: attrString(evt, CeKeyAddress),
+	}
+	lat, errLat := strconv.ParseFloat(attrString(evt, CeKeyLatitude), 64)
+	lon, errLon := strconv.ParseFloat(attrString(evt, CeKeyLongitude), 64)
+	if errLat == nil && errLon == nil && lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180 {
+		p.Latitude, p.Longitude, p.HasCoords = lat, lon, true
+	}
+	if p.Name != "" || p.Address != "" || p.HasCoords {
+		e.Place = &p
+	}
+	return
+}
@@ -0,0 +1,327 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func TestService_ConvertActivityToEvent_Event(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "urlBase",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	cases := map[string]struct {
+		in      string
+		attrs   map[string]*pb.CloudEventAttributeValue
+		missing []string
+	}{
+		"mobilizon": {
+			in: `{
+				"id": "https://mobilizon.fr/events/1/activity",
+				"type": "Create",
+				"actor": "https://mobilizon.fr/@gophers",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mobilizon.fr/events/1",
+					"type": "Event",
+					"name": "Go meetup",
+					"content": "<p>Talks and pizza</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"startTime": "2024-05-01T18:00:00+02:00",
+					"endTime": "2024-05-01T21:00:00+02:00",
+					"timezone": "Europe/Paris",
+					"joinMode": "free",
+					"participantCount": 12,
+					"actor": "https://mobilizon.fr/@johndoe",
+					"attributedTo": "https://mobilizon.fr/@gophers",
+					"location": {
+						"type": "Place",
+						"name": "La Cantine",
+						"address": {
+							"type": "PostalAddress",
+							"streetAddress": "10 Rue de Rivoli",
+							"addressLocality": "Paris"
+						},
+						"latitude": 48.8566,
+						"longitude": 2.3522
+					}
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObject: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "Event",
+					},
+				},
+				CeKeyStarts: {
+					Attr: &pb.CloudEventAttributeValue_CeTimestamp{
+						CeTimestamp: timestamppb.New(time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC)),
+					},
+				},
+				CeKeyEnds: {
+					Attr: &pb.CloudEventAttributeValue_CeTimestamp{
+						CeTimestamp: timestamppb.New(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)),
+					},
+				},
+				CeKeyTimezone: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "Europe/Paris",
+					},
+				},
+				CeKeyJoinMode: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "free",
+					},
+				},
+				CeKeyParticipants: {
+					Attr: &pb.CloudEventAttributeValue_CeInteger{
+						CeInteger: 12,
+					},
+				},
+				CeKeyOrganizer: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://mobilizon.fr/@johndoe",
+					},
+				},
+				CeKeyPlace: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "La Cantine",
+					},
+				},
+				CeKeyAddress: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "10 Rue de Rivoli, Paris",
+					},
+				},
+				CeKeyLatitude: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "48.856600",
+					},
+				},
+				CeKeyLongitude: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "2.352200",
+					},
+				},
+			},
+		},
+		"gancio without coordinates": {
+			in: `{
+				"id": "https://gancio.example/federation/m/1#create",
+				"type": "Create",
+				"actor": "https://gancio.example/federation/u/relay",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://gancio.example/federation/m/1",
+					"type": "Event",
+					"name": "Concert",
+					"content": "<p>Live music</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"startTime": "2024-05-01T20:00:00Z",
+					"attributedTo": "https://gancio.example/federation/u/relay",
+					"location": {"type": "Place", "name": "Main square", "address": "Piazza 1, Torino"}
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyPlace: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "Main square",
+					},
+				},
+				CeKeyAddress: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "Piazza 1, Torino",
+					},
+				},
+				CeKeyOrganizer: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://gancio.example/federation/u/relay",
+					},
+				},
+			},
+			missing: []string{CeKeyLatitude, CeKeyLongitude, CeKeyTimezone, CeKeyJoinMode, CeKeyParticipants, CeKeyEnds},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID: env.Activity.Actor.GetLink(),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			require.Nil(t, err)
+			for k, v := range c.attrs {
+				assert.Equal(t, v, evt.Attributes[k], k)
+			}
+			for _, k := range c.missing {
+				assert.NotContains(t, evt.Attributes, k)
+			}
+		})
+	}
+}
+
+func TestService_ConvertEventToActivity_Event(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "https://int.example",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	starts := time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		in    *pb.CloudEvent
+		event *model.Event
+	}{
+		"event with place": {
+			in: &pb.CloudEvent{
+				Id:     "evt1",
+				Source: "https://mobilizon.fr/@gophers",
+				Attributes: map[string]*pb.CloudEventAttributeValue{
+					CeKeyObject: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "Event"},
+					},
+					CeKeyTitle: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "Go meetup"},
+					},
+					CeKeyStarts: {
+						Attr: &pb.CloudEventAttributeValue_CeTimestamp{CeTimestamp: timestamppb.New(starts)},
+					},
+					CeKeyEnds: {
+						Attr: &pb.CloudEventAttributeValue_CeTimestamp{CeTimestamp: timestamppb.New(starts.Add(3 * time.Hour))},
+					},
+					CeKeyTimezone: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "Europe/Paris"},
+					},
+					CeKeyJoinMode: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "free"},
+					},
+					CeKeyParticipants: {
+						Attr: &pb.CloudEventAttributeValue_CeInteger{CeInteger: 12},
+					},
+					CeKeyOrganizer: {
+						Attr: &pb.CloudEventAttributeValue_CeUri{CeUri: "https://mobilizon.fr/@akurilov"},
+					},
+					CeKeyPlace: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "La Cantine"},
+					},
+					CeKeyAddress: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "10 Rue de Rivoli, Paris"},
+					},
+					CeKeyLatitude: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "48.856600"},
+					},
+					CeKeyLongitude: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "2.352200"},
+					},
+				},
+				Data: &pb.CloudEvent_TextData{TextData: "Talks and pizza"},
+			},
+			event: &model.Event{
+				Timezone:         "Europe/Paris",
+				JoinMode:         "free",
+				ParticipantCount: 12,
+				Organizer:        "https://mobilizon.fr/@akurilov",
+				Place: &model.EventPlace{
+					Name:      "La Cantine",
+					Address:   "10 Rue de Rivoli, Paris",
+					Latitude:  48.8566,
+					Longitude: 2.3522,
+					HasCoords: true,
+				},
+			},
+		},
+		"starts and place, from telegram": {
+			in: &pb.CloudEvent{
+				Id:     "evt2",
+				Source: "@channel",
+				Attributes: map[string]*pb.CloudEventAttributeValue{
+					CeKeyStarts: {
+						Attr: &pb.CloudEventAttributeValue_CeTimestamp{CeTimestamp: timestamppb.New(starts)},
+					},
+					CeKeyPlace: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "La Cantine"},
+					},
+				},
+				Data: &pb.CloudEvent_TextData{TextData: "Talks and pizza"},
+			},
+			event: &model.Event{
+				Place: &model.EventPlace{
+					Name: "La Cantine",
+				},
+			},
+		},
+		"starts only, e.g. a stream": {
+			in: &pb.CloudEvent{
+				Id:     "evt4",
+				Source: "@channel",
+				Attributes: map[string]*pb.CloudEventAttributeValue{
+					CeKeyStarts: {
+						Attr: &pb.CloudEventAttributeValue_CeTimestamp{CeTimestamp: timestamppb.New(starts)},
+					},
+				},
+				Data: &pb.CloudEvent_TextData{TextData: "Live now"},
+			},
+		},
+		"starts of the original event only": {
+			in: &pb.CloudEvent{
+				Id:     "evt5",
+				Source: "https://mobilizon.fr/@gophers",
+				Attributes: map[string]*pb.CloudEventAttributeValue{
+					CeKeyObject: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "Event"},
+					},
+					CeKeyStarts: {
+						Attr: &pb.CloudEventAttributeValue_CeTimestamp{CeTimestamp: timestamppb.New(starts)},
+					},
+				},
+				Data: &pb.CloudEvent_TextData{TextData: "Talks and pizza"},
+			},
+			event: &model.Event{},
+		},
+		"event without start": {
+			in: &pb.CloudEvent{
+				Id:     "evt3",
+				Source: "https://mobilizon.fr/@gophers",
+				Attributes: map[string]*pb.CloudEventAttributeValue{
+					CeKeyObject: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "Event"},
+					},
+				},
+				Data: &pb.CloudEvent_TextData{TextData: "Talks and pizza"},
+			},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			a, err := svc.ConvertEventToActivity(context.TODO(), c.in, "interest1", nil, nil)
+			require.Nil(t, err)
+			e, isEvent := a.Object.(model.Event)
+			require.Equal(t, c.event != nil, isEvent)
+			if !isEvent {
+				assert.Equal(t, vocab.NoteType, a.Object.GetType())
+				return
+			}
+			assert.Equal(t, vocab.EventType, e.Type)
+			assert.Equal(t, starts, e.StartTime)
+			assert.NotEmpty(t, e.Name.String())
+			assert.Equal(t, c.event.Timezone, e.Timezone)
+			assert.Equal(t, c.event.JoinMode, e.JoinMode)
+			assert.Equal(t, c.event.ParticipantCount, e.ParticipantCount)
+			assert.Equal(t, c.event.Organizer, e.Organizer)
+			assert.Equal(t, c.event.Place, e.Place)
+		})
+	}
+}
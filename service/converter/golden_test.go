@@ -0,0 +1,111 @@
+package converter
+
+import (
+	"context"
+	"flag"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/bytedance/sonic"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in the testdata")
+
+// goldenInboxPayloads are the payloads shared with the util tests, these are read from util/testdata/inbox while the
+// golden files are in the testdata.
+var goldenInboxPayloads = []string{
+	"funkwhale_create_audio",
+	"peertube_create_video",
+}
+
+// goldenEvent is the stable representation of the event, the id is random and omitted. The missing data is null,
+// the event not to publish (e.g. the reaction) is null too.
+type goldenEvent struct {
+	Source     string         `json:"source"`
+	Type       string         `json:"type"`
+	Data       *string        `json:"data"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+func newGoldenEvent(evt *pb.CloudEvent) (g *goldenEvent) {
+	if evt == nil {
+		return
+	}
+	g = &goldenEvent{
+		Source: evt.Source,
+		Type:   evt.Type,
+	}
+	if d, ok := evt.Data.(*pb.CloudEvent_TextData); ok {
+		g.Data = &d.TextData
+	}
+	g.Attributes = make(map[string]any, len(evt.Attributes))
+	for k, v := range evt.Attributes {
+		switch a := v.Attr.(type) {
+		case *pb.CloudEventAttributeValue_CeString:
+			g.Attributes[k] = a.CeString
+		case *pb.CloudEventAttributeValue_CeUri:
+			g.Attributes[k] = a.CeUri
+		case *pb.CloudEventAttributeValue_CeInteger:
+			g.Attributes[k] = a.CeInteger
+		case *pb.CloudEventAttributeValue_CeBoolean:
+			g.Attributes[k] = a.CeBoolean
+		case *pb.CloudEventAttributeValue_CeTimestamp:
+			g.Attributes[k] = a.CeTimestamp.AsTime().Format(time.RFC3339)
+		}
+	}
+	return
+}
+
+// TestService_ConvertActivityToEvent_Golden converts the sample payloads from testdata/*.json and goldenInboxPayloads
+// and compares the results with testdata/*.golden.json. Run with -update to regenerate these after the intended
+// conversion change. The PeerTube and Funkwhale payloads are not captured deliveries: these follow the servers'
+// ActivityPub serializers field by field, but the hosts and the ids are made up.
+func TestService_ConvertActivityToEvent_Golden(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "urlBase",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	require.Nil(t, err)
+	for _, name := range goldenInboxPayloads {
+		files = append(files, filepath.Join("..", "..", "util", "testdata", "inbox", name+".json"))
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f, ".golden.json") {
+			continue
+		}
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			data, err := os.ReadFile(f)
+			require.Nil(t, err)
+			env, err := util.DecodeEnvelope(data)
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID: env.Activity.Actor.GetLink(),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			require.Nil(t, err)
+			var out []byte
+			out, err = sonic.ConfigStd.MarshalIndent(newGoldenEvent(evt), "", "  ")
+			require.Nil(t, err)
+			fGolden := filepath.Join("testdata", strings.TrimSuffix(filepath.Base(f), ".json")+".golden.json")
+			if *updateGolden {
+				require.Nil(t, os.WriteFile(fGolden, append(out, '\n'), 0644))
+			}
+			var expected []byte
+			expected, err = os.ReadFile(fGolden)
+			require.Nil(t, err)
+			assert.JSONEq(t, string(expected), string(out))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	"golang.org/x/text/language"
+	"math"
+	"sort"
+	"strings"
+)
+
+// convertLanguage sets the base language of the BCP-47 tag, e.g. "pt" for "pt-BR", and the region subtag when it's
+// explicit. Returns false when the tag is missing, undetermined or invalid.
+func convertLanguage(ref string, evt *pb.CloudEvent) (ok bool) {
+	if ref == "" || ref == "-" {
+		return
+	}
+	tag, err := language.Parse(strings.ReplaceAll(ref, "_", "-"))
+	if err != nil {
+		return
+	}
+	// the undetermined language is guessed by the library, e.g. "en" for "und", skip it
+	base, conf := tag.Base()
+	if conf != language.Exact {
+		return
+	}
+	evt.Attributes[CeKeyLanguage] = &pb.CloudEventAttributeValue{
+		Attr: &pb.CloudEventAttributeValue_CeString{
+			CeString: base.String(),
+		},
+	}
+	if region, conf := tag.Region(); conf == language.Exact {
+		evt.Attributes[CeKeyLanguageRegion] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: region.String(),
+			},
+		}
+	}
+	ok = true
+	return
+}
+
+// contentMapLanguage selects the language of the contentMap entry having the same content as the object. Otherwise,
+// selects the 1st language in the alphabetical order, so the result doesn't depend on the map iteration order.
+func contentMapLanguage(cm map[string]string, content string) (ref string) {
+	refs := make([]string, 0, len(cm))
+	for r := range cm {
+		if r != "" && r != "-" && r != language.Und.String() {
+			refs = append(refs, r)
+		}
+	}
+	sort.Strings(refs)
+	for _, r := range refs {
+		if content != "" && cm[r] == content {
+			ref = r
+			return
+		}
+	}
+	if len(refs) > 0 {
+		ref = refs[0]
+	}
+	return
+}
+
+// detectLanguage guesses the language by the event's plain text when the language is missing or undetermined. The
+// hashtags, the mentions and the links are not the natural language text and are skipped.
+func detectLanguage(evt *pb.CloudEvent) {
+	if l := evt.Attributes[CeKeyLanguage].GetCeString(); l != "" && l != language.Und.String() {
+		return
+	}
+	var words []string
+	for _, k := range []string{CeKeyTitle, CeKeySummary} {
+		words = appendLanguageWords(words, evt.Attributes[k].GetCeString())
+	}
+	words = appendLanguageWords(words, util.HtmlToText(evt.GetTextData()))
+	lang, confidence := util.DetectLanguage(strings.Join(words, " "))
+	if lang == "" {
+		delete(evt.Attributes, CeKeyLanguage)
+		return
+	}
+	evt.Attributes[CeKeyLanguage] = &pb.CloudEventAttributeValue{
+		Attr: &pb.CloudEventAttributeValue_CeString{
+			CeString: lang,
+		},
+	}
+	evt.Attributes[CeKeyLanguageConfidence] = &pb.CloudEventAttributeValue{
+		Attr: &pb.CloudEventAttributeValue_CeInteger{
+			CeInteger: int32(math.Round(100 * confidence)),
+		},
+	}
+}
+
+func appendLanguageWords(words []string, txt string) []string {
+	for _, w := range strings.Fields(txt) {
+		switch {
+		case strings.HasPrefix(w, "#"), strings.HasPrefix(w, "@"), strings.Contains(w, "://"):
+		case len(w) > 2 && strings.HasPrefix(w, ":") && strings.HasSuffix(w, ":"):
+			// custom emoji shortcode
+		default:
+			words = append(words, w)
+		}
+	}
+	return words
+}
@@ -0,0 +1,204 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestConvertLanguage(t *testing.T) {
+	cases := map[string]struct {
+		ref    string
+		ok     bool
+		lang   string
+		region string
+	}{
+		"empty": {},
+		"nil ref": {
+			ref: "-",
+		},
+		"undetermined": {
+			ref: "und",
+		},
+		"invalid": {
+			ref: "not a language",
+		},
+		"base": {
+			ref:  "de",
+			ok:   true,
+			lang: "de",
+		},
+		"region": {
+			ref:    "pt-BR",
+			ok:     true,
+			lang:   "pt",
+			region: "BR",
+		},
+		"underscore and case": {
+			ref:    "EN_gb",
+			ok:     true,
+			lang:   "en",
+			region: "GB",
+		},
+		"script without region": {
+			ref:  "zh-Hant",
+			ok:   true,
+			lang: "zh",
+		},
+		"3-letter code": {
+			ref:  "fil",
+			ok:   true,
+			lang: "fil",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			evt := &pb.CloudEvent{
+				Attributes: map[string]*pb.CloudEventAttributeValue{},
+			}
+			ok := convertLanguage(c.ref, evt)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.lang, evt.Attributes[CeKeyLanguage].GetCeString())
+			assert.Equal(t, c.region, evt.Attributes[CeKeyLanguageRegion].GetCeString())
+		})
+	}
+}
+
+func TestContentMapLanguage(t *testing.T) {
+	cases := map[string]struct {
+		cm      map[string]string
+		content string
+		ref     string
+	}{
+		"empty": {},
+		"matching content": {
+			cm: map[string]string{
+				"en": "<p>hello</p>",
+				"fr": "<p>bonjour</p>",
+			},
+			content: "<p>bonjour</p>",
+			ref:     "fr",
+		},
+		"no matching content": {
+			cm: map[string]string{
+				"nl": "<p>hallo</p>",
+				"de": "<p>hallo</p>",
+				"en": "<p>hello</p>",
+			},
+			content: "<p>hi</p>",
+			ref:     "de",
+		},
+		"undetermined skipped": {
+			cm: map[string]string{
+				"und":   "<p>hallo</p>",
+				"es-MX": "<p>hola</p>",
+			},
+			content: "<p>hallo</p>",
+			ref:     "es-MX",
+		},
+		"undetermined only": {
+			cm: map[string]string{
+				"und": "<p>hallo</p>",
+			},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				assert.Equal(t, c.ref, contentMapLanguage(c.cm, c.content))
+			}
+		})
+	}
+}
+
+func TestService_ConvertActivityToEvent_Language(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "urlBase",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	cases := map[string]struct {
+		in         string
+		lang       string
+		region     string
+		confidence int32
+	}{
+		"content map": {
+			in: `{
+				"type": "Create",
+				"actor": "https://mastodon.social/users/alice",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.social/users/alice/statuses/1",
+					"type": "Note",
+					"content": "<p>colour</p>",
+					"contentMap": {"en-US": "<p>color</p>", "en-GB": "<p>colour</p>"}
+				}
+			}`,
+			lang:   "en",
+			region: "GB",
+		},
+		"detected": {
+			in: `{
+				"type": "Create",
+				"actor": "https://gts.example/users/bob",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://gts.example/users/bob/statuses/1",
+					"type": "Note",
+					"content": "<p>Ich freue mich schon auf das Wochenende mit meinen Freunden <a href=\"https://gts.example/tags/wochenende\">#Wochenende</a></p>"
+				}
+			}`,
+			lang:       "de",
+			confidence: 100,
+		},
+		"undetermined content map, detected": {
+			in: `{
+				"type": "Create",
+				"actor": "https://gts.example/users/bob",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://gts.example/users/bob/statuses/2",
+					"type": "Note",
+					"content": "<p>Je suis tellement fatigué aujourd'hui, vivement les vacances</p>",
+					"contentMap": {"und": "<p>Je suis tellement fatigué aujourd'hui, vivement les vacances</p>"}
+				}
+			}`,
+			lang:       "fr",
+			confidence: 100,
+		},
+		"too short to detect": {
+			in: `{
+				"type": "Create",
+				"actor": "https://gts.example/users/bob",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://gts.example/users/bob/statuses/3",
+					"type": "Note",
+					"content": "<p>gm <a href=\"https://gts.example/@alice\">@alice</a> https://example.com/a/very/long/link/to/nowhere</p>"
+				}
+			}`,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID: env.Activity.Actor.GetLink(),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			require.Nil(t, err)
+			assert.Equal(t, c.lang, evt.Attributes[CeKeyLanguage].GetCeString())
+			assert.Equal(t, c.region, evt.Attributes[CeKeyLanguageRegion].GetCeString())
+			assert.Equal(t, c.confidence, evt.Attributes[CeKeyLanguageConfidence].GetCeInteger())
+		})
+	}
+}
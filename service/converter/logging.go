@@ -22,13 +22,13 @@ func NewLogging(svc Service, log *slog.Logger) Service {
 	}
 }
 
-func (l logging) ConvertActivityToEvent(ctx context.Context, actor vocab.Actor, activity vocab.Activity, tags util.ActivityTags, cm util.ActivityContentMap) (evt *pb.CloudEvent, err error) {
-	evt, err = l.svc.ConvertActivityToEvent(ctx, actor, activity, tags, cm)
+func (l logging) ConvertActivityToEvent(ctx context.Context, actor vocab.Actor, env util.Envelope) (evt *pb.CloudEvent, err error) {
+	evt, err = l.svc.ConvertActivityToEvent(ctx, actor, env)
 	switch evt {
 	case nil:
-		l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("converter.ConvertActivityToEvent(actor=%s, activity=%s, tags=%d, cm=%d): <nil>, %s", actor.ID, activity.ID, len(tags.Tag), len(cm.ContentMap), err))
+		l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("converter.ConvertActivityToEvent(actor=%s, activity=%s, tags=%d, cm=%d): <nil>, %s", actor.ID, env.Activity.ID, len(env.Tags)+len(env.Object.Tags), len(env.ContentMap), err))
 	default:
-		l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("converter.ConvertActivityToEvent(actor=%s, activity=%s, tags=%d, cm=%d): %s, %s", actor.ID, activity.ID, len(tags.Tag), len(cm.ContentMap), evt.Id, err))
+		l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("converter.ConvertActivityToEvent(actor=%s, activity=%s, tags=%d, cm=%d): %s, %s", actor.ID, env.Activity.ID, len(env.Tags)+len(env.Object.Tags), len(env.ContentMap), evt.Id, err))
 	}
 	return
 }
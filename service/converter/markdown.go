@@ -0,0 +1,129 @@
+package converter
+
+import (
+	"fmt"
+	vocab "github.com/go-ap/activitypub"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// mediaTypeMarkdown is the content type of the PeerTube video descriptions, the other servers send the HTML.
+const mediaTypeMarkdown = "text/markdown"
+
+var reMdHeading = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*$`)
+var reMdItemBullet = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+var reMdItemNum = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+var reMdQuote = regexp.MustCompile(`^\s*&gt;\s?(.*)$`)
+
+// reMdSpan matches either the code span, the link or the bare address, the emphasis is applied to none of these
+var reMdSpan = regexp.MustCompile("`([^`]+)`" + `|\[([^\]]+)\]\((https?://[^\s)]+)\)|(https?://[^\s<]*[^\s<.,:;!?)])`)
+var reMdBold = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+var reMdItalic = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_]+)_\b`)
+var reMdStrike = regexp.MustCompile(`~~([^~]+)~~`)
+
+type mdBlock int
+
+const (
+	mdBlockNone mdBlock = iota
+	mdBlockPara
+	mdBlockBullets
+	mdBlockNums
+	mdBlockQuote
+)
+
+var mdBlockTags = map[mdBlock][2]string{
+	mdBlockPara:    {"<p>", "</p>"},
+	mdBlockBullets: {"<ul>", "</ul>"},
+	mdBlockNums:    {"<ol>", "</ol>"},
+	mdBlockQuote:   {"<blockquote><p>", "</p></blockquote>"},
+}
+
+// objectContent returns the object's content as HTML, the markdown is converted.
+func objectContent(obj *vocab.Object) (content string) {
+	content = obj.Content.String()
+	if strings.HasPrefix(string(obj.MediaType), mediaTypeMarkdown) {
+		content = markdownToHtml(content)
+	}
+	return
+}
+
+// markdownToHtml converts the markdown subset used in the descriptions: the paragraphs, the headings, the lists,
+// the quotes, the emphasis, the code spans and the links. The source markup is escaped, the raw HTML is not kept.
+func markdownToHtml(src string) (dst string) {
+	src = strings.ReplaceAll(strings.TrimSpace(src), "\r\n", "\n")
+	if src == "" {
+		return
+	}
+	var sb strings.Builder
+	cur := mdBlockNone
+	closeBlock := func() {
+		if cur != mdBlockNone {
+			sb.WriteString(mdBlockTags[cur][1])
+			cur = mdBlockNone
+		}
+	}
+	for _, l := range strings.Split(html.EscapeString(src), "\n") {
+		var next mdBlock
+		var txt string
+		if strings.TrimSpace(l) == "" {
+			closeBlock()
+			continue
+		}
+		if m := reMdHeading.FindStringSubmatch(l); m != nil {
+			closeBlock()
+			_, _ = fmt.Fprintf(&sb, "<h%d>%s</h%d>", len(m[1]), markdownInline(m[2]), len(m[1]))
+			continue
+		}
+		if m := reMdItemBullet.FindStringSubmatch(l); m != nil {
+			next, txt = mdBlockBullets, "<li>"+markdownInline(m[1])+"</li>"
+		} else if m = reMdItemNum.FindStringSubmatch(l); m != nil {
+			next, txt = mdBlockNums, "<li>"+markdownInline(m[1])+"</li>"
+		} else if m = reMdQuote.FindStringSubmatch(l); m != nil {
+			next, txt = mdBlockQuote, markdownInline(m[1])
+		} else {
+			next, txt = mdBlockPara, markdownInline(strings.TrimSpace(l))
+		}
+		switch cur {
+		case next:
+			if next == mdBlockPara || next == mdBlockQuote {
+				sb.WriteString("<br/>")
+			}
+		default:
+			closeBlock()
+			cur = next
+			sb.WriteString(mdBlockTags[cur][0])
+		}
+		sb.WriteString(txt)
+	}
+	closeBlock()
+	dst = sb.String()
+	return
+}
+
+// markdownInline converts the inline markup of the escaped text, the code spans are kept verbatim.
+func markdownInline(txt string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range reMdSpan.FindAllStringSubmatchIndex(txt, -1) {
+		sb.WriteString(markdownEmphasis(txt[last:loc[0]]))
+		switch {
+		case loc[2] >= 0:
+			sb.WriteString("<code>" + txt[loc[2]:loc[3]] + "</code>")
+		case loc[4] >= 0:
+			sb.WriteString(`<a href="` + txt[loc[6]:loc[7]] + `">` + markdownEmphasis(txt[loc[4]:loc[5]]) + "</a>")
+		default:
+			sb.WriteString(`<a href="` + txt[loc[8]:loc[9]] + `">` + txt[loc[8]:loc[9]] + "</a>")
+		}
+		last = loc[1]
+	}
+	sb.WriteString(markdownEmphasis(txt[last:]))
+	return sb.String()
+}
+
+func markdownEmphasis(txt string) string {
+	txt = reMdBold.ReplaceAllString(txt, `<strong>$1$2</strong>`)
+	txt = reMdItalic.ReplaceAllString(txt, `<em>$1$2</em>`)
+	txt = reMdStrike.ReplaceAllString(txt, `<del>$1</del>`)
+	return txt
+}
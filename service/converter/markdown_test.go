@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_markdownToHtml(t *testing.T) {
+	cases := map[string]struct {
+		in  string
+		out string
+	}{
+		"empty": {},
+		"plain": {
+			in:  "Step by step guide.",
+			out: "<p>Step by step guide.</p>",
+		},
+		"emphasis": {
+			in:  "Step by step **guide**, _really_ *easy* ~~hard~~.",
+			out: "<p>Step by step <strong>guide</strong>, <em>really</em> <em>easy</em> <del>hard</del>.</p>",
+		},
+		"snake case is not emphasis": {
+			in:  "Set the max_len_total option.",
+			out: "<p>Set the max_len_total option.</p>",
+		},
+		"link and code": {
+			in:  "See [the __docs__](https://docs.example/a_b_c?x=1&y=2) and `**not bold**`.",
+			out: `<p>See <a href="https://docs.example/a_b_c?x=1&amp;y=2">the <strong>docs</strong></a> and <code>**not bold**</code>.</p>`,
+		},
+		"not http link": {
+			in:  "[click](javascript:alert(1))",
+			out: "<p>[click](javascript:alert(1))</p>",
+		},
+		"html is escaped": {
+			in:  "<script>alert(1)</script> & co",
+			out: "<p>&lt;script&gt;alert(1)&lt;/script&gt; &amp; co</p>",
+		},
+		"bare address": {
+			in:  "The code: https://codeberg.org/bob/gopher_robot_v2.",
+			out: `<p>The code: <a href="https://codeberg.org/bob/gopher_robot_v2">https://codeberg.org/bob/gopher_robot_v2</a>.</p>`,
+		},
+		"list after the paragraph line": {
+			in:  "Chapters:\n- the chassis\n- the _firmware_\nThat's it",
+			out: "<p>Chapters:</p><ul><li>the chassis</li><li>the <em>firmware</em></li></ul><p>That&#39;s it</p>",
+		},
+		"blocks": {
+			in:  "## Chapters\n\n- intro\n- **build**\n\n1. one\n2. two\n\n> quoted\n> twice\n\nline 1\r\nline 2",
+			out: "<h2>Chapters</h2><ul><li>intro</li><li><strong>build</strong></li></ul><ol><li>one</li><li>two</li></ol><blockquote><p>quoted<br/>twice</p></blockquote><p>line 1<br/>line 2</p>",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, c.out, markdownToHtml(c.in))
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/bytedance/sonic"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"mime"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Mastodon displays up to 4 attachments
+const countMediaMax = 4
+
+// convertMedia maps the event's attachment and image attributes to the typed attachments.
+// Returns also the 1st image address to be used as the object image.
+func (svc service) convertMedia(ctx context.Context, evt *pb.CloudEvent) (attachments vocab.ItemCollection, imgUrl string) {
+	attachments = vocab.ItemCollection{}
+	var medias []model.Media
+	if attAddr := attrString(evt, CeKeyAttachmentUrl); attAddr != "" {
+		m := model.Media{
+			Object: vocab.Object{
+				Type:      vocab.DocumentType,
+				MediaType: vocab.MimeType(model.ParseMediaType(attrString(evt, CeKeyAttachmentType))),
+				URL:       vocab.IRI(attAddr),
+			},
+			Width:      attrInt(evt, CeKeyAttachmentWidth),
+			Height:     attrInt(evt, CeKeyAttachmentHeight),
+			Blurhash:   attrString(evt, CeKeyAttachmentBlurhash),
+			FocalPoint: parseFocalPoint(attrString(evt, CeKeyAttachmentFocalPoint)),
+		}
+		if alt := attrString(evt, CeKeyAttachmentAlt); alt != "" {
+			m.Name = vocab.DefaultNaturalLanguageValue(alt)
+		}
+		medias = append(medias, m)
+	}
+	for _, k := range []string{CeKeyPreview, CeKeySrcImageUrl, CeKeyImageUrl} {
+		addr := attrString(evt, k)
+		if addr == "" {
+			continue
+		}
+		if imgUrl == "" {
+			imgUrl = addr
+		}
+		dup := false
+		for _, m := range medias {
+			if m.URL == vocab.IRI(addr) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			m := model.Media{
+				Object: vocab.Object{
+					Type: vocab.ImageType,
+					URL:  vocab.IRI(addr),
+				},
+			}
+			if alt := attrString(evt, CeKeyImageAlt); alt != "" {
+				m.Name = vocab.DefaultNaturalLanguageValue(alt)
+			}
+			medias = append(medias, m)
+		}
+	}
+	for i, m := range medias {
+		if i == countMediaMax {
+			break
+		}
+		svc.completeMedia(ctx, &m)
+		attachments = append(attachments, m)
+	}
+	return
+}
+
+// completeMedia fills the missing media type, dimensions and blurhash using the probe when it's available,
+// the media type is guessed by the file extension otherwise.
+func (svc service) completeMedia(ctx context.Context, m *model.Media) {
+	addr := m.URL.GetLink().String()
+	img := m.Type == vocab.ImageType || strings.HasPrefix(string(m.MediaType), "image/")
+	if svc.probe != nil && (m.MediaType == "" || img && (m.Width == 0 || m.Height == 0 || m.Blurhash == "")) {
+		meta, err := svc.probe.Probe(ctx, addr)
+		if err == nil {
+			if m.MediaType == "" {
+				m.MediaType = vocab.MimeType(meta.MediaType)
+			}
+			if m.Width == 0 || m.Height == 0 {
+				m.Width, m.Height = meta.Width, meta.Height
+			}
+			if m.Blurhash == "" {
+				m.Blurhash = meta.Blurhash
+			}
+		}
+	}
+	if m.MediaType == "" {
+		if u, err := url.Parse(addr); err == nil {
+			m.MediaType = vocab.MimeType(model.ParseMediaType(mime.TypeByExtension(strings.ToLower(path.Ext(u.Path)))))
+		}
+	}
+	m.Type = model.MediaObjectType(string(m.MediaType), m.Type)
+}
+
+// convertAttachmentsMeta keeps the inbound attachments metadata: the 1st attachment's properties go to the
+// separate attributes, the full list goes to the JSON attribute. The alt texts are appended to the event text, so the
+// posts having the images only are still matched and published.
+func convertAttachmentsMeta(atts []util.ActivityAttachment, evt *pb.CloudEvent) (err error) {
+	if len(atts) == 0 {
+		return
+	}
+	first := atts[0]
+	if first.Name != "" {
+		evt.Attributes[CeKeyAttachmentAlt] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: first.Name,
+			},
+		}
+	}
+	if first.Blurhash != "" {
+		evt.Attributes[CeKeyAttachmentBlurhash] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: first.Blurhash,
+			},
+		}
+	}
+	if first.Width > 0 && first.Height > 0 {
+		evt.Attributes[CeKeyAttachmentWidth] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeInteger{
+				CeInteger: int32(first.Width),
+			},
+		}
+		evt.Attributes[CeKeyAttachmentHeight] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeInteger{
+				CeInteger: int32(first.Height),
+			},
+		}
+	}
+	if len(first.FocalPoint) == 2 {
+		evt.Attributes[CeKeyAttachmentFocalPoint] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: fmt.Sprintf("%g,%g", first.FocalPoint[0], first.FocalPoint[1]),
+			},
+		}
+	}
+	var data []byte
+	data, err = sonic.ConfigStd.Marshal(atts)
+	switch err {
+	case nil:
+		evt.Attributes[CeKeyAttachments] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: string(data),
+			},
+		}
+	default:
+		err = fmt.Errorf("%w attachments: %s", ErrFail, err)
+	}
+	var alts []string
+	for _, att := range atts {
+		if att.Name != "" {
+			alts = append(alts, att.Name)
+		}
+	}
+	switch {
+	case len(alts) > 0:
+		txt := strings.Join(alts, "\n")
+		if prev := evt.GetTextData(); prev != "" {
+			txt = prev + "\n\n" + txt
+		}
+		evt.Data = &pb.CloudEvent_TextData{
+			TextData: txt,
+		}
+	case evt.Data == nil && hasSupportedMedia(atts):
+		// the photo without the caption and the alt text, e.g. Pixelfed, is still the publication
+		evt.Data = &pb.CloudEvent_TextData{}
+	}
+	return
+}
+
+// hasSupportedMedia returns true when any attachment is an image, a video or an audio, the media type is guessed by
+// the file extension when missing.
+func hasSupportedMedia(atts []util.ActivityAttachment) (ok bool) {
+	for _, att := range atts {
+		mt := model.ParseMediaType(att.MediaType)
+		if mt == "" {
+			if u, err := url.Parse(att.Url); err == nil {
+				mt = model.ParseMediaType(mime.TypeByExtension(strings.ToLower(path.Ext(u.Path))))
+			}
+		}
+		if ok = model.MediaObjectType(mt, "") != ""; ok {
+			break
+		}
+	}
+	return
+}
+
+// convertMediaObject keeps the Video or Audio object's title, duration and thumbnail and points the object url to
+// the HTML page instead of the JSON endpoint, e.g. for PeerTube and Funkwhale.
+func convertMediaObject(m util.ActivityMediaObject, evt *pb.CloudEvent) {
+	if m.PageUrl != "" {
+		evt.Attributes[CeKeyObjectUrl] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeUri{
+				CeUri: m.PageUrl,
+			},
+		}
+	}
+	if m.Title != "" {
+		evt.Attributes[CeKeyTitle] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: m.Title,
+			},
+		}
+		if evt.GetTextData() == "" {
+			evt.Data = &pb.CloudEvent_TextData{
+				TextData: m.Title,
+			}
+		}
+	}
+	if _, present := evt.Attributes[CeKeyLanguage]; !present {
+		convertLanguage(m.Language, evt)
+	}
+	if m.Duration > 0 {
+		evt.Attributes[CeKeyDuration] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeInteger{
+				CeInteger: int32(m.Duration.Seconds()),
+			},
+		}
+	}
+	if m.ThumbnailUrl != "" {
+		evt.Attributes[CeKeyImageUrl] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeUri{
+				CeUri: m.ThumbnailUrl,
+			},
+		}
+	}
+	for _, k := range []string{CeKeyIcon, CeKeyPreview} {
+		if attr, present := evt.Attributes[k]; present && attr.GetCeUri() == "" {
+			// the list of the thumbnails or the storyboard has no single link
+			delete(evt.Attributes, k)
+		}
+	}
+	if _, present := evt.Attributes[CeKeyAttachmentUrl]; !present && m.MediaUrl != "" {
+		evt.Attributes[CeKeyAttachmentUrl] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeUri{
+				CeUri: m.MediaUrl,
+			},
+		}
+		evt.Attributes[CeKeyAttachmentType] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: m.MediaType,
+			},
+		}
+	}
+}
+
+func attrString(evt *pb.CloudEvent, k string) (v string) {
+	if attr, present := evt.Attributes[k]; present {
+		v = attr.GetCeString()
+		if v == "" {
+			v = attr.GetCeUri()
+		}
+	}
+	return
+}
+
+func attrInt(evt *pb.CloudEvent, k string) (v int) {
+	if attr, present := evt.Attributes[k]; present {
+		v = int(attr.GetCeInteger())
+		if v == 0 {
+			v, _ = strconv.Atoi(attr.GetCeString())
+		}
+	}
+	return
+}
+
+// parseFocalPoint parses the "x,y" focal point, both coordinates should be in the range -1..1.
+func parseFocalPoint(src string) (fp []float64) {
+	xy := strings.Split(src, ",")
+	if len(xy) == 2 {
+		x, errX := strconv.ParseFloat(strings.TrimSpace(xy[0]), 64)
+		y, errY := strconv.ParseFloat(strings.TrimSpace(xy[1]), 64)
+		if errX == nil && errY == nil && x >= -1 && x <= 1 && y >= -1 && y <= 1 {
+			fp = []float64{x, y}
+		}
+	}
+	return
+}
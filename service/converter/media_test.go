@@ -0,0 +1,417 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/api/http/media"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestService_ConvertMedia(t *testing.T) {
+	cases := map[string]struct {
+		probe       media.Service
+		attrs       map[string]*pb.CloudEventAttributeValue
+		attachments vocab.ItemCollection
+		imgUrl      string
+	}{
+		"none": {
+			attachments: vocab.ItemCollection{},
+		},
+		"attachment w/o probe": {
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAttachmentUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://host/media/clip.MP4",
+					},
+				},
+				CeKeyAttachmentType: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "video",
+					},
+				},
+				CeKeyAttachmentAlt: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "a cat jumping",
+					},
+				},
+			},
+			attachments: vocab.ItemCollection{
+				model.Media{
+					Object: vocab.Object{
+						Type:      vocab.VideoType,
+						MediaType: "video/mp4",
+						Name:      vocab.DefaultNaturalLanguageValue("a cat jumping"),
+						URL:       vocab.IRI("https://host/media/clip.MP4"),
+					},
+				},
+			},
+		},
+		"attachment with the metadata": {
+			probe: media.NewMock(),
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAttachmentUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://host/media/cat",
+					},
+				},
+				CeKeyAttachmentType: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "image/webp",
+					},
+				},
+				CeKeyAttachmentWidth: {
+					Attr: &pb.CloudEventAttributeValue_CeInteger{
+						CeInteger: 1280,
+					},
+				},
+				CeKeyAttachmentHeight: {
+					Attr: &pb.CloudEventAttributeValue_CeInteger{
+						CeInteger: 720,
+					},
+				},
+				CeKeyAttachmentBlurhash: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "LEHV6nWB2yk8pyo0adR*.7kCMdnj",
+					},
+				},
+				CeKeyAttachmentFocalPoint: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "-0.5, 0.25",
+					},
+				},
+			},
+			attachments: vocab.ItemCollection{
+				model.Media{
+					Object: vocab.Object{
+						Type:      vocab.ImageType,
+						MediaType: "image/webp",
+						URL:       vocab.IRI("https://host/media/cat"),
+					},
+					Width:      1280,
+					Height:     720,
+					Blurhash:   "LEHV6nWB2yk8pyo0adR*.7kCMdnj",
+					FocalPoint: []float64{-0.5, 0.25},
+				},
+			},
+		},
+		"images probed and deduplicated": {
+			probe: media.NewMock(),
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAttachmentUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://host/media/clip.mp4",
+					},
+				},
+				CeKeyPreview: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://host/media/preview",
+					},
+				},
+				CeKeyImageUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "https://host/media/preview",
+					},
+				},
+				CeKeySrcImageUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "https://host/media/fail",
+					},
+				},
+				CeKeyImageAlt: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "preview",
+					},
+				},
+			},
+			attachments: vocab.ItemCollection{
+				model.Media{
+					Object: vocab.Object{
+						Type:      vocab.VideoType,
+						MediaType: "video/mp4",
+						URL:       vocab.IRI("https://host/media/clip.mp4"),
+					},
+				},
+				model.Media{
+					Object: vocab.Object{
+						Type:      vocab.ImageType,
+						MediaType: "image/png",
+						Name:      vocab.DefaultNaturalLanguageValue("preview"),
+						URL:       vocab.IRI("https://host/media/preview"),
+					},
+					Width:    640,
+					Height:   480,
+					Blurhash: "L00000fQfQfQfQfQfQfQfQfQfQfQ",
+				},
+				model.Media{
+					Object: vocab.Object{
+						Type: vocab.ImageType,
+						Name: vocab.DefaultNaturalLanguageValue("preview"),
+						URL:  vocab.IRI("https://host/media/fail"),
+					},
+				},
+			},
+			imgUrl: "https://host/media/preview",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			svc := NewService(Options{
+				CeType:    "foo",
+				UrlBase:   "https://base",
+				ActorType: vocab.ServiceType,
+				Policy:    policyTest,
+				Rewrites:  model.UrlRewritesBuiltin,
+			}, c.probe).(service)
+			evt := &pb.CloudEvent{
+				Attributes: c.attrs,
+			}
+			attachments, imgUrl := svc.convertMedia(context.TODO(), evt)
+			assert.Equal(t, c.attachments, attachments)
+			assert.Equal(t, c.imgUrl, imgUrl)
+		})
+	}
+}
+
+func TestParseFocalPoint(t *testing.T) {
+	cases := map[string][]float64{
+		"0,0":       {0, 0},
+		"-1, 1":     {-1, 1},
+		"0.5":       nil,
+		"2,0":       nil,
+		"foo,bar":   nil,
+		"0.1,0.2,0": nil,
+	}
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			assert.Equal(t, out, parseFocalPoint(in))
+		})
+	}
+}
+
+func TestService_ConvertActivityToEvent_Media(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "urlBase",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	cases := map[string]struct {
+		in     string
+		txt    string
+		attrs  map[string]*pb.CloudEventAttributeValue
+		noData bool
+	}{
+		"content warning, sensitive and attachments": {
+			in: `{
+				"id": "https://mastodon.social/users/johndoe/statuses/1/activity",
+				"type": "Create",
+				"actor": "https://mastodon.social/users/johndoe",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.social/users/johndoe/statuses/1",
+					"type": "Note",
+					"summary": "spiders",
+					"sensitive": true,
+					"content": "<p>look at this</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"attachment": [
+						{
+							"type": "Document",
+							"mediaType": "image/jpeg",
+							"url": "https://files.mastodon.social/1.jpg",
+							"name": "a jumping spider",
+							"blurhash": "UBL_:rOpGG-oBUNG",
+							"width": 1200,
+							"height": 800,
+							"focalPoint": [0, -0.5]
+						},
+						{
+							"type": "Document",
+							"mediaType": "image/jpeg",
+							"url": "https://files.mastodon.social/2.jpg",
+							"name": "a web"
+						}
+					]
+				}
+			}`,
+			txt: "<p>look at this</p>\n\na jumping spider\na web",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAttachmentUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://files.mastodon.social/1.jpg",
+					},
+				},
+				CeKeyAttachmentType: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "image/jpeg",
+					},
+				},
+				CeKeyAttachmentAlt: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "a jumping spider",
+					},
+				},
+				CeKeyAttachmentBlurhash: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "UBL_:rOpGG-oBUNG",
+					},
+				},
+				CeKeyAttachmentWidth: {
+					Attr: &pb.CloudEventAttributeValue_CeInteger{
+						CeInteger: 1200,
+					},
+				},
+				CeKeyAttachmentHeight: {
+					Attr: &pb.CloudEventAttributeValue_CeInteger{
+						CeInteger: 800,
+					},
+				},
+				CeKeyAttachmentFocalPoint: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "0,-0.5",
+					},
+				},
+				CeKeyAttachments: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: `[{"type":"Document","mediaType":"image/jpeg","url":"https://files.mastodon.social/1.jpg","name":"a jumping spider","blurhash":"UBL_:rOpGG-oBUNG","width":1200,"height":800,"focalPoint":[0,-0.5]},{"type":"Document","mediaType":"image/jpeg","url":"https://files.mastodon.social/2.jpg","name":"a web"}]`,
+					},
+				},
+				CeKeyContentWarning: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "spiders",
+					},
+				},
+				CeKeySensitive: {
+					Attr: &pb.CloudEventAttributeValue_CeBoolean{
+						CeBoolean: true,
+					},
+				},
+			},
+		},
+		"image without caption and alt text": {
+			in: `{
+				"id": "https://pixelfed.social/p/dana/1/activity",
+				"type": "Create",
+				"actor": "https://pixelfed.social/users/dana",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://pixelfed.social/p/dana/1",
+					"type": "Note",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"attachment": [
+						{
+							"type": "Image",
+							"mediaType": "image/jpeg",
+							"url": "https://pxscdn.com/public/m/1.jpg",
+							"name": null
+						}
+					]
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAttachmentUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://pxscdn.com/public/m/1.jpg",
+					},
+				},
+			},
+		},
+		"unsupported attachment without caption and alt text": {
+			in: `{
+				"id": "https://pixelfed.social/p/dana/2/activity",
+				"type": "Create",
+				"actor": "https://pixelfed.social/users/dana",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://pixelfed.social/p/dana/2",
+					"type": "Note",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"attachment": [
+						{
+							"type": "Document",
+							"mediaType": "application/zip",
+							"url": "https://pxscdn.com/public/m/2.zip"
+						}
+					]
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAttachmentUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://pxscdn.com/public/m/2.zip",
+					},
+				},
+			},
+			noData: true,
+		},
+		"image without media type, caption and alt text": {
+			in: `{
+				"id": "https://pixelfed.social/p/dana/3/activity",
+				"type": "Create",
+				"actor": "https://pixelfed.social/users/dana",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://pixelfed.social/p/dana/3",
+					"type": "Note",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"attachment": [
+						{
+							"type": "Document",
+							"url": "https://pxscdn.com/public/m/3.webp"
+						}
+					]
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAttachmentUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://pxscdn.com/public/m/3.webp",
+					},
+				},
+			},
+		},
+		"article summary is not a content warning": {
+			in: `{
+				"id": "https://blog.example/activity/1",
+				"type": "Create",
+				"actor": "https://blog.example/author",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://blog.example/posts/1",
+					"type": "Article",
+					"summary": "the abstract",
+					"content": "<p>the article</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"]
+				}
+			}`,
+			txt: "the article",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID: env.Activity.Actor.GetLink(),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			require.Nil(t, err)
+			assert.Equal(t, !c.noData, evt.Data != nil)
+			assert.Equal(t, c.txt, evt.GetTextData())
+			for k, v := range c.attrs {
+				assert.Equal(t, v, evt.Attributes[k], k)
+			}
+			if c.attrs == nil {
+				assert.NotContains(t, evt.Attributes, CeKeyContentWarning)
+				assert.NotContains(t, evt.Attributes, CeKeySensitive)
+				assert.NotContains(t, evt.Attributes, CeKeyAttachments)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package converter
+
+import (
+	"github.com/awakari/int-activitypub/util"
+	"github.com/bytedance/sonic"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+)
+
+const activityTypeEmojiReact vocab.ActivityVocabularyType = "EmojiReact"
+
+// propMisskeyContent is the MFM source of the Misskey-family (Misskey, Sharkey, Firefish, Iceshrimp) note.
+const propMisskeyContent = "_misskey_content"
+
+// propMisskeyReaction is the reaction emoji of the Misskey-family Like activity.
+const propMisskeyReaction = "_misskey_reaction"
+
+// emojiReaction returns true when the activity is the emoji reaction to a post: the Pleroma/Firefish EmojiReact or
+// the Like/Dislike having the reaction emoji as the content (Misskey, Sharkey). These have no own text to publish.
+// The plain Like is not considered as the reaction.
+func emojiReaction(env util.Envelope) (ok bool) {
+	switch env.Activity.Type {
+	case activityTypeEmojiReact:
+		ok = true
+	case vocab.LikeType, vocab.DislikeType:
+		_, ok = env.Extra[propMisskeyReaction]
+		ok = ok || env.Activity.Content.Count() > 0
+	}
+	return
+}
+
+// misskeySource returns the object's MFM source when present. Misskey sets both the "_misskey_content" and the
+// "source", others (Firefish, Iceshrimp) may set the latter only.
+func misskeySource(obj vocab.Item, extra map[string][]byte) (src string) {
+	if raw, present := extra[propMisskeyContent]; present {
+		_ = sonic.Unmarshal(raw, &src)
+	}
+	if src == "" && obj != nil && !obj.IsLink() {
+		_ = vocab.OnObject(obj, func(o *vocab.Object) error {
+			if o.Source.MediaType == util.MediaTypeMfm {
+				src = o.Source.Content.String()
+			}
+			return nil
+		})
+	}
+	return
+}
+
+// convertMisskeyContent replaces the event text with the plain text rendered from the MFM source: the HTML content
+// generated by Misskey keeps the unrendered function markup, e.g. "$[x2 ".
+func convertMisskeyContent(obj vocab.Item, extra map[string][]byte, evt *pb.CloudEvent) {
+	if src := misskeySource(obj, extra); src != "" {
+		if txt := util.MfmToText(src); txt != "" {
+			evt.Data = &pb.CloudEvent_TextData{
+				TextData: txt,
+			}
+		}
+	}
+}
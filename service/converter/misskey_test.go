@@ -0,0 +1,102 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestService_ConvertActivityToEvent_Misskey(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "urlBase",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	cases := map[string]struct {
+		in      string
+		skipped bool
+		txt     string
+	}{
+		"misskey reaction": {
+			in:      `{"type":"Like","actor":"https://misskey.io/users/1","object":"https://mastodon.social/users/alice/statuses/1","content":"⭐","_misskey_reaction":"⭐"}`,
+			skipped: true,
+		},
+		"sharkey custom emoji reaction without content": {
+			in:      `{"type":"Like","actor":"https://sharkey.example/users/1","object":"https://mastodon.social/users/alice/statuses/1","_misskey_reaction":":blobcat:"}`,
+			skipped: true,
+		},
+		"pleroma emoji react": {
+			in:      `{"type":"EmojiReact","actor":"https://pleroma.example/users/bob","object":"https://mastodon.social/users/alice/statuses/1","content":"🔥"}`,
+			skipped: true,
+		},
+		"plain like": {
+			in:  `{"type":"Like","actor":"https://mastodon.social/users/bob","object":"https://mastodon.social/users/alice/statuses/1","to":["https://www.w3.org/ns/activitystreams#Public"],"summary":"bob liked the post"}`,
+			txt: "bob liked the post",
+		},
+		"misskey content": {
+			in: `{
+				"type":"Create",
+				"actor":"https://misskey.io/users/1",
+				"to":["https://www.w3.org/ns/activitystreams#Public"],
+				"object":{
+					"id":"https://misskey.io/notes/1",
+					"type":"Note",
+					"content":"<p><span>$[tada </span><b><span>hello</span></b><span>]</span></p>",
+					"_misskey_content":"$[tada **hello**]"
+				}
+			}`,
+			txt: "hello",
+		},
+		"firefish source only": {
+			in: `{
+				"type":"Create",
+				"actor":"https://firefish.social/users/1",
+				"to":["https://www.w3.org/ns/activitystreams#Public"],
+				"object":{
+					"id":"https://firefish.social/notes/1",
+					"type":"Note",
+					"content":"<p>$[x2 big] <i>news</i></p>",
+					"source":{"content":"$[x2 big] <i>news</i>","mediaType":"text/x.misskeymarkdown"}
+				}
+			}`,
+			txt: "big news",
+		},
+		"markdown source is not mfm": {
+			in: `{
+				"type":"Create",
+				"actor":"https://example.social/users/1",
+				"to":["https://www.w3.org/ns/activitystreams#Public"],
+				"object":{
+					"id":"https://example.social/notes/1",
+					"type":"Note",
+					"content":"<p><strong>bold</strong></p>",
+					"source":{"content":"**bold**","mediaType":"text/markdown"}
+				}
+			}`,
+			txt: "<p><strong>bold</strong></p>",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID: env.Activity.Actor.GetLink(),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			require.Nil(t, err)
+			if c.skipped {
+				assert.Nil(t, evt)
+				return
+			}
+			require.NotNil(t, evt)
+			assert.Equal(t, c.txt, evt.GetTextData())
+		})
+	}
+}
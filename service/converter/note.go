@@ -0,0 +1,189 @@
+package converter
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed labels/*.json
+var labelFiles embed.FS
+
+// Labels are the localized captions of the links in the outbound Note.
+type Labels struct {
+	Origin   string `json:"origin"`
+	Interest string `json:"interest"`
+	Match    string `json:"match"`
+}
+
+// NoteFormat defines how the outbound Note content and summary are rendered.
+// The templates are HTML ones, so the data is escaped depending on the context, e.g. the unsafe URLs are filtered.
+type NoteFormat struct {
+	Content *template.Template
+
+	// Summary is rendered only when set. Most implementations display it as the content warning.
+	Summary *template.Template
+
+	// Labels by the 2-letter language code of the event, the English ones are used when missing.
+	Labels map[string]Labels
+
+	// LenMaxText is the budget of the event text length in characters.
+	LenMaxText int
+}
+
+// NoteData is the input of the Note templates.
+type NoteData struct {
+	// Text is the event text without the markup, already truncated.
+	Text        string
+	Language    string
+	Tags        []NoteTag
+	OriginUrl   string
+	InterestUrl string
+	MatchUrl    string
+	Labels      Labels
+}
+
+type NoteTag struct {
+	// Name including the leading "#".
+	Name string
+	Href string
+}
+
+const langDefault = "en"
+const lenMaxTextDefault = 200
+
+const TplContentDefault = `{{ .Text }}<br/>` +
+	`{{ if .Tags }}<br/>{{ range $i, $t := .Tags }}{{ if $i }} {{ end }}<a rel="tag" class="mention hashtag" href="{{ $t.Href }}">{{ $t.Name }}</a>{{ end }}{{ end }}` +
+	`<br/><br/><a href="{{ .OriginUrl }}">{{ .Labels.Origin }}</a> | <a href="{{ .InterestUrl }}">{{ .Labels.Interest }}</a> | <a href="{{ .MatchUrl }}">{{ .Labels.Match }}</a>`
+
+var ErrNoteFormat = errors.New("invalid note format")
+
+var tplContentDefault = template.Must(template.New("content").Parse(TplContentDefault))
+
+var labelsBundled = mustLoadLabelsBundled()
+
+// NewNoteFormat parses the templates, the empty content template means the default one.
+// The labels found in the labelsDir override the bundled ones, the files are named by the language, e.g. "de.json".
+func NewNoteFormat(tplContent, tplSummary, labelsDir string, lenMaxText int) (f NoteFormat, err error) {
+	if tplContent != "" {
+		f.Content, err = template.New("content").Parse(tplContent)
+	}
+	if err == nil && tplSummary != "" {
+		f.Summary, err = template.New("summary").Parse(tplSummary)
+	}
+	if err == nil && labelsDir != "" {
+		f.Labels, err = loadLabelsDir(labelsDir)
+	}
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrNoteFormat, err)
+	}
+	f.LenMaxText = lenMaxText
+	return
+}
+
+// withDefaults fills the format properties missing.
+func (f NoteFormat) withDefaults() NoteFormat {
+	if f.Content == nil {
+		f.Content = tplContentDefault
+	}
+	labels := make(map[string]Labels, len(labelsBundled)+len(f.Labels))
+	for lang, l := range labelsBundled {
+		labels[lang] = l
+	}
+	for lang, l := range f.Labels {
+		labels[lang] = l
+	}
+	f.Labels = labels
+	if f.LenMaxText <= 0 {
+		f.LenMaxText = lenMaxTextDefault
+	}
+	return f
+}
+
+func (f NoteFormat) labels(lang string) (l Labels) {
+	l, found := f.Labels[lang]
+	if !found {
+		l = f.Labels[langDefault]
+	}
+	return
+}
+
+func renderTemplate(tpl *template.Template, data NoteData) (txt string, err error) {
+	var sb strings.Builder
+	err = tpl.Execute(&sb, data)
+	switch err {
+	case nil:
+		txt = sb.String()
+	default:
+		err = fmt.Errorf("%w note %s: %s", ErrFail, tpl.Name(), err)
+	}
+	return
+}
+
+func mustLoadLabelsBundled() (labels map[string]Labels) {
+	entries, err := labelFiles.ReadDir("labels")
+	if err != nil {
+		panic(err)
+	}
+	labels = make(map[string]Labels, len(entries))
+	for _, e := range entries {
+		var data []byte
+		data, err = labelFiles.ReadFile("labels/" + e.Name())
+		var l Labels
+		if err == nil {
+			err = sonic.Unmarshal(data, &l)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("bundled labels %s: %s", e.Name(), err))
+		}
+		labels[strings.TrimSuffix(e.Name(), ".json")] = l
+	}
+	return
+}
+
+func loadLabelsDir(dir string) (labels map[string]Labels, err error) {
+	var paths []string
+	paths, err = filepath.Glob(filepath.Join(dir, "*.json"))
+	if err == nil {
+		labels = make(map[string]Labels, len(paths))
+	}
+	for _, p := range paths {
+		var data []byte
+		data, err = os.ReadFile(p)
+		var l Labels
+		if err == nil {
+			err = sonic.Unmarshal(data, &l)
+		}
+		if err != nil {
+			break
+		}
+		labels[strings.TrimSuffix(filepath.Base(p), ".json")] = l
+	}
+	return
+}
+
+// truncateStringUtf8 limits the string length in characters, the truncated string ends with "..." when the limit
+// leaves the room for it.
+func truncateStringUtf8(s string, lenMax int) string {
+	if utf8.RuneCountInString(s) <= lenMax {
+		return s
+	}
+	lenPrefix, suffix := lenMax-3, "..."
+	if lenPrefix < 0 {
+		lenPrefix, suffix = max(lenMax, 0), ""
+	}
+	var n int
+	for i := range s {
+		if n == lenPrefix {
+			return s[:i] + suffix
+		}
+		n++
+	}
+	return s
+}
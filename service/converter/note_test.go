@@ -0,0 +1,244 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewNoteFormat(t *testing.T) {
+	dirLabels := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dirLabels, "de.json"), []byte(`{"origin":"Ursprung","interest":"Interesse","match":"Treffer"}`), 0644))
+	dirLabelsInvalid := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dirLabelsInvalid, "de.json"), []byte(`{"origin":`), 0644))
+	cases := map[string]struct {
+		tplContent string
+		tplSummary string
+		labelsDir  string
+		labels     map[string]Labels
+		err        error
+	}{
+		"defaults": {},
+		"custom": {
+			tplContent: `{{ .Text }} <a href="{{ .OriginUrl }}">{{ .Labels.Origin }}</a>`,
+			tplSummary: `{{ .Language }}`,
+			labelsDir:  dirLabels,
+			labels: map[string]Labels{
+				"de": {
+					Origin:   "Ursprung",
+					Interest: "Interesse",
+					Match:    "Treffer",
+				},
+			},
+		},
+		"invalid content template": {
+			tplContent: `{{ .Text `,
+			err:        ErrNoteFormat,
+		},
+		"invalid summary template": {
+			tplSummary: `{{ end }}`,
+			err:        ErrNoteFormat,
+		},
+		"invalid labels": {
+			labelsDir: dirLabelsInvalid,
+			err:       ErrNoteFormat,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			f, err := NewNoteFormat(c.tplContent, c.tplSummary, c.labelsDir, 100)
+			assert.ErrorIs(t, err, c.err)
+			if c.err == nil {
+				assert.Equal(t, c.tplContent == "", f.Content == nil)
+				assert.Equal(t, c.tplSummary == "", f.Summary == nil)
+				assert.Equal(t, c.labels, f.Labels)
+				assert.Equal(t, 100, f.LenMaxText)
+			}
+		})
+	}
+}
+
+func TestNoteFormat_labels(t *testing.T) {
+	f := NoteFormat{
+		Labels: map[string]Labels{
+			"de": {
+				Origin: "Ursprung",
+			},
+		},
+	}.withDefaults()
+	assert.Equal(t, "Ursprung", f.labels("de").Origin)
+	assert.Equal(t, "Source", f.labels("fr").Origin)
+	assert.Equal(t, "Origin", f.labels("xx").Origin)
+	assert.Equal(t, lenMaxTextDefault, f.LenMaxText)
+}
+
+func Test_renderTemplate(t *testing.T) {
+	cases := map[string]struct {
+		data NoteData
+		out  string
+	}{
+		"plain": {
+			data: NoteData{
+				Text:        "Fish & chips",
+				OriginUrl:   "https://origin.social/@jane/1",
+				InterestUrl: "https://awakari.com/sub-details.html?id=interest0",
+				MatchUrl:    "https://reader/evt1&interestId=interest0",
+				Labels: Labels{
+					Origin:   "Origin",
+					Interest: "Interest",
+					Match:    "Match",
+				},
+			},
+			out: `Fish &amp; chips<br/><br/><br/><a href="https://origin.social/@jane/1">Origin</a> | <a href="https://awakari.com/sub-details.html?id=interest0">Interest</a> | <a href="https://reader/evt1&amp;interestId=interest0">Match</a>`,
+		},
+		"markup injection": {
+			data: NoteData{
+				Text: "<script>alert(1)</script>",
+				Tags: []NoteTag{
+					{
+						Name: "#x<img src=x onerror=alert(1)>",
+						Href: `https://base/tags/x" onmouseover="alert(1)`,
+					},
+				},
+				OriginUrl: "javascript:alert(1)",
+			},
+			out: `&lt;script&gt;alert(1)&lt;/script&gt;<br/><br/><a rel="tag" class="mention hashtag" href="https://base/tags/x%22%20onmouseover=%22alert%281%29">#x&lt;img src=x onerror=alert(1)&gt;</a><br/><br/><a href="#ZgotmplZ"></a> | <a href=""></a> | <a href=""></a>`,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			txt, err := renderTemplate(tplContentDefault, c.data)
+			assert.Nil(t, err)
+			assert.Equal(t, c.out, txt)
+		})
+	}
+}
+
+func Test_truncateStringUtf8(t *testing.T) {
+	cases := map[string]struct {
+		in     string
+		lenMax int
+		out    string
+	}{
+		"short": {
+			in:     "hello",
+			lenMax: 10,
+			out:    "hello",
+		},
+		"ascii": {
+			in:     "hello world",
+			lenMax: 8,
+			out:    "hello...",
+		},
+		"multibyte counted as chars": {
+			in:     "Привет, мир",
+			lenMax: 9,
+			out:    "Привет...",
+		},
+		"exact": {
+			in:     "Привет",
+			lenMax: 6,
+			out:    "Привет",
+		},
+		"no room for ellipsis": {
+			in:     "Привет",
+			lenMax: 2,
+			out:    "Пр",
+		},
+		"zero": {
+			in:     "hello",
+			lenMax: 0,
+			out:    "",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, c.out, truncateStringUtf8(c.in, c.lenMax))
+		})
+	}
+}
+
+func TestService_ConvertEventToActivity_Format(t *testing.T) {
+	f, err := NewNoteFormat("", `{{ .Labels.Match }}: {{ .Text }}`, "", 12)
+	require.Nil(t, err)
+	svc := NewService(Options{
+		CeType:          "com_awakari_activitypub_v1",
+		UrlBase:         "https://base",
+		UrlInterestBase: "https://awakari.com/sub-details.html?id=",
+		EvtReaderBase:   "https://reader/evt",
+		ActorType:       vocab.ServiceType,
+		Policy:          policyTest,
+		Format:          f,
+		Rewrites:        model.UrlRewritesBuiltin,
+	}, nil)
+	ts := time.Date(2024, 7, 27, 1, 32, 21, 0, time.UTC)
+	cases := map[string]struct {
+		typ     string
+		source  string
+		lang    string
+		content string
+		summary string
+		tagHref vocab.IRI
+	}{
+		"fediverse, german": {
+			typ:     "com_awakari_activitypub_v1",
+			source:  "https://origin.social/users/jane",
+			lang:    "de-DE",
+			content: `Grüße aus...<br/><br/><a rel="tag" class="mention hashtag" href="https://base/tags/k%C3%A4se">#käse</a><br/><br/><a href="https://origin.social/@jane/1">Quelle</a> | <a href="https://awakari.com/sub-details.html?id=interest0">Interesse</a> | <a href="https://reader/evt2jrVcFeXfGNcExKHLCcrrXBYyLJ&amp;interestId=interest0">Treffer</a>`,
+			summary: "Treffer: Grüße aus...",
+			tagHref: "https://base/tags/k%C3%A4se",
+		},
+		"other source, unknown language": {
+			typ:     "com_awakari_feeds_v1",
+			source:  "https://origin.com/feed.xml",
+			lang:    "xx",
+			content: `Grüße aus...<br/><br/><a rel="tag" class="mention hashtag" href="https://base/tags/k%C3%A4se">#käse</a><br/><br/><a href="https://origin.social/@jane/1">Origin</a> | <a href="https://awakari.com/sub-details.html?id=interest0">Interest</a> | <a href="https://reader/evt2jrVcFeXfGNcExKHLCcrrXBYyLJ&amp;interestId=interest0">Match</a>`,
+			summary: "Match: Grüße aus...",
+			tagHref: "https://base/tags/k%C3%A4se",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			evt := &pb.CloudEvent{
+				Id:          "2jrVcFeXfGNcExKHLCcrrXBYyLJ",
+				SpecVersion: CeSpecVersion,
+				Source:      c.source,
+				Type:        c.typ,
+				Attributes: map[string]*pb.CloudEventAttributeValue{
+					CeKeyCategories: {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "#käse",
+						},
+					},
+					CeKeyLanguage: {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: c.lang,
+						},
+					},
+					CeKeyObjectUrl: {
+						Attr: &pb.CloudEventAttributeValue_CeUri{
+							CeUri: "https://origin.social/@jane/1",
+						},
+					},
+				},
+				Data: &pb.CloudEvent_TextData{
+					TextData: "Grüße aus Köln",
+				},
+			}
+			a, err := svc.ConvertEventToActivity(context.TODO(), evt, "interest0", nil, &ts)
+			require.Nil(t, err)
+			obj := a.Object.(*vocab.Object)
+			assert.Equal(t, c.content, obj.Content.String())
+			assert.Equal(t, c.summary, obj.Summary.String())
+			require.Len(t, obj.Tag, 1)
+			assert.Equal(t, c.tagHref, obj.Tag[0].(*vocab.Link).Href)
+		})
+	}
+}
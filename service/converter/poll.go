@@ -0,0 +1,110 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/bytedance/sonic"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// convertPoll adds the poll options with the vote counts to the event. The poll id is the same for the Create and
+// the closing Update of the same poll, so the results may be correlated with the original publication.
+func convertPoll(poll util.ActivityPoll, obj vocab.Item, evt *pb.CloudEvent) (err error) {
+	if len(poll.Options) == 0 {
+		return
+	}
+	var data []byte
+	data, err = sonic.ConfigStd.Marshal(poll.Options)
+	if err == nil {
+		evt.Attributes[CeKeyPollOptions] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: string(data),
+			},
+		}
+	}
+	evt.Attributes[CeKeyPollMultiple] = &pb.CloudEventAttributeValue{
+		Attr: &pb.CloudEventAttributeValue_CeBoolean{
+			CeBoolean: poll.Multiple,
+		},
+	}
+	if poll.Voters > 0 {
+		evt.Attributes[CeKeyPollVoters] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeInteger{
+				CeInteger: int32(poll.Voters),
+			},
+		}
+	}
+	if poll.Closed {
+		closedAt := poll.ClosedAt
+		if closedAt.IsZero() {
+			closedAt = time.Now().UTC()
+		}
+		evt.Attributes[CeKeyPollClosed] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeTimestamp{
+				CeTimestamp: timestamppb.New(closedAt),
+			},
+		}
+	}
+	if obj != nil && obj.GetLink() != "" {
+		evt.Attributes[CeKeyPollId] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeUri{
+				CeUri: obj.GetLink().String(),
+			},
+		}
+	}
+	return
+}
+
+// interimPollUpdate returns true when the activity only updates the vote counts of the poll that is still open.
+// Only the closing update with the final results is worth to publish.
+func interimPollUpdate(env util.Envelope, now time.Time) bool {
+	return env.Activity.Type == vocab.UpdateType && env.Object.Poll != nil && !env.Object.Poll.IsClosed(now)
+}
+
+// outboundPollOptions returns the poll options from the event, nil when the event has no poll.
+func outboundPollOptions(evt *pb.CloudEvent) (options []model.PollOption) {
+	src := attrString(evt, CeKeyPollOptions)
+	if src == "" {
+		return
+	}
+	var all []model.PollOption
+	_ = sonic.Unmarshal([]byte(src), &all)
+	for _, o := range all {
+		if o.Name != "" {
+			options = append(options, o)
+		}
+	}
+	return
+}
+
+// outboundPoll wraps the object into the Question having the event's poll options.
+func (svc service) outboundPoll(evt *pb.CloudEvent, obj vocab.Object, options []model.PollOption) (p model.Poll) {
+	p.Object = obj
+	p.Type = vocab.QuestionType
+	p.Options = options
+	if pollId := attrString(evt, CeKeyPollId); pollId != "" {
+		// same object id for the poll creation and the following update
+		p.ID = vocab.ID(svc.pollUrl(pollId))
+	}
+	if attr, present := evt.Attributes[CeKeyPollMultiple]; present {
+		p.Multiple = attr.GetCeBoolean()
+	}
+	p.Voters = attrInt(evt, CeKeyPollVoters)
+	if attr, present := evt.Attributes[CeKeyEnds]; present && attr.GetCeTimestamp() != nil {
+		p.EndTime = attr.GetCeTimestamp().AsTime()
+	}
+	if attr, present := evt.Attributes[CeKeyPollClosed]; present && attr.GetCeTimestamp() != nil {
+		p.ClosedAt = attr.GetCeTimestamp().AsTime()
+	}
+	return
+}
+
+func (svc service) pollUrl(pollId string) string {
+	h := sha256.Sum256([]byte(pollId))
+	return svc.urlBase + "/poll/" + hex.EncodeToString(h[:16])
+}
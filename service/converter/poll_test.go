@@ -0,0 +1,290 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func TestService_ConvertActivityToEvent_Poll(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "urlBase",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	cases := map[string]struct {
+		in      string
+		skip    bool
+		attrs   map[string]*pb.CloudEventAttributeValue
+		missing []string
+	}{
+		"created": {
+			in: `{
+				"id": "https://mastodon.social/users/johndoe/statuses/1/activity",
+				"type": "Create",
+				"actor": "https://mastodon.social/users/johndoe",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.social/users/johndoe/statuses/1",
+					"type": "Question",
+					"content": "<p>Tabs or spaces?</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"endTime": "2124-05-01T12:00:00Z",
+					"votersCount": 0,
+					"oneOf": [
+						{"type": "Note", "name": "tabs", "replies": {"type": "Collection", "totalItems": 0}},
+						{"type": "Note", "name": "spaces", "replies": {"type": "Collection", "totalItems": 0}}
+					]
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyPollOptions: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: `[{"name":"tabs","votes":0},{"name":"spaces","votes":0}]`,
+					},
+				},
+				CeKeyPollMultiple: {
+					Attr: &pb.CloudEventAttributeValue_CeBoolean{
+						CeBoolean: false,
+					},
+				},
+				CeKeyPollId: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://mastodon.social/users/johndoe/statuses/1",
+					},
+				},
+				CeKeyEnds: {
+					Attr: &pb.CloudEventAttributeValue_CeTimestamp{
+						CeTimestamp: timestamppb.New(time.Date(2124, 5, 1, 12, 0, 0, 0, time.UTC)),
+					},
+				},
+			},
+			missing: []string{CeKeyPollVoters, CeKeyPollClosed},
+		},
+		"interim results": {
+			in: `{
+				"id": "https://mastodon.social/users/johndoe/statuses/1#updates/1",
+				"type": "Update",
+				"actor": "https://mastodon.social/users/johndoe",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.social/users/johndoe/statuses/1",
+					"type": "Question",
+					"content": "<p>Tabs or spaces?</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"endTime": "2124-05-01T12:00:00Z",
+					"votersCount": 1,
+					"oneOf": [
+						{"type": "Note", "name": "tabs", "replies": {"type": "Collection", "totalItems": 1}},
+						{"type": "Note", "name": "spaces", "replies": {"type": "Collection", "totalItems": 0}}
+					]
+				}
+			}`,
+			skip: true,
+		},
+		"closed": {
+			in: `{
+				"id": "https://mastodon.social/users/johndoe/statuses/1#updates/2",
+				"type": "Update",
+				"actor": "https://mastodon.social/users/johndoe",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.social/users/johndoe/statuses/1",
+					"type": "Question",
+					"content": "<p>Pick any</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"endTime": "2024-05-01T12:00:00Z",
+					"closed": "2024-05-01T12:00:01Z",
+					"votersCount": 3,
+					"anyOf": [
+						{"type": "Note", "name": "tabs", "replies": {"type": "Collection", "totalItems": 1}},
+						{"type": "Note", "name": "spaces", "replies": {"type": "Collection", "totalItems": 3}}
+					]
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAction: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "Update",
+					},
+				},
+				CeKeyPollOptions: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: `[{"name":"tabs","votes":1},{"name":"spaces","votes":3}]`,
+					},
+				},
+				CeKeyPollMultiple: {
+					Attr: &pb.CloudEventAttributeValue_CeBoolean{
+						CeBoolean: true,
+					},
+				},
+				CeKeyPollVoters: {
+					Attr: &pb.CloudEventAttributeValue_CeInteger{
+						CeInteger: 3,
+					},
+				},
+				CeKeyPollClosed: {
+					Attr: &pb.CloudEventAttributeValue_CeTimestamp{
+						CeTimestamp: timestamppb.New(time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC)),
+					},
+				},
+				CeKeyPollId: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://mastodon.social/users/johndoe/statuses/1",
+					},
+				},
+			},
+		},
+		"not a poll": {
+			in: `{
+				"id": "https://mastodon.social/users/johndoe/statuses/2/activity",
+				"type": "Update",
+				"actor": "https://mastodon.social/users/johndoe",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.social/users/johndoe/statuses/2",
+					"type": "Note",
+					"content": "<p>edited</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"]
+				}
+			}`,
+			missing: []string{CeKeyPollOptions, CeKeyPollMultiple, CeKeyPollId},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID: env.Activity.Actor.GetLink(),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			require.Nil(t, err)
+			if c.skip {
+				assert.Nil(t, evt)
+				return
+			}
+			require.NotNil(t, evt)
+			for k, v := range c.attrs {
+				assert.Equal(t, v, evt.Attributes[k], k)
+			}
+			for _, k := range c.missing {
+				assert.NotContains(t, evt.Attributes, k)
+			}
+		})
+	}
+}
+
+func TestService_ConvertEventToActivity_Poll(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "https://int.example",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	pollId := "https://mastodon.social/users/johndoe/statuses/1"
+	cases := map[string]struct {
+		in     *pb.CloudEvent
+		typ    vocab.ActivityVocabularyType
+		poll   bool
+		objId  vocab.ID
+		closed bool
+	}{
+		"poll created": {
+			in: &pb.CloudEvent{
+				Id:     "evt1",
+				Source: "https://mastodon.social/users/johndoe",
+				Attributes: map[string]*pb.CloudEventAttributeValue{
+					CeKeyObject: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "Question"},
+					},
+					CeKeyPollOptions: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: `[{"name":"tabs","votes":0},{"name":"spaces","votes":0}]`},
+					},
+					CeKeyPollId: {
+						Attr: &pb.CloudEventAttributeValue_CeUri{CeUri: pollId},
+					},
+				},
+				Data: &pb.CloudEvent_TextData{TextData: "Tabs or spaces?"},
+			},
+			typ:   vocab.CreateType,
+			poll:  true,
+			objId: vocab.ID(svc.(service).pollUrl(pollId)),
+		},
+		"poll closed": {
+			in: &pb.CloudEvent{
+				Id:     "evt2",
+				Source: "https://mastodon.social/users/johndoe",
+				Attributes: map[string]*pb.CloudEventAttributeValue{
+					CeKeyAction: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "Update"},
+					},
+					CeKeyObject: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "Question"},
+					},
+					CeKeyPollOptions: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: `[{"name":"tabs","votes":1},{"name":"spaces","votes":3}]`},
+					},
+					CeKeyPollMultiple: {
+						Attr: &pb.CloudEventAttributeValue_CeBoolean{CeBoolean: true},
+					},
+					CeKeyPollVoters: {
+						Attr: &pb.CloudEventAttributeValue_CeInteger{CeInteger: 3},
+					},
+					CeKeyPollClosed: {
+						Attr: &pb.CloudEventAttributeValue_CeTimestamp{CeTimestamp: timestamppb.New(time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC))},
+					},
+					CeKeyPollId: {
+						Attr: &pb.CloudEventAttributeValue_CeUri{CeUri: pollId},
+					},
+				},
+				Data: &pb.CloudEvent_TextData{TextData: "Tabs or spaces?"},
+			},
+			typ:    vocab.UpdateType,
+			poll:   true,
+			objId:  vocab.ID(svc.(service).pollUrl(pollId)),
+			closed: true,
+		},
+		"question without options": {
+			in: &pb.CloudEvent{
+				Id:     "evt3",
+				Source: "https://mastodon.social/users/johndoe",
+				Attributes: map[string]*pb.CloudEventAttributeValue{
+					CeKeyObject: {
+						Attr: &pb.CloudEventAttributeValue_CeString{CeString: "Question"},
+					},
+				},
+				Data: &pb.CloudEvent_TextData{TextData: "Tabs or spaces?"},
+			},
+			typ:   vocab.CreateType,
+			objId: "https://int.example/evt3",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			a, err := svc.ConvertEventToActivity(context.TODO(), c.in, "interest1", nil, nil)
+			require.Nil(t, err)
+			assert.Equal(t, c.typ, a.Type)
+			assert.Equal(t, c.objId, a.Object.GetID())
+			p, isPoll := a.Object.(model.Poll)
+			assert.Equal(t, c.poll, isPoll)
+			if isPoll {
+				assert.Equal(t, vocab.QuestionType, p.Type)
+				assert.Len(t, p.Options, 2)
+				assert.Equal(t, c.closed, !p.ClosedAt.IsZero())
+			} else {
+				assert.Equal(t, vocab.NoteType, a.Object.GetType())
+			}
+		})
+	}
+}
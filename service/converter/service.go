@@ -2,8 +2,10 @@ package converter
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"github.com/awakari/int-activitypub/api/http/media"
 	"github.com/awakari/int-activitypub/model"
 	"github.com/awakari/int-activitypub/util"
 	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
@@ -11,20 +13,19 @@ import (
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/segmentio/ksuid"
 	"google.golang.org/protobuf/types/known/timestamppb"
+	"html"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
 	"time"
-	"unicode/utf8"
 )
 
 type Service interface {
 	ConvertActivityToEvent(
 		ctx context.Context,
 		actor vocab.Actor,
-		activity vocab.Activity,
-		tags util.ActivityTags,
-		cm util.ActivityContentMap,
+		env util.Envelope,
 	) (evt *pb.CloudEvent, err error)
 	ConvertEventToActivity(ctx context.Context, evt *pb.CloudEvent, interestId string, follower *vocab.Actor, t *time.Time) (a vocab.Activity, err error)
 	ConvertEventToActorUpdate(ctx context.Context, evt *pb.CloudEvent, interestId string, follower *vocab.Actor, t *time.Time) (a vocab.Activity, err error)
@@ -36,49 +37,82 @@ type service struct {
 	urlInterestBase  string
 	urlReaderEvtBase string
 	actorType        vocab.ActivityVocabularyType
+	policy           model.VisibilityPolicy
+	outbound         model.OutboundPolicy
+	format           NoteFormat
+	probe            media.Service
+	articleLenMax    int
+	rewrites         model.UrlRewrites
 }
 
 const CeSpecVersion = "1.0"
 const CeKeyAction = "action"
+const CeKeyAddress = "address"
+const CeKeyAttachmentAlt = "attachmentalt"
+const CeKeyAttachmentBlurhash = "attachmentblurhash"
+const CeKeyAttachmentFocalPoint = "attachmentfocalpoint"
+const CeKeyAttachmentHeight = "attachmentheight"
 const CeKeyAttachmentUrl = "attachmenturl"
 const CeKeyAttachmentType = "attachmenttype"
+const CeKeyAttachmentWidth = "attachmentwidth"
 const CeKeyAudience = "audience"
+const CeKeyBridge = "bridge"
 const CeKeyCategories = "categories"
+const CeKeyAttachments = "attachments"
 const CeKeyCc = "cc"
+const CeKeyContentWarning = "contentwarning"
 const CeKeyDescription = "description"
+const CeKeyDiscoverable = "discoverable"
 const CeKeyDuration = "duration"
+const CeKeyEmoji = "emoji"
 const CeKeyEnds = "ends"
 const CeKeyHeadline = "headline"
 const CeKeyIcon = "icon"
+const CeKeyImageAlt = "imagealt"
 const CeKeyImageUrl = "imageurl"
 const CeKeyInReplyTo = "inreplyto"
+const CeKeyJoinMode = "joinmode"
 const CeKeyLanguage = "language"
+const CeKeyLanguageConfidence = "languageconfidence"
+const CeKeyLanguageRegion = "languageregion"
 const CeKeyLatitude = "latitude"
 const CeKeyLongitude = "longitude"
+const CeKeyMentions = "mentions"
 const CeKeyName = "name"
 const CeKeyObject = "object"
 const CeKeyObjectUrl = "objecturl"
+const CeKeyOrganizer = "organizer"
+const CeKeyParticipants = "participants"
+const CeKeyPlace = "place"
+const CeKeyPollClosed = "pollclosed"
+const CeKeyPollId = "pollid"
+const CeKeyPollMultiple = "pollmultiple"
+const CeKeyPollOptions = "polloptions"
+const CeKeyPollVoters = "pollvoters"
 const CeKeyPreview = "preview"
+const CeKeyQuoteOf = "quoteof"
+const CeKeySensitive = "sensitive"
 const CeKeySrcImageUrl = "sourceimageurl"
 const CeKeyStarts = "starts"
 const CeKeySubject = "subject"
 const CeKeySummary = "summary"
 const CeKeyTime = "time"
+const CeKeyTimezone = "timezone"
 const CeKeyTitle = "title"
 const CeKeyTo = "to"
 const CeKeyUpdated = "updated"
+const CeKeyVisibility = "visibility"
 
 const asPublic = "https://www.w3.org/ns/activitystreams#Public"
 
-const fmtLenMaxBodyTxt = 200
+// the compact forms of the public collection IRI, used by some implementations without the JSON-LD expansion
+const asPublicCompact = "as:Public"
+const asPublicBare = "Public"
 
 const ceTypePrefixFollowersOnly = "com_awakari_mastodon_"
 
-const prefixSrcBridgy = "https://bsky.brid.gy/ap/did:plc:"
-const prefixObjUrlBridgy = "https://bsky.brid.gy/convert/ap/at://did:plc:"
-const prefixObjUrlBluesky = "https://bsky.app/profile/did:plc:"
-
 var ErrFail = errors.New("failed to convert")
+var ErrDropped = errors.New("dropped by the visibility policy")
 
 var htmlStripTags = bluemonday.
 	StrictPolicy().
@@ -86,31 +120,64 @@ var htmlStripTags = bluemonday.
 
 var reMultiSpace = regexp.MustCompile(`\s+`)
 
-func NewService(ceType, urlBase, urlInterestBase, evtReaderBase string, actorType vocab.ActivityVocabularyType) Service {
+// Options are the conversion settings, the zero value of each is the default.
+type Options struct {
+	// CeType is the type of the events converted from the activities.
+	CeType string
+
+	// UrlBase is the base address of this host, e.g. "https://activitypub.awakari.com".
+	UrlBase string
+
+	// UrlInterestBase is the interest details page address prefix, the interest id is appended.
+	UrlInterestBase string
+
+	// EvtReaderBase is the event page address prefix, the event id is appended.
+	EvtReaderBase string
+
+	// ActorType is the type of the interest actors.
+	ActorType vocab.ActivityVocabularyType
+
+	Policy   model.VisibilityPolicy
+	Outbound model.OutboundPolicy
+	Format   NoteFormat
+
+	// ArticleLenMax limits the plain text converted from the article's HTML, 0 means no limit.
+	ArticleLenMax int
+
+	Rewrites model.UrlRewrites
+}
+
+// NewService returns the converter, the media probe is optional.
+func NewService(opts Options, probe media.Service) Service {
 	return service{
-		ceType:           ceType,
-		urlBase:          urlBase,
-		urlInterestBase:  urlInterestBase,
-		urlReaderEvtBase: evtReaderBase,
-		actorType:        actorType,
+		ceType:           opts.CeType,
+		urlBase:          opts.UrlBase,
+		urlInterestBase:  opts.UrlInterestBase,
+		urlReaderEvtBase: opts.EvtReaderBase,
+		actorType:        opts.ActorType,
+		policy:           opts.Policy,
+		outbound:         opts.Outbound,
+		format:           opts.Format.withDefaults(),
+		probe:            probe,
+		articleLenMax:    opts.ArticleLenMax,
+		rewrites:         opts.Rewrites,
 	}
 }
 
 func (svc service) ConvertActivityToEvent(
 	ctx context.Context,
 	actor vocab.Actor,
-	activity vocab.Activity,
-	tags util.ActivityTags,
-	cm util.ActivityContentMap,
+	env util.Envelope,
 ) (evt *pb.CloudEvent, err error) {
 	//
-	src := actor.ID.String()
-	if strings.HasPrefix(src, prefixSrcBridgy) {
-		src = prefixObjUrlBluesky + strings.TrimPrefix(src, prefixSrcBridgy)
+	if emojiReaction(env) {
+		// nothing to publish
+		return
 	}
+	activity := env.Activity
 	evt = &pb.CloudEvent{
 		Id:          ksuid.New().String(),
-		Source:      src,
+		Source:      actor.ID.String(),
 		SpecVersion: CeSpecVersion,
 		Type:        svc.ceType,
 		Attributes: map[string]*pb.CloudEventAttributeValue{
@@ -129,9 +196,7 @@ func (svc service) ConvertActivityToEvent(
 		}
 	}
 	//
-	var public bool
-	public, err = svc.convertActivity(activity, evt, tags)
-	var publicObj bool
+	err = svc.convertActivity(activity, evt, env)
 	t := string(activity.Type)
 	if activity.Object != nil {
 		evt.Attributes[CeKeyAction] = &pb.CloudEventAttributeValue{
@@ -142,15 +207,15 @@ func (svc service) ConvertActivityToEvent(
 		obj := activity.Object
 		switch objT := obj.(type) {
 		case *vocab.Object:
-			publicObj, err = svc.convertObject(objT, evt)
+			err = svc.convertObject(objT, evt)
 		case *vocab.Question:
-			publicObj, err = svc.convertQuestion(objT, evt)
+			err = svc.convertQuestion(objT, evt)
 		default:
 			switch obj.IsLink() {
 			case true:
 				evt.Attributes[CeKeyObjectUrl] = &pb.CloudEventAttributeValue{
 					Attr: &pb.CloudEventAttributeValue_CeUri{
-						CeUri: objectUrl(string(obj.GetLink())),
+						CeUri: string(obj.GetLink()),
 					},
 				}
 			default:
@@ -159,32 +224,93 @@ func (svc service) ConvertActivityToEvent(
 		}
 	}
 
-	// missing language detection attempt
-	if _, langOk := evt.Attributes[CeKeyLanguage]; !langOk && len(cm.ContentMap) > 0 {
-		for langCode := range cm.ContentMap {
-			if len(langCode) > 1 {
-				if len(langCode) > 2 {
-					langCode = langCode[:2]
-				}
-				evt.Attributes[CeKeyLanguage] = &pb.CloudEventAttributeValue{
-					Attr: &pb.CloudEventAttributeValue_CeString{
-						CeString: langCode,
+	if poll := env.Object.Poll; poll != nil {
+		if interimPollUpdate(env, time.Now()) {
+			// nothing to publish until the poll is closed
+			evt = nil
+			return
+		}
+		err = errors.Join(err, convertPoll(*poll, activity.Object, evt))
+	}
+
+	if activity.Published.IsZero() && activity.Object != nil && !activity.Object.IsLink() {
+		// the creation activity may have no own publication time, e.g. PeerTube and Funkwhale
+		_ = vocab.OnObject(activity.Object, func(o *vocab.Object) error {
+			if !o.Published.IsZero() {
+				evt.Attributes[CeKeyTime] = &pb.CloudEventAttributeValue{
+					Attr: &pb.CloudEventAttributeValue_CeTimestamp{
+						CeTimestamp: timestamppb.New(o.Published),
 					},
 				}
-				break
 			}
-		}
+			return nil
+		})
+	}
+	if e := env.Object.Event; e != nil {
+		convertEvent(*e, evt)
 	}
+	if m := env.Object.Media; m != nil {
+		convertMediaObject(*m, evt)
+	}
+
+	// media and content warning properties unknown to the activitypub library
+	atts, extra := env.Object.Attachments, env.Object.Extra
+	if activity.Object == nil || activity.Object.IsLink() {
+		atts, extra = env.Attachments, env.Extra
+	}
+	convertMisskeyContent(activity.Object, extra, evt)
+	err = errors.Join(err, convertAttachmentsMeta(atts, evt))
+	convertSensitive(extra, evt)
+	tags := append(append([]util.ActivityTag{}, env.Tags...), env.Object.Tags...)
+	err = errors.Join(err, convertTags(tags, evt))
+	convertQuote(tags, extra, evt)
+
+	// missing language: try the content map, detect by the text otherwise
+	if _, langOk := evt.Attributes[CeKeyLanguage]; !langOk {
+		cm := env.Object.ContentMap
+		if len(cm) == 0 {
+			cm = env.ContentMap
+		}
+		var content string
+		if activity.Object != nil && !activity.Object.IsLink() {
+			_ = vocab.OnObject(activity.Object, func(o *vocab.Object) error {
+				content = o.Content.First().Value.String()
+				return nil
+			})
+		}
+		convertLanguage(contentMapLanguage(cm, content), evt)
+	}
+	detectLanguage(evt)
 
-	// honor the privacy: discard any publication that is not explicitly public
-	if !public && !publicObj {
+	svc.convertBridged(evt)
+
+	// honor the privacy: the policy decides what to do with the publication that is not explicitly public
+	vc := classifyVisibility(actor, env)
+	evt.Attributes[CeKeyVisibility] = &pb.CloudEventAttributeValue{
+		Attr: &pb.CloudEventAttributeValue_CeString{
+			CeString: vc.Visibility.String(),
+		},
+	}
+	switch svc.policy.Decide(vc) {
+	case model.VisibilityActionPublishUndiscoverable:
+		evt.Attributes[CeKeyDiscoverable] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeBoolean{
+				CeBoolean: false,
+			},
+		}
+	case model.VisibilityActionDrop:
 		evt = nil
+		errDrop := fmt.Errorf("%w: %s visibility", ErrDropped, vc.Visibility)
+		if vc.AnnounceRestricted {
+			errDrop = fmt.Errorf("%w, boosts restricted", errDrop)
+		}
+		err = errors.Join(err, errDrop)
 	}
 	//
 	return
 }
 
-func (svc service) convertActivity(a vocab.Activity, evt *pb.CloudEvent, tags util.ActivityTags) (public bool, err error) {
+func (svc service) convertActivity(a vocab.Activity, evt *pb.CloudEvent, env util.Envelope) (err error) {
 	evt.Attributes[CeKeyObject] = &pb.CloudEventAttributeValue{
 		Attr: &pb.CloudEventAttributeValue_CeString{
 			CeString: string(a.Type),
@@ -199,42 +325,20 @@ func (svc service) convertActivity(a vocab.Activity, evt *pb.CloudEvent, tags ut
 	}
 	evt.Attributes[CeKeyObjectUrl] = &pb.CloudEventAttributeValue{
 		Attr: &pb.CloudEventAttributeValue_CeUri{
-			CeUri: objectUrl(objUrl),
+			CeUri: objUrl,
 		},
 	}
 	if att := a.Attachment; att != nil {
 		err = convertAttachment(att, evt)
 	}
 	if aud := a.Audience; aud != nil && len(aud) > 0 {
-		var publicAud bool
-		var errAud error
-		publicAud, errAud = convertAsCollectionDetectAsPublic(aud, evt, CeKeyAudience)
-		if publicAud {
-			public = true
-		}
-		err = errors.Join(err, errAud)
+		err = errors.Join(err, convertAsCollection(aud, evt, CeKeyAudience))
 	}
 	if cc := a.CC; cc != nil && len(cc) > 0 {
-		var publicCc bool
-		var errCc error
-		publicCc, errCc = convertAsCollectionDetectAsPublic(cc, evt, CeKeyCc)
-		if publicCc {
-			public = true
-		}
-		err = errors.Join(err, errCc)
+		err = errors.Join(err, convertAsCollection(cc, evt, CeKeyCc))
 	}
 	if a.Content != nil {
-		lang := strings.ToLower(a.Content.First().Ref.String())
-		if lang != "" && lang != "-" {
-			if len(lang) > 2 {
-				lang = lang[:2]
-			}
-			evt.Attributes[CeKeyLanguage] = &pb.CloudEventAttributeValue{
-				Attr: &pb.CloudEventAttributeValue_CeString{
-					CeString: lang,
-				},
-			}
-		}
+		convertLanguage(a.Content.First().Ref.String(), evt)
 		txt := evt.GetTextData()
 		switch txt {
 		case "":
@@ -284,17 +388,7 @@ func (svc service) convertActivity(a vocab.Activity, evt *pb.CloudEvent, tags ut
 		}
 	}
 	if summ := a.Summary; summ != nil && len(summ) > 0 {
-		lang := strings.ToLower(summ.First().Ref.String())
-		if lang != "" && lang != "-" {
-			if len(lang) > 2 {
-				lang = lang[:2]
-			}
-			evt.Attributes[CeKeyLanguage] = &pb.CloudEventAttributeValue{
-				Attr: &pb.CloudEventAttributeValue_CeString{
-					CeString: lang,
-				},
-			}
-		}
+		convertLanguage(summ.First().Ref.String(), evt)
 		txt := evt.GetTextData()
 		switch txt {
 		case "":
@@ -308,28 +402,8 @@ func (svc service) convertActivity(a vocab.Activity, evt *pb.CloudEvent, tags ut
 		}
 		err = errors.Join(err, convertAsText(summ, evt, CeKeySummary))
 	}
-	var tagNames []string
-	for _, t := range tags.Tag {
-		tagNames = append(tagNames, t.Name)
-	}
-	for _, t := range tags.Object.Tag {
-		tagNames = append(tagNames, t.Name)
-	}
-	if len(tagNames) > 0 {
-		evt.Attributes[CeKeyCategories] = &pb.CloudEventAttributeValue{
-			Attr: &pb.CloudEventAttributeValue_CeString{
-				CeString: strings.Join(tagNames, " "),
-			},
-		}
-	}
 	if to := a.To; to != nil && len(to) > 0 {
-		var publicTo bool
-		var errTo error
-		publicTo, errTo = convertAsCollectionDetectAsPublic(to, evt, CeKeyTo)
-		if publicTo {
-			public = true
-		}
-		err = errors.Join(err, errTo)
+		err = errors.Join(err, convertAsCollection(to, evt, CeKeyTo))
 	}
 	if !a.Updated.IsZero() {
 		evt.Attributes[CeKeyUpdated] = &pb.CloudEventAttributeValue{
@@ -341,7 +415,7 @@ func (svc service) convertActivity(a vocab.Activity, evt *pb.CloudEvent, tags ut
 	return
 }
 
-func (svc service) convertObject(obj *vocab.Object, evt *pb.CloudEvent) (public bool, err error) {
+func (svc service) convertObject(obj *vocab.Object, evt *pb.CloudEvent) (err error) {
 	evt.Attributes[CeKeyObject] = &pb.CloudEventAttributeValue{
 		Attr: &pb.CloudEventAttributeValue_CeString{
 			CeString: string(obj.Type),
@@ -349,51 +423,29 @@ func (svc service) convertObject(obj *vocab.Object, evt *pb.CloudEvent) (public
 	}
 	evt.Attributes[CeKeyObjectUrl] = &pb.CloudEventAttributeValue{
 		Attr: &pb.CloudEventAttributeValue_CeUri{
-			CeUri: objectUrl(string(obj.ID)),
+			CeUri: string(obj.ID),
 		},
 	}
 	if att := obj.Attachment; att != nil {
 		err = convertAttachment(att, evt)
 	}
 	if aud := obj.Audience; aud != nil && len(aud) > 0 {
-		var publicAud bool
-		var errAud error
-		publicAud, errAud = convertAsCollectionDetectAsPublic(aud, evt, CeKeyAudience)
-		if publicAud {
-			public = true
-		}
-		err = errors.Join(err, errAud)
+		err = errors.Join(err, convertAsCollection(aud, evt, CeKeyAudience))
 	}
 	if cc := obj.CC; cc != nil && len(cc) > 0 {
-		var publicCc bool
-		var errCc error
-		publicCc, errCc = convertAsCollectionDetectAsPublic(cc, evt, CeKeyCc)
-		if publicCc {
-			public = true
-		}
-		err = errors.Join(err, errCc)
+		err = errors.Join(err, convertAsCollection(cc, evt, CeKeyCc))
 	}
 	if obj.Content != nil {
-		lang := strings.ToLower(obj.Content.First().Ref.String())
-		if lang != "" && lang != "-" {
-			if len(lang) > 2 {
-				lang = lang[:2]
-			}
-			evt.Attributes[CeKeyLanguage] = &pb.CloudEventAttributeValue{
-				Attr: &pb.CloudEventAttributeValue_CeString{
-					CeString: lang,
-				},
-			}
-		}
+		convertLanguage(obj.Content.First().Ref.String(), evt)
 		txt := evt.GetTextData()
 		switch txt {
 		case "":
 			evt.Data = &pb.CloudEvent_TextData{
-				TextData: obj.Content.String(),
+				TextData: objectContent(obj),
 			}
 		default:
 			evt.Data = &pb.CloudEvent_TextData{
-				TextData: fmt.Sprintf("%s\n\n%s", obj.Content.String(), txt),
+				TextData: fmt.Sprintf("%s\n\n%s", objectContent(obj), txt),
 			}
 		}
 	}
@@ -434,26 +486,21 @@ func (svc service) convertObject(obj *vocab.Object, evt *pb.CloudEvent) (public
 		}
 	}
 	if summ := obj.Summary; summ != nil && len(summ) > 0 {
-		lang := strings.ToLower(summ.First().Ref.String())
-		if lang != "" && lang != "-" {
-			if len(lang) > 2 {
-				lang = lang[:2]
-			}
-			evt.Attributes[CeKeyLanguage] = &pb.CloudEventAttributeValue{
-				Attr: &pb.CloudEventAttributeValue_CeString{
-					CeString: lang,
-				},
-			}
-		}
-		txt := evt.GetTextData()
-		switch txt {
-		case "":
-			evt.Data = &pb.CloudEvent_TextData{
-				TextData: summ.String(),
-			}
+		convertLanguage(summ.First().Ref.String(), evt)
+		switch contentWarning(obj.Type, obj.Content) {
+		case true:
+			err = errors.Join(err, convertAsText(summ, evt, CeKeyContentWarning))
 		default:
-			evt.Data = &pb.CloudEvent_TextData{
-				TextData: fmt.Sprintf("%s\n\n%s", summ.String(), txt),
+			txt := evt.GetTextData()
+			switch txt {
+			case "":
+				evt.Data = &pb.CloudEvent_TextData{
+					TextData: summ.String(),
+				}
+			default:
+				evt.Data = &pb.CloudEvent_TextData{
+					TextData: fmt.Sprintf("%s\n\n%s", summ.String(), txt),
+				}
 			}
 		}
 	}
@@ -461,13 +508,7 @@ func (svc service) convertObject(obj *vocab.Object, evt *pb.CloudEvent) (public
 		err = errors.Join(err, convertAsCollection(tags, evt, CeKeyCategories))
 	}
 	if to := obj.To; to != nil && len(to) > 0 {
-		var publicTo bool
-		var errTo error
-		publicTo, errTo = convertAsCollectionDetectAsPublic(to, evt, CeKeyTo)
-		if publicTo {
-			public = true
-		}
-		err = errors.Join(err, errTo)
+		err = errors.Join(err, convertAsCollection(to, evt, CeKeyTo))
 	}
 	if !obj.Updated.IsZero() {
 		evt.Attributes[CeKeyUpdated] = &pb.CloudEventAttributeValue{
@@ -476,11 +517,14 @@ func (svc service) convertObject(obj *vocab.Object, evt *pb.CloudEvent) (public
 			},
 		}
 	}
+	if obj.Type == vocab.ArticleType {
+		svc.convertArticle(obj, evt)
+	}
 	//
 	return
 }
 
-func (svc service) convertQuestion(obj *vocab.Question, evt *pb.CloudEvent) (public bool, err error) {
+func (svc service) convertQuestion(obj *vocab.Question, evt *pb.CloudEvent) (err error) {
 	evt.Attributes[CeKeyObject] = &pb.CloudEventAttributeValue{
 		Attr: &pb.CloudEventAttributeValue_CeString{
 			CeString: string(obj.Type),
@@ -488,42 +532,20 @@ func (svc service) convertQuestion(obj *vocab.Question, evt *pb.CloudEvent) (pub
 	}
 	evt.Attributes[CeKeyObjectUrl] = &pb.CloudEventAttributeValue{
 		Attr: &pb.CloudEventAttributeValue_CeUri{
-			CeUri: objectUrl(string(obj.ID)),
+			CeUri: string(obj.ID),
 		},
 	}
 	if att := obj.Attachment; att != nil {
 		err = convertAttachment(att, evt)
 	}
 	if aud := obj.Audience; aud != nil && len(aud) > 0 {
-		var publicAud bool
-		var errAud error
-		publicAud, errAud = convertAsCollectionDetectAsPublic(aud, evt, CeKeyAudience)
-		if publicAud {
-			public = true
-		}
-		err = errors.Join(err, errAud)
+		err = errors.Join(err, convertAsCollection(aud, evt, CeKeyAudience))
 	}
 	if cc := obj.CC; cc != nil && len(cc) > 0 {
-		var publicCc bool
-		var errCc error
-		publicCc, errCc = convertAsCollectionDetectAsPublic(cc, evt, CeKeyCc)
-		if publicCc {
-			public = true
-		}
-		err = errors.Join(err, errCc)
+		err = errors.Join(err, convertAsCollection(cc, evt, CeKeyCc))
 	}
 	if obj.Content != nil {
-		lang := strings.ToLower(obj.Content.First().Ref.String())
-		if lang != "" && lang != "-" {
-			if len(lang) > 2 {
-				lang = lang[:2]
-			}
-			evt.Attributes[CeKeyLanguage] = &pb.CloudEventAttributeValue{
-				Attr: &pb.CloudEventAttributeValue_CeString{
-					CeString: lang,
-				},
-			}
-		}
+		convertLanguage(obj.Content.First().Ref.String(), evt)
 		txt := evt.GetTextData()
 		switch txt {
 		case "":
@@ -573,26 +595,21 @@ func (svc service) convertQuestion(obj *vocab.Question, evt *pb.CloudEvent) (pub
 		}
 	}
 	if summ := obj.Summary; summ != nil && len(summ) > 0 {
-		lang := strings.ToLower(summ.First().Ref.String())
-		if lang != "" && lang != "-" {
-			if len(lang) > 2 {
-				lang = lang[:2]
-			}
-			evt.Attributes[CeKeyLanguage] = &pb.CloudEventAttributeValue{
-				Attr: &pb.CloudEventAttributeValue_CeString{
-					CeString: lang,
-				},
-			}
-		}
-		txt := evt.GetTextData()
-		switch txt {
-		case "":
-			evt.Data = &pb.CloudEvent_TextData{
-				TextData: summ.String(),
-			}
+		convertLanguage(summ.First().Ref.String(), evt)
+		switch contentWarning(obj.Type, obj.Content) {
+		case true:
+			err = errors.Join(err, convertAsText(summ, evt, CeKeyContentWarning))
 		default:
-			evt.Data = &pb.CloudEvent_TextData{
-				TextData: fmt.Sprintf("%s\n\n%s", summ.String(), txt),
+			txt := evt.GetTextData()
+			switch txt {
+			case "":
+				evt.Data = &pb.CloudEvent_TextData{
+					TextData: summ.String(),
+				}
+			default:
+				evt.Data = &pb.CloudEvent_TextData{
+					TextData: fmt.Sprintf("%s\n\n%s", summ.String(), txt),
+				}
 			}
 		}
 	}
@@ -600,13 +617,7 @@ func (svc service) convertQuestion(obj *vocab.Question, evt *pb.CloudEvent) (pub
 		err = errors.Join(err, convertAsCollection(tags, evt, CeKeyCategories))
 	}
 	if to := obj.To; to != nil && len(to) > 0 {
-		var publicTo bool
-		var errTo error
-		publicTo, errTo = convertAsCollectionDetectAsPublic(to, evt, CeKeyTo)
-		if publicTo {
-			public = true
-		}
-		err = errors.Join(err, errTo)
+		err = errors.Join(err, convertAsCollection(to, evt, CeKeyTo))
 	}
 	if !obj.Updated.IsZero() {
 		evt.Attributes[CeKeyUpdated] = &pb.CloudEventAttributeValue{
@@ -619,7 +630,7 @@ func (svc service) convertQuestion(obj *vocab.Question, evt *pb.CloudEvent) (pub
 	return
 }
 
-func convertAsCollectionDetectAsPublic(items vocab.ItemCollection, evt *pb.CloudEvent, key string) (public bool, err error) {
+func convertAsCollection(items vocab.ItemCollection, evt *pb.CloudEvent, key string) (err error) {
 	var result []string
 	for _, item := range items {
 		var itemStr string
@@ -634,9 +645,6 @@ func convertAsCollectionDetectAsPublic(items vocab.ItemCollection, evt *pb.Cloud
 		if itemStr != "" {
 			result = append(result, itemStr)
 		}
-		if itemStr == asPublic {
-			public = true
-		}
 	}
 	evt.Attributes[key] = &pb.CloudEventAttributeValue{
 		Attr: &pb.CloudEventAttributeValue_CeString{
@@ -646,11 +654,6 @@ func convertAsCollectionDetectAsPublic(items vocab.ItemCollection, evt *pb.Cloud
 	return
 }
 
-func convertAsCollection(items vocab.ItemCollection, evt *pb.CloudEvent, key string) (err error) {
-	_, err = convertAsCollectionDetectAsPublic(items, evt, key)
-	return
-}
-
 func convertAttachment(att vocab.Item, evt *pb.CloudEvent) (err error) {
 	switch {
 	case att.IsLink():
@@ -702,6 +705,22 @@ func convertAsText(item vocab.NaturalLanguageValues, evt *pb.CloudEvent, key str
 	return
 }
 
+// contentWarning returns true when the object's summary is the content warning rather than the abstract,
+// Mastodon and others use it this way for the short posts.
+func contentWarning(t vocab.ActivityVocabularyType, content vocab.NaturalLanguageValues) bool {
+	return (t == vocab.NoteType || t == vocab.QuestionType) && len(content) > 0 && content.String() != ""
+}
+
+func convertSensitive(extra map[string][]byte, evt *pb.CloudEvent) {
+	if string(extra["sensitive"]) == "true" {
+		evt.Attributes[CeKeySensitive] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeBoolean{
+				CeBoolean: true,
+			},
+		}
+	}
+}
+
 func convertInReplyTo(inReplyTo vocab.Item, evt *pb.CloudEvent) (err error) {
 	switch {
 	case inReplyTo.IsLink():
@@ -724,7 +743,15 @@ func convertInReplyTo(inReplyTo vocab.Item, evt *pb.CloudEvent) (err error) {
 
 func convertLocation(loc vocab.Item, evt *pb.CloudEvent) (err error) {
 	switch locT := loc.(type) {
+	case vocab.ItemCollection:
+		if len(locT) > 0 {
+			err = convertLocation(locT[0], evt)
+		}
 	case *vocab.Place:
+		if locT.Latitude == 0 && locT.Longitude == 0 {
+			// the place without the coordinates, e.g. the Pixelfed photo location
+			break
+		}
 		evt.Attributes[CeKeyLatitude] = &pb.CloudEventAttributeValue{
 			Attr: &pb.CloudEventAttributeValue_CeString{
 				CeString: fmt.Sprintf("%f", locT.Latitude),
@@ -744,14 +771,44 @@ func convertLocation(loc vocab.Item, evt *pb.CloudEvent) (err error) {
 func (svc service) ConvertEventToActivity(ctx context.Context, evt *pb.CloudEvent, interestId string, follower *vocab.Actor, t *time.Time) (a vocab.Activity, err error) {
 
 	svc.initActivity(evt, interestId, follower, t, &a)
-	if evt.Type != svc.ceType && !strings.HasPrefix(evt.Type, ceTypePrefixFollowersOnly) {
+	switch svc.outboundVisibility(evt) {
+	case model.VisibilityPublic:
 		a.To = append(a.To, vocab.IRI(asPublic))
+	case model.VisibilityUnlisted:
+		a.CC = append(a.CC, vocab.IRI(asPublic))
+	}
+
+	if svc.outbound.Mode(interestId) == model.OutboundModeAnnounce {
+		addrOrigin, undo := svc.announceableObject(evt)
+		switch {
+		case addrOrigin == "":
+		case undo:
+			// the original is deleted, undo the boost
+			a.Type = vocab.UndoType
+			a.CC = append(a.CC, vocab.IRI(evt.Source))
+			a.Object = &vocab.Activity{
+				Type:   vocab.AnnounceType,
+				ID:     svc.announceId(interestId, addrOrigin),
+				Actor:  a.Actor,
+				Object: vocab.IRI(addrOrigin),
+			}
+			return
+		default:
+			a.ID = svc.announceId(interestId, addrOrigin)
+			a.Type = vocab.AnnounceType
+			a.Object = vocab.IRI(addrOrigin)
+			a.CC = append(a.CC, vocab.IRI(evt.Source))
+			return
+		}
 	}
 
 	txt := eventSummaryText(evt)
 	txt = htmlStripTags.Sanitize(txt)
+	// plain text, the note template escapes it
+	txt = html.UnescapeString(txt)
 	txt = reMultiSpace.ReplaceAllString(txt, " ")
-	txt = truncateStringUtf8(txt, fmtLenMaxBodyTxt)
+	txt = truncateStringUtf8(txt, svc.format.LenMaxText)
+	txtPlain := txt
 
 	var ceObj string
 	var objType vocab.ActivityVocabularyType
@@ -762,8 +819,16 @@ func (svc service) ConvertEventToActivity(ctx context.Context, evt *pb.CloudEven
 			ceObj = attrObj.GetCeUri()
 		}
 	}
-	switch vocab.ObjectTypes.Contains(vocab.ActivityVocabularyType(ceObj)) {
-	case true:
+	pollOptions := outboundPollOptions(evt)
+	switch {
+	case len(pollOptions) > 0:
+		objType = vocab.QuestionType
+	case isOutboundEvent(evt, ceObj):
+		objType = vocab.EventType
+	case ceObj == string(vocab.QuestionType), ceObj == string(vocab.EventType):
+		// neither a poll without the options nor an event without the start time is displayed properly
+		objType = vocab.NoteType
+	case vocab.ObjectTypes.Contains(vocab.ActivityVocabularyType(ceObj)):
 		objType = vocab.ActivityVocabularyType(ceObj)
 	default:
 		objType = vocab.NoteType
@@ -800,7 +865,7 @@ func (svc service) ConvertEventToActivity(ctx context.Context, evt *pb.CloudEven
 
 	attrCats, _ := evt.Attributes[CeKeyCategories]
 	cats := strings.Split(attrCats.GetCeString(), " ")
-	var tagsFormatted []string
+	var tags []NoteTag
 	var tagCount int
 	for _, cat := range cats {
 		var tagName string
@@ -814,13 +879,12 @@ func (svc service) ConvertEventToActivity(ctx context.Context, evt *pb.CloudEven
 			tag := vocab.LinkNew("", "")
 			tag.Name = vocab.DefaultNaturalLanguageValue("#" + tagName)
 			tag.Type = "Hashtag"
-			tag.Href = vocab.IRI("https://mastodon.social/tags/" + tagName)
+			tag.Href = vocab.IRI(svc.tagUrl(tagName))
 			obj.Tag = append(obj.Tag, tag)
-			tagFormatted := fmt.Sprintf(
-				"<a rel=\"tag\" class=\"mention hashtag\" href=\"%s\">%s</a>",
-				tag.Href, tag.Name.String(),
-			)
-			tagsFormatted = append(tagsFormatted, tagFormatted)
+			tags = append(tags, NoteTag{
+				Name: tag.Name.String(),
+				Href: tag.Href.String(),
+			})
 		}
 		tagCount++
 		if tagCount > 10 {
@@ -828,15 +892,36 @@ func (svc service) ConvertEventToActivity(ctx context.Context, evt *pb.CloudEven
 		}
 	}
 
-	var tagsFormattedStr string
-	if len(tagsFormatted) > 0 {
-		tagsFormattedStr = fmt.Sprintf("<br/>%s", strings.Join(tagsFormatted, " "))
+	lang := langDefault
+	if attrLang, langPresent := evt.Attributes[CeKeyLanguage]; langPresent {
+		if l := strings.ToLower(attrLang.GetCeString()); len(l) > 1 {
+			lang = l[:2]
+		}
+	}
+	data := NoteData{
+		Text:        txt,
+		Language:    lang,
+		Tags:        tags,
+		OriginUrl:   addrOrigin,
+		InterestUrl: svc.urlInterestBase + interestId,
+		MatchUrl:    a.URL.GetLink().String(),
+		Labels:      svc.format.labels(lang),
+	}
+	txt, err = renderTemplate(svc.format.Content, data)
+	if err != nil {
+		return
 	}
-	txt += fmt.Sprintf(
-		"<br/>%s<br/><br/><a href=\"%s\">Origin</a> | <a href=\"%s%s\">Interest</a> | <a href=\"%s\">Match</a>",
-		tagsFormattedStr, addrOrigin, svc.urlInterestBase, interestId, a.URL,
-	)
 	obj.Content = vocab.DefaultNaturalLanguageValue(txt)
+	if svc.format.Summary != nil {
+		var summ string
+		summ, err = renderTemplate(svc.format.Summary, data)
+		if err != nil {
+			return
+		}
+		if summ = strings.TrimSpace(summ); summ != "" {
+			obj.Summary = vocab.DefaultNaturalLanguageValue(summ)
+		}
+	}
 
 	//if follower != nil {
 	//	followerMention := "@" + follower.PreferredUsername.First().Value.String()
@@ -873,7 +958,6 @@ func (svc service) ConvertEventToActivity(ctx context.Context, evt *pb.CloudEven
 	replies.First = repliesPageFirst
 	repliesPageFirst.Next = repliesPageFirst.PartOf
 
-	attachments := vocab.ItemCollection{}
 	attrIco, attrIcoPresent := evt.Attributes[CeKeyIcon]
 	if attrIcoPresent {
 		icoUrl := attrIco.GetCeString()
@@ -882,47 +966,18 @@ func (svc service) ConvertEventToActivity(ctx context.Context, evt *pb.CloudEven
 		}
 		obj.Icon = vocab.LinkNew(vocab.ID(icoUrl), vocab.LinkType)
 	}
-	attrAttType, attrAttTypePresent := evt.Attributes[CeKeyAttachmentType]
-	attrAttUrl, attrAttUrlPresent := evt.Attributes[CeKeyAttachmentUrl]
-	if attrAttTypePresent && attrAttUrlPresent {
-		objAttUrl := attrAttUrl.GetCeString()
-		if objAttUrl == "" {
-			objAttUrl = attrAttUrl.GetCeUri()
-		}
-		attachments = append(attachments, &vocab.Object{
-			Type:      vocab.DocumentType,
-			MediaType: vocab.MimeType(attrAttType.GetCeString()),
-			URL:       vocab.IRI(objAttUrl),
-		})
+	var imgUrl string
+	obj.Attachment, imgUrl = svc.convertMedia(ctx, evt)
+	if imgUrl != "" {
+		obj.Image = vocab.LinkNew(vocab.ID(imgUrl), vocab.LinkType)
 	}
-	for _, k := range []string{CeKeyPreview, CeKeySrcImageUrl, CeKeyImageUrl} {
-		attrImg, attrImgPresent := evt.Attributes[k]
-		if attrImgPresent {
-			imgUrl := attrImg.GetCeString()
-			if imgUrl == "" {
-				imgUrl = attrIco.GetCeUri()
-			}
-			if imgUrl != "" {
-				for _, att := range attachments {
-					if att.(*vocab.Object).URL == vocab.IRI(imgUrl) {
-						imgUrl = "" // discard duplicate
-						break
-					}
-				}
-			}
-			if imgUrl != "" {
-				if obj.Image == nil {
-					obj.Image = vocab.LinkNew(vocab.ID(imgUrl), vocab.LinkType)
-				} else {
-					attachments = append(attachments, &vocab.Object{
-						Type: vocab.ImageType,
-						URL:  vocab.IRI(imgUrl),
-					})
-				}
-			}
-		}
+
+	switch objType {
+	case vocab.QuestionType:
+		a.Object = svc.outboundPoll(evt, *obj, pollOptions)
+	case vocab.EventType:
+		a.Object = outboundEvent(evt, *obj, txtPlain)
 	}
-	obj.Attachment = attachments
 
 	attrAction, actionPresent := evt.Attributes[CeKeyAction]
 	switch actionPresent {
@@ -965,6 +1020,82 @@ func (svc service) initActivity(evt *pb.CloudEvent, interestId string, follower
 	return
 }
 
+// announceableObject returns the original object IRI when the event comes from the Fediverse and the object may be
+// boosted, i.e. it's created or announced by the source and not restricted to the source's followers or mentions.
+// The undo is true when the source deleted the object, so the boost should be undone.
+// Returns an empty string otherwise.
+func (svc service) announceableObject(evt *pb.CloudEvent) (addr string, undo bool) {
+	if evt.Type != svc.ceType && !strings.HasPrefix(evt.Type, ceTypePrefixFollowersOnly) {
+		return
+	}
+	if attrAction, actionPresent := evt.Attributes[CeKeyAction]; actionPresent {
+		switch vocab.ActivityVocabularyType(attrAction.GetCeString()) {
+		case vocab.CreateType, vocab.AnnounceType:
+		case vocab.DeleteType:
+			undo = true
+		default:
+			return
+		}
+	}
+	if attrVis, visPresent := evt.Attributes[CeKeyVisibility]; visPresent {
+		vSrc, errVis := model.ParseVisibility(attrVis.GetCeString())
+		if errVis != nil || vSrc > model.VisibilityUnlisted {
+			return
+		}
+	}
+	if attrObjUrl, attrObjUrlPresent := evt.Attributes[CeKeyObjectUrl]; attrObjUrlPresent {
+		addr = attrObjUrl.GetCeUri()
+		if addr == "" {
+			addr = attrObjUrl.GetCeString()
+		}
+	}
+	switch {
+	case svc.rewrites.Rewritten(addr):
+		// rewritten to the network of origin, e.g. the Bluesky web URL, not resolvable as an ActivityPub object
+		addr = ""
+	case strings.HasPrefix(addr, "https://"), strings.HasPrefix(addr, "http://"):
+	default:
+		addr = ""
+	}
+	if addr == "" {
+		undo = false
+	}
+	return
+}
+
+// announceId is the same for every boost of the object by the interest actor, so the boost may be undone by the
+// later event.
+func (svc service) announceId(interestId, addrObj string) (id vocab.ID) {
+	h := sha256.Sum256([]byte(addrObj))
+	id = vocab.ID(fmt.Sprintf("%s/actor/%s/announce/%x", svc.urlBase, interestId, h[:16]))
+	return
+}
+
+// tagUrl points the hashtag to the local collection of the public notes having it.
+func (svc service) tagUrl(tagName string) (addr string) {
+	addr = svc.urlBase + "/tags/" + url.PathEscape(model.NormalizeTag(tagName))
+	return
+}
+
+// outboundVisibility never makes the publication more visible than the source did.
+func (svc service) outboundVisibility(evt *pb.CloudEvent) (v model.Visibility) {
+	switch {
+	case evt.Type == svc.ceType, strings.HasPrefix(evt.Type, ceTypePrefixFollowersOnly):
+		v = model.VisibilityFollowers
+	default:
+		v = model.VisibilityPublic
+	}
+	if attrVis, visPresent := evt.Attributes[CeKeyVisibility]; visPresent {
+		if vSrc, errVis := model.ParseVisibility(attrVis.GetCeString()); errVis == nil && vSrc > v {
+			v = vSrc
+		}
+	}
+	if attrDisc, discPresent := evt.Attributes[CeKeyDiscoverable]; discPresent && !attrDisc.GetCeBoolean() && v == model.VisibilityPublic {
+		v = model.VisibilityUnlisted
+	}
+	return
+}
+
 func eventSummaryText(evt *pb.CloudEvent) (txt string) {
 
 	attrHead, headPresent := evt.Attributes[CeKeyHeadline]
@@ -1011,27 +1142,3 @@ func eventSummaryText(evt *pb.CloudEvent) (txt string) {
 
 	return
 }
-
-func truncateStringUtf8(s string, lenMax int) string {
-	if len(s) <= lenMax {
-		return s
-	}
-	// Ensure we don't split a UTF-8 character in the middle.
-	for i := lenMax - 3; i > 0; i-- {
-		if utf8.RuneStart(s[i]) {
-			return s[:i] + "..."
-		}
-	}
-	return ""
-}
-
-func objectUrl(src string) (dst string) {
-	switch {
-	case strings.HasPrefix(src, prefixObjUrlBridgy):
-		dst = prefixObjUrlBluesky + strings.TrimPrefix(src, prefixObjUrlBridgy)
-		dst = strings.Replace(dst, "/app.bsky.feed.post/", "/post/", 1)
-	default:
-		dst = src
-	}
-	return
-}
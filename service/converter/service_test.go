@@ -2,6 +2,7 @@ package converter
 
 import (
 	"context"
+	"github.com/awakari/int-activitypub/model"
 	"github.com/awakari/int-activitypub/util"
 	"github.com/bytedance/sonic"
 	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
@@ -14,12 +15,28 @@ import (
 	"time"
 )
 
+var policyTest = model.VisibilityPolicy{
+	Actions: map[model.Visibility]model.VisibilityAction{
+		model.VisibilityPublic:   model.VisibilityActionPublish,
+		model.VisibilityUnlisted: model.VisibilityActionPublishUndiscoverable,
+	},
+	AnnounceRestricted: model.VisibilityActionPublishUndiscoverable,
+}
+
 func TestService_ConvertActivityToEvent(t *testing.T) {
-	svc := NewService("foo", "urlBase", "https://awakari.com/sub-details.html?id=", "https://reader/evt", vocab.ServiceType)
+	svc := NewService(Options{
+		CeType:          "foo",
+		UrlBase:         "urlBase",
+		UrlInterestBase: "https://awakari.com/sub-details.html?id=",
+		EvtReaderBase:   "https://reader/evt",
+		ActorType:       vocab.ServiceType,
+		Policy:          policyTest,
+		Rewrites:        model.UrlRewritesBuiltin,
+	}, nil)
 	svc = NewLogging(svc, slog.Default())
 	cases := map[string]struct {
 		actor vocab.Actor
-		cm    util.ActivityContentMap
+		cm    map[string]string
 		in    string
 		out   *pb.CloudEvent
 		err   error
@@ -77,6 +94,11 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 				Type:        "foo",
 				Source:      "https://mastodon.social/users/johndoe",
 				Attributes: map[string]*pb.CloudEventAttributeValue{
+					"visibility": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "public",
+						},
+					},
 					"action": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "Create",
@@ -155,6 +177,11 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 				Type:        "foo",
 				Source:      "https://mastodon.social/users/johndoe",
 				Attributes: map[string]*pb.CloudEventAttributeValue{
+					"visibility": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "public",
+						},
+					},
 					"subject": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "John Doe",
@@ -170,6 +197,16 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 							CeString: "https://rhiaro.co.uk/followers/ https://www.w3.org/ns/activitystreams#Public",
 						},
 					},
+					"language": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "en",
+						},
+					},
+					"languageconfidence": {
+						Attr: &pb.CloudEventAttributeValue_CeInteger{
+							CeInteger: 100,
+						},
+					},
 					"object": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "Article",
@@ -222,11 +259,26 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 				SpecVersion: "1.0",
 				Type:        "foo",
 				Attributes: map[string]*pb.CloudEventAttributeValue{
+					"visibility": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "public",
+						},
+					},
 					"action": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "Create",
 						},
 					},
+					"language": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "en",
+						},
+					},
+					"languageconfidence": {
+						Attr: &pb.CloudEventAttributeValue_CeInteger{
+							CeInteger: 100,
+						},
+					},
 					"latitude": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "-73.974740",
@@ -284,6 +336,11 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 				SpecVersion: "1.0",
 				Type:        "foo",
 				Attributes: map[string]*pb.CloudEventAttributeValue{
+					"visibility": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "public",
+						},
+					},
 					"action": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "Like",
@@ -299,6 +356,16 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 							CeString: "Chris liked 'Minimal ActivityPub update client'",
 						},
 					},
+					"language": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "en",
+						},
+					},
+					"languageconfidence": {
+						Attr: &pb.CloudEventAttributeValue_CeInteger{
+							CeInteger: 100,
+						},
+					},
 					"object": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "Like",
@@ -362,6 +429,16 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 				SpecVersion: "1.0",
 				Type:        "foo",
 				Attributes: map[string]*pb.CloudEventAttributeValue{
+					"discoverable": {
+						Attr: &pb.CloudEventAttributeValue_CeBoolean{
+							CeBoolean: false,
+						},
+					},
+					"visibility": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "unlisted",
+						},
+					},
 					"action": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "Add",
@@ -377,6 +454,16 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 							CeString: "Martin added an article to his blog",
 						},
 					},
+					"language": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "en",
+						},
+					},
+					"languageconfidence": {
+						Attr: &pb.CloudEventAttributeValue_CeInteger{
+							CeInteger: 100,
+						},
+					},
 					"object": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "Article",
@@ -384,7 +471,12 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 					},
 					"objecturl": {
 						Attr: &pb.CloudEventAttributeValue_CeUri{
-							CeUri: "http://www.test.example/blog/abc123/xyz",
+							CeUri: "http://example.org/blog/2011/02/entry",
+						},
+					},
+					"title": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "Why I love Activity Streams",
 						},
 					},
 					"time": {
@@ -464,6 +556,11 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 				SpecVersion: "1.0",
 				Type:        "foo",
 				Attributes: map[string]*pb.CloudEventAttributeValue{
+					"visibility": {
+						Attr: &pb.CloudEventAttributeValue_CeString{
+							CeString: "public",
+						},
+					},
 					"cc": {
 						Attr: &pb.CloudEventAttributeValue_CeString{
 							CeString: "https://mastodon.social/users/akurilov/followers",
@@ -501,7 +598,7 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 			var activity vocab.Activity
 			err := sonic.Unmarshal([]byte(c.in), &activity)
 			require.Nil(t, err)
-			evt, err := svc.ConvertActivityToEvent(context.TODO(), c.actor, activity, util.ActivityTags{}, c.cm)
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), c.actor, util.Envelope{Activity: activity, ContentMap: c.cm})
 			if c.out == nil {
 				assert.Nil(t, evt)
 			} else {
@@ -518,7 +615,15 @@ func TestService_ConvertActivityToEvent(t *testing.T) {
 }
 
 func TestService_ConvertEventToActivity(t *testing.T) {
-	svc := NewService("foo", "https://base", "https://awakari.com/sub-details.html?id=", "https://reader/evt", vocab.ServiceType)
+	svc := NewService(Options{
+		CeType:          "foo",
+		UrlBase:         "https://base",
+		UrlInterestBase: "https://awakari.com/sub-details.html?id=",
+		EvtReaderBase:   "https://reader/evt",
+		ActorType:       vocab.ServiceType,
+		Policy:          policyTest,
+		Rewrites:        model.UrlRewritesBuiltin,
+	}, nil)
 	svc = NewLogging(svc, slog.Default())
 	ts := time.Date(2024, 7, 27, 1, 32, 21, 0, time.UTC)
 	cases := map[string]struct {
@@ -602,12 +707,20 @@ func TestService_ConvertEventToActivity(t *testing.T) {
 					Type:         "Note",
 					Name:         vocab.NaturalLanguageValues{},
 					AttributedTo: vocab.IRI("https://otakukart.com/feed/"),
-					Attachment:   vocab.ItemCollection{},
+					Attachment: vocab.ItemCollection{
+						model.Media{
+							Object: vocab.Object{
+								Type:      vocab.ImageType,
+								MediaType: "image/jpeg",
+								URL:       vocab.IRI("https://otakukart.com/wp-content/uploads/2024/07/The-10-Must-Watch-Futuristic-Anime-That-Every-Fan-Should-See.jpg"),
+							},
+						},
+					},
 					Image: &vocab.Link{
 						ID:   "https://otakukart.com/wp-content/uploads/2024/07/The-10-Must-Watch-Futuristic-Anime-That-Every-Fan-Should-See.jpg",
 						Type: "Link",
 					},
-					Content:   vocab.DefaultNaturalLanguageValue(`The 10 Must-Watch Futuristic Anime That Every Fan Should See Anime is known for its wide range of stories, each reflecting the boundless creativity of its creators. Among the various genres, specul...<br/><br/><a rel="tag" class="mention hashtag" href="https://mastodon.social/tags/anime">#anime</a> <a rel="tag" class="mention hashtag" href="https://mastodon.social/tags/otaku">#otaku</a><br/><br/><a href="https://otakukart.com/the-10-must-watch-futuristic-anime-that-every-fan-should-see/">Origin</a> | <a href="https://awakari.com/sub-details.html?id=interest1">Interest</a> | <a href="https://reader/evt2jrVcFeXfGNcExKHLCcrrXBYyLJ&interestId=interest1">Match</a>`),
+					Content:   vocab.DefaultNaturalLanguageValue(`The 10 Must-Watch Futuristic Anime That Every Fan Should See Anime is known for its wide range of stories, each reflecting the boundless creativity of its creators. Among the various genres, specul...<br/><br/><a rel="tag" class="mention hashtag" href="https://base/tags/anime">#anime</a> <a rel="tag" class="mention hashtag" href="https://base/tags/otaku">#otaku</a><br/><br/><a href="https://otakukart.com/the-10-must-watch-futuristic-anime-that-every-fan-should-see/">Origin</a> | <a href="https://awakari.com/sub-details.html?id=interest1">Interest</a> | <a href="https://reader/evt2jrVcFeXfGNcExKHLCcrrXBYyLJ&amp;interestId=interest1">Match</a>`),
 					Published: ts,
 					Replies: &vocab.Collection{
 						ID:      "https://otakukart.com/the-10-must-watch-futuristic-anime-that-every-fan-should-see/replies",
@@ -628,12 +741,12 @@ func TestService_ConvertEventToActivity(t *testing.T) {
 						&vocab.Link{
 							Type: "Hashtag",
 							Name: vocab.DefaultNaturalLanguageValue("#anime"),
-							Href: vocab.IRI("https://mastodon.social/tags/anime"),
+							Href: vocab.IRI("https://base/tags/anime"),
 						},
 						&vocab.Link{
 							Type: "Hashtag",
 							Name: vocab.DefaultNaturalLanguageValue("#otaku"),
-							Href: vocab.IRI("https://mastodon.social/tags/otaku"),
+							Href: vocab.IRI("https://base/tags/otaku"),
 						},
 					},
 					To: vocab.ItemCollection{
@@ -746,7 +859,7 @@ func TestService_ConvertEventToActivity(t *testing.T) {
 					Name:         vocab.NaturalLanguageValues{},
 					Attachment:   vocab.ItemCollection{},
 					AttributedTo: vocab.IRI("http://rss.arxiv.org/rss/hep-ex"),
-					Content:      vocab.DefaultNaturalLanguageValue(`Torsion Balance Experiments Enable Direct Detection of Sub-eV Dark Matter arXiv:2506.07763v1 Announce Type: cross Abstract: Light dark matter with sub-eV masses has a high number density in our gal...<br/><br/><a rel="tag" class="mention hashtag" href="https://mastodon.social/tags/hep-ph">#hep-ph</a> <a rel="tag" class="mention hashtag" href="https://mastodon.social/tags/hep-ex">#hep-ex</a><br/><br/><a href="https://arxiv.org/abs/2506.07763">Origin</a> | <a href="https://awakari.com/sub-details.html?id=interest1">Interest</a> | <a href="https://reader/evtRdkNYGkgLyvmI7G4XhHmIeGgANM&interestId=interest1">Match</a>`),
+					Content:      vocab.DefaultNaturalLanguageValue(`Torsion Balance Experiments Enable Direct Detection of Sub-eV Dark Matter arXiv:2506.07763v1 Announce Type: cross Abstract: Light dark matter with sub-eV masses has a high number density in our gal...<br/><br/><a rel="tag" class="mention hashtag" href="https://base/tags/hep-ph">#hep-ph</a> <a rel="tag" class="mention hashtag" href="https://base/tags/hep-ex">#hep-ex</a><br/><br/><a href="https://arxiv.org/abs/2506.07763">Origin</a> | <a href="https://awakari.com/sub-details.html?id=interest1">Interest</a> | <a href="https://reader/evtRdkNYGkgLyvmI7G4XhHmIeGgANM&amp;interestId=interest1">Match</a>`),
 					Replies: &vocab.Collection{
 						ID:      vocab.IRI("https://arxiv.org/abs/2506.07763/replies"),
 						Type:    vocab.CollectionType,
@@ -766,12 +879,12 @@ func TestService_ConvertEventToActivity(t *testing.T) {
 						&vocab.Link{
 							Type: "Hashtag",
 							Name: vocab.DefaultNaturalLanguageValue("#hep-ph"),
-							Href: "https://mastodon.social/tags/hep-ph",
+							Href: "https://base/tags/hep-ph",
 						},
 						&vocab.Link{
 							Type: "Hashtag",
 							Name: vocab.DefaultNaturalLanguageValue("#hep-ex"),
-							Href: "https://mastodon.social/tags/hep-ex",
+							Href: "https://base/tags/hep-ex",
 						},
 					},
 					To: vocab.ItemCollection{
@@ -793,8 +906,197 @@ func TestService_ConvertEventToActivity(t *testing.T) {
 	}
 }
 
+func TestService_ConvertEventToActivity_Announce(t *testing.T) {
+	outbound := model.OutboundPolicy{
+		Default: model.OutboundModeAnnounce,
+		Interests: map[string]model.OutboundMode{
+			"interest_note": model.OutboundModeNote,
+		},
+	}
+	svc := NewService(Options{
+		CeType:          "com_awakari_activitypub_v1",
+		UrlBase:         "https://base",
+		UrlInterestBase: "https://awakari.com/sub-details.html?id=",
+		EvtReaderBase:   "https://reader/evt",
+		ActorType:       vocab.ServiceType,
+		Policy:          policyTest,
+		Outbound:        outbound,
+		Rewrites:        model.UrlRewritesBuiltin,
+	}, nil)
+	svc = NewLogging(svc, slog.Default())
+	ts := time.Date(2024, 7, 27, 1, 32, 21, 0, time.UTC)
+	follower := &vocab.Actor{
+		ID: "https://mastodon.social/users/johndoe",
+	}
+	cases := map[string]struct {
+		typ        string
+		source     string
+		attrs      map[string]*pb.CloudEventAttributeValue
+		interestId string
+		announce   vocab.IRI
+		undo       bool
+	}{
+		"fediverse object": {
+			typ:    "com_awakari_activitypub_v1",
+			source: "https://origin.social/users/jane",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAction: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "Create",
+					},
+				},
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://origin.social/users/jane/statuses/1",
+					},
+				},
+				CeKeyVisibility: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "public",
+					},
+				},
+			},
+			interestId: "interest0",
+			announce:   "https://origin.social/users/jane/statuses/1",
+		},
+		"interest in note mode": {
+			typ:    "com_awakari_activitypub_v1",
+			source: "https://origin.social/users/jane",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://origin.social/users/jane/statuses/1",
+					},
+				},
+			},
+			interestId: "interest_note",
+		},
+		"not a fediverse event": {
+			typ:    "com_awakari_feeds_v1",
+			source: "https://origin.com/feed.xml",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://origin.com/posts/1",
+					},
+				},
+			},
+			interestId: "interest0",
+		},
+		"followers only original": {
+			typ:    "com_awakari_activitypub_v1",
+			source: "https://origin.social/users/jane",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://origin.social/users/jane/statuses/1",
+					},
+				},
+				CeKeyVisibility: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "followers",
+					},
+				},
+			},
+			interestId: "interest0",
+		},
+		"deleted original": {
+			typ:    "com_awakari_activitypub_v1",
+			source: "https://origin.social/users/jane",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAction: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "Delete",
+					},
+				},
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://origin.social/users/jane/statuses/1",
+					},
+				},
+			},
+			interestId: "interest0",
+			announce:   "https://origin.social/users/jane/statuses/1",
+			undo:       true,
+		},
+		"update": {
+			typ:    "com_awakari_activitypub_v1",
+			source: "https://origin.social/users/jane",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyAction: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "Update",
+					},
+				},
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://origin.social/users/jane/statuses/1",
+					},
+				},
+			},
+			interestId: "interest0",
+		},
+		"bluesky bridged": {
+			typ:    "com_awakari_activitypub_v1",
+			source: "https://bsky.app/profile/did:plc:abc",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyObjectUrl: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://bsky.app/profile/did:plc:abc/post/123",
+					},
+				},
+			},
+			interestId: "interest0",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			evt := &pb.CloudEvent{
+				Id:          "2jrVcFeXfGNcExKHLCcrrXBYyLJ",
+				SpecVersion: CeSpecVersion,
+				Source:      c.source,
+				Type:        c.typ,
+				Attributes:  c.attrs,
+			}
+			a, err := svc.ConvertEventToActivity(context.TODO(), evt, c.interestId, follower, &ts)
+			assert.Nil(t, err)
+			assert.Equal(t, vocab.IRI("https://base/actor/"+c.interestId), a.Actor)
+			announceId := vocab.ID("https://base/actor/" + c.interestId + "/announce/bd0fc1c60d0baa998ad0927c9d698e1a")
+			switch {
+			case c.announce == "":
+				assert.Equal(t, vocab.ID("https://base/2jrVcFeXfGNcExKHLCcrrXBYyLJ"), a.ID)
+				assert.NotEqual(t, vocab.AnnounceType, a.Type)
+				assert.Equal(t, vocab.NoteType, a.Object.GetType())
+			case c.undo:
+				assert.Equal(t, vocab.ID("https://base/2jrVcFeXfGNcExKHLCcrrXBYyLJ"), a.ID)
+				assert.Equal(t, vocab.UndoType, a.Type)
+				assert.Equal(t, &vocab.Activity{
+					Type:   vocab.AnnounceType,
+					ID:     announceId,
+					Actor:  a.Actor,
+					Object: c.announce,
+				}, a.Object)
+				assert.Contains(t, a.CC, vocab.IRI(c.source))
+			default:
+				assert.Equal(t, announceId, a.ID)
+				assert.Equal(t, vocab.AnnounceType, a.Type)
+				assert.Equal(t, c.announce, a.Object)
+				assert.Contains(t, a.CC, vocab.IRI(c.source))
+			}
+		})
+	}
+}
+
 func TestService_ConvertEventToActorUpdate(t *testing.T) {
-	svc := NewService("foo", "https://base", "https://awakari.com/sub-details.html?id=", "https://reader/evt", vocab.ServiceType)
+	svc := NewService(Options{
+		CeType:          "foo",
+		UrlBase:         "https://base",
+		UrlInterestBase: "https://awakari.com/sub-details.html?id=",
+		EvtReaderBase:   "https://reader/evt",
+		ActorType:       vocab.ServiceType,
+		Policy:          policyTest,
+		Rewrites:        model.UrlRewritesBuiltin,
+	}, nil)
 	svc = NewLogging(svc, slog.Default())
 	ts := time.Date(2024, 7, 27, 1, 32, 21, 0, time.UTC)
 	cases := map[string]struct {
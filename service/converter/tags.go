@@ -0,0 +1,119 @@
+package converter
+
+import (
+	"fmt"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/bytedance/sonic"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	"strings"
+)
+
+const tagTypeEmoji = "Emoji"
+const tagTypeHashtag = "Hashtag"
+const tagTypeLink = "Link"
+const tagTypeMention = "Mention"
+
+// quoteProps are the object properties referencing the quoted post, in the order of preference.
+var quoteProps = []string{
+	"quote",          // FEP-044f
+	"quoteUrl",       // Akkoma, Misskey
+	"quoteUri",       // Fedibird
+	"_misskey_quote", // Misskey
+}
+
+// convertTags splits the decoded tags: the hashtags go to the categories, the mentions to the mentioned actor IRIs
+// and the custom emoji to the shortcode -> image URL JSON object. Does nothing when there are no tags decoded.
+func convertTags(tags []util.ActivityTag, evt *pb.CloudEvent) (err error) {
+	if len(tags) == 0 {
+		return
+	}
+	var hashtags, mentions []string
+	emoji := map[string]string{}
+	for _, t := range tags {
+		switch t.Type {
+		case tagTypeHashtag:
+			switch {
+			case t.Name == "":
+			case strings.HasPrefix(t.Name, "#"):
+				hashtags = append(hashtags, t.Name)
+			default:
+				// PeerTube omits the hash sign
+				hashtags = append(hashtags, "#"+t.Name)
+			}
+		case tagTypeMention:
+			if t.Href != "" {
+				mentions = append(mentions, t.Href)
+			}
+		case tagTypeEmoji:
+			if t.Name != "" && t.IconUrl != "" {
+				emoji[t.Name] = t.IconUrl
+			}
+		}
+	}
+	switch len(hashtags) {
+	case 0:
+		delete(evt.Attributes, CeKeyCategories)
+	default:
+		evt.Attributes[CeKeyCategories] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: strings.Join(hashtags, " "),
+			},
+		}
+	}
+	if len(mentions) > 0 {
+		evt.Attributes[CeKeyMentions] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeString{
+				CeString: strings.Join(mentions, " "),
+			},
+		}
+	}
+	if len(emoji) > 0 {
+		var data []byte
+		data, err = sonic.ConfigStd.Marshal(emoji)
+		switch err {
+		case nil:
+			evt.Attributes[CeKeyEmoji] = &pb.CloudEventAttributeValue{
+				Attr: &pb.CloudEventAttributeValue_CeString{
+					CeString: string(data),
+				},
+			}
+		default:
+			err = fmt.Errorf("%w emoji: %s", ErrFail, err)
+		}
+	}
+	return
+}
+
+// convertQuote resolves the quoted post address from the known quote properties or the FEP-e232 object link tag.
+func convertQuote(tags []util.ActivityTag, extra map[string][]byte, evt *pb.CloudEvent) {
+	var addr string
+	for _, k := range quoteProps {
+		if raw, present := extra[k]; present {
+			_ = sonic.Unmarshal(raw, &addr)
+			if addr != "" {
+				break
+			}
+		}
+	}
+	if addr == "" {
+		for _, t := range tags {
+			if t.Type == tagTypeLink && t.Href != "" && objectLinkMediaType(t.MediaType) {
+				addr = t.Href
+				break
+			}
+		}
+	}
+	if strings.HasPrefix(addr, "https://") || strings.HasPrefix(addr, "http://") {
+		evt.Attributes[CeKeyQuoteOf] = &pb.CloudEventAttributeValue{
+			Attr: &pb.CloudEventAttributeValue_CeUri{
+				CeUri: addr,
+			},
+		}
+	}
+}
+
+// objectLinkMediaType returns true for the ActivityPub object media types, see FEP-e232.
+func objectLinkMediaType(mt string) bool {
+	return strings.HasPrefix(mt, "application/activity+json") ||
+		strings.HasPrefix(mt, "application/ld+json") && strings.Contains(mt, "https://www.w3.org/ns/activitystreams")
+}
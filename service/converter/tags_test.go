@@ -0,0 +1,163 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestService_ConvertActivityToEvent_Tags(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "urlBase",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	cases := map[string]struct {
+		in      string
+		attrs   map[string]*pb.CloudEventAttributeValue
+		missing []string
+	}{
+		"mastodon mentions, hashtags and emoji": {
+			in: `{
+				"id": "https://mastodon.social/users/johndoe/statuses/1/activity",
+				"type": "Create",
+				"actor": "https://mastodon.social/users/johndoe",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.social/users/johndoe/statuses/1",
+					"type": "Note",
+					"content": "<p>@janedoe look at this #golang :blobcat:</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"tag": [
+						{"type": "Mention", "href": "https://fosstodon.org/users/janedoe", "name": "@janedoe@fosstodon.org"},
+						{"type": "Hashtag", "href": "https://mastodon.social/tags/golang", "name": "#golang"},
+						{"type": "Hashtag", "href": "https://mastodon.social/tags/fediverse", "name": "#fediverse"},
+						{
+							"type": "Emoji",
+							"name": ":blobcat:",
+							"icon": {"type": "Image", "mediaType": "image/png", "url": "https://files.mastodon.social/blobcat.png"}
+						}
+					]
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyCategories: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "#golang #fediverse",
+					},
+				},
+				CeKeyMentions: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "https://fosstodon.org/users/janedoe",
+					},
+				},
+				CeKeyEmoji: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: `{":blobcat:":"https://files.mastodon.social/blobcat.png"}`,
+					},
+				},
+			},
+			missing: []string{CeKeyQuoteOf},
+		},
+		"mentions only": {
+			in: `{
+				"id": "https://mastodon.social/users/johndoe/statuses/2/activity",
+				"type": "Create",
+				"actor": "https://mastodon.social/users/johndoe",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://mastodon.social/users/johndoe/statuses/2",
+					"type": "Note",
+					"content": "<p>@janedoe hi</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"tag": {"type": "Mention", "href": "https://fosstodon.org/users/janedoe", "name": "@janedoe@fosstodon.org"}
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyMentions: {
+					Attr: &pb.CloudEventAttributeValue_CeString{
+						CeString: "https://fosstodon.org/users/janedoe",
+					},
+				},
+			},
+			missing: []string{CeKeyCategories, CeKeyEmoji},
+		},
+		"misskey quote": {
+			in: `{
+				"id": "https://misskey.io/notes/9tq1bbbbbb/activity",
+				"type": "Create",
+				"actor": "https://misskey.io/users/9abc",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://misskey.io/notes/9tq1bbbbbb",
+					"type": "Note",
+					"content": "<p>RE: this</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"_misskey_quote": "https://misskey.io/notes/9tq1aaaaaa",
+					"quoteUrl": "https://misskey.io/notes/9tq1aaaaaa"
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyQuoteOf: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://misskey.io/notes/9tq1aaaaaa",
+					},
+				},
+			},
+		},
+		"fep-e232 quote link": {
+			in: `{
+				"id": "https://server.example/notes/2/activity",
+				"type": "Create",
+				"actor": "https://server.example/users/alice",
+				"to": ["https://www.w3.org/ns/activitystreams#Public"],
+				"object": {
+					"id": "https://server.example/notes/2",
+					"type": "Note",
+					"content": "<p>RE: https://other.example/notes/1</p>",
+					"to": ["https://www.w3.org/ns/activitystreams#Public"],
+					"tag": [
+						{
+							"type": "Link",
+							"mediaType": "application/ld+json; profile=\"https://www.w3.org/ns/activitystreams\"",
+							"href": "https://other.example/notes/1",
+							"name": "RE: https://other.example/notes/1"
+						}
+					]
+				}
+			}`,
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyQuoteOf: {
+					Attr: &pb.CloudEventAttributeValue_CeUri{
+						CeUri: "https://other.example/notes/1",
+					},
+				},
+			},
+			missing: []string{CeKeyCategories, CeKeyMentions, CeKeyEmoji},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID: env.Activity.Actor.GetLink(),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			require.Nil(t, err)
+			for k, v := range c.attrs {
+				assert.Equal(t, v, evt.Attributes[k], k)
+			}
+			for _, k := range c.missing {
+				assert.NotContains(t, evt.Attributes, k)
+			}
+		})
+	}
+}
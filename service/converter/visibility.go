@@ -0,0 +1,158 @@
+package converter
+
+import (
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/bytedance/sonic"
+	vocab "github.com/go-ap/activitypub"
+	"strings"
+)
+
+// Pleroma and Akkoma address the local-only posts to "https://<instance>/#Public"
+const suffixLocalPublic = "/#Public"
+
+// extension flags marking the post as the local-only one
+var extLocalOnly = []string{
+	"localOnly",
+	"_misskey_localOnly",
+}
+
+const extInteractionPolicy = "interactionPolicy"
+
+// gtsInteractionPolicy is the GoToSocial interaction policy, see https://docs.gotosocial.org/en/latest/federation/interaction_policy/
+type gtsInteractionPolicy struct {
+	CanAnnounce *struct {
+		Always            []string `json:"always"`
+		AutomaticApproval []string `json:"automaticApproval"`
+	} `json:"canAnnounce"`
+}
+
+type addressing struct {
+	to  vocab.ItemCollection
+	cc  vocab.ItemCollection
+	bto vocab.ItemCollection
+	bcc vocab.ItemCollection
+	aud vocab.ItemCollection
+}
+
+func (a addressing) empty() bool {
+	return len(a.to) == 0 && len(a.cc) == 0 && len(a.bto) == 0 && len(a.bcc) == 0 && len(a.aud) == 0
+}
+
+// classifyVisibility takes the most permissive visibility of the activity and the embedded object.
+func classifyVisibility(actor vocab.Actor, env util.Envelope) (c model.VisibilityClass) {
+	a := env.Activity
+	addrs := []addressing{
+		{
+			to:  a.To,
+			cc:  a.CC,
+			bto: a.Bto,
+			bcc: a.BCC,
+			aud: a.Audience,
+		},
+	}
+	if obj := a.Object; obj != nil && obj.IsObject() {
+		_ = vocab.OnObject(obj, func(o *vocab.Object) error {
+			addrs = append(addrs, addressing{
+				to:  o.To,
+				cc:  o.CC,
+				bto: o.Bto,
+				bcc: o.BCC,
+				aud: o.Audience,
+			})
+			return nil
+		})
+	}
+	c.Visibility = model.VisibilityDirect
+	var local bool
+	for _, addr := range addrs {
+		if addr.empty() {
+			continue
+		}
+		v, l := visibilityOf(actor, addr)
+		if v < c.Visibility {
+			c.Visibility = v
+		}
+		local = local || l
+	}
+	for _, extra := range []map[string][]byte{env.Extra, env.Object.Extra} {
+		for _, k := range extLocalOnly {
+			if string(extra[k]) == "true" {
+				local = true
+			}
+		}
+		if announceRestricted(extra[extInteractionPolicy]) {
+			c.AnnounceRestricted = true
+		}
+	}
+	if local {
+		c.Visibility = model.VisibilityLocal
+	}
+	return
+}
+
+func visibilityOf(actor vocab.Actor, addr addressing) (v model.Visibility, local bool) {
+	v = model.VisibilityDirect
+	for _, items := range []vocab.ItemCollection{addr.to, addr.aud, addr.cc, addr.bto, addr.bcc} {
+		for _, item := range items {
+			if item == nil {
+				continue
+			}
+			id := item.GetLink().String()
+			switch {
+			case isPublic(id):
+				// public in "cc" only is the unlisted post
+				switch {
+				case containsPublic(addr.to) || containsPublic(addr.aud):
+					v = model.VisibilityPublic
+				case v > model.VisibilityUnlisted:
+					v = model.VisibilityUnlisted
+				}
+			case strings.HasSuffix(id, suffixLocalPublic):
+				local = true
+			case actor.Followers != nil && id == actor.Followers.GetLink().String():
+				if v > model.VisibilityFollowers {
+					v = model.VisibilityFollowers
+				}
+			}
+		}
+	}
+	return
+}
+
+func isPublic(id string) bool {
+	switch id {
+	case asPublic, asPublicCompact, asPublicBare:
+		return true
+	}
+	return false
+}
+
+func containsPublic(items vocab.ItemCollection) bool {
+	for _, item := range items {
+		if item != nil && isPublic(item.GetLink().String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// announceRestricted is true when the interaction policy doesn't allow everybody to announce without the approval.
+func announceRestricted(raw []byte) (restricted bool) {
+	if len(raw) == 0 {
+		return
+	}
+	var p gtsInteractionPolicy
+	if sonic.Unmarshal(raw, &p) != nil || p.CanAnnounce == nil {
+		return
+	}
+	restricted = true
+	for _, items := range [][]string{p.CanAnnounce.Always, p.CanAnnounce.AutomaticApproval} {
+		for _, item := range items {
+			if isPublic(item) {
+				restricted = false
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,230 @@
+package converter
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestClassifyVisibility(t *testing.T) {
+	actor := vocab.Actor{
+		ID:        "https://host.social/users/john",
+		Followers: vocab.IRI("https://host.social/users/john/fans"),
+	}
+	cases := map[string]struct {
+		in  string
+		out model.VisibilityClass
+	}{
+		"public": {
+			in: `{"type":"Create","to":["https://www.w3.org/ns/activitystreams#Public"],"object":{"type":"Note","to":["https://www.w3.org/ns/activitystreams#Public"]}}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityPublic,
+			},
+		},
+		"public compact": {
+			in: `{"type":"Create","to":"as:Public","object":"https://host.social/notes/1"}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityPublic,
+			},
+		},
+		"public in the object only": {
+			in: `{"type":"Create","to":["https://host.social/users/john/fans"],"object":{"type":"Note","to":"as:Public"}}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityPublic,
+			},
+		},
+		"unlisted": {
+			in: `{"type":"Create","to":["https://host.social/users/john/followers"],"cc":["https://www.w3.org/ns/activitystreams#Public"]}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityUnlisted,
+			},
+		},
+		"followers": {
+			in: `{"type":"Create","to":["https://host.social/users/john/fans"],"cc":["https://other.social/users/jane"]}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityFollowers,
+			},
+		},
+		"direct": {
+			in: `{"type":"Create","to":["https://other.social/users/jane"],"object":{"type":"Note","to":["https://other.social/users/jane"]}}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityDirect,
+			},
+		},
+		"followers of another actor": {
+			in: `{"type":"Create","to":["https://host.social/users/john/followers"]}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityDirect,
+			},
+		},
+		"no addressing": {
+			in: `{"type":"Create","object":{"type":"Note","content":"foo"}}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityDirect,
+			},
+		},
+		"akkoma local": {
+			in: `{"type":"Create","to":["https://akkoma.example/#Public"],"cc":["https://akkoma.example/users/john/followers"]}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityLocal,
+			},
+		},
+		"misskey local only flag": {
+			in: `{"type":"Create","to":["https://www.w3.org/ns/activitystreams#Public"],"object":{"type":"Note","_misskey_localOnly":true}}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityLocal,
+			},
+		},
+		"gotosocial announce restricted": {
+			in: `{
+  "type": "Create",
+  "to": ["https://www.w3.org/ns/activitystreams#Public"],
+  "object": {
+    "type": "Note",
+    "interactionPolicy": {
+      "canAnnounce": {
+        "always": ["https://host.social/users/john"],
+        "approvalRequired": ["https://www.w3.org/ns/activitystreams#Public"]
+      }
+    }
+  }
+}`,
+			out: model.VisibilityClass{
+				Visibility:         model.VisibilityPublic,
+				AnnounceRestricted: true,
+			},
+		},
+		"gotosocial announce allowed": {
+			in: `{
+  "type": "Create",
+  "to": ["https://www.w3.org/ns/activitystreams#Public"],
+  "object": {
+    "type": "Note",
+    "interactionPolicy": {
+      "canAnnounce": {
+        "automaticApproval": ["https://www.w3.org/ns/activitystreams#Public"]
+      }
+    }
+  }
+}`,
+			out: model.VisibilityClass{
+				Visibility: model.VisibilityPublic,
+			},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			assert.Equal(t, c.out, classifyVisibility(actor, env))
+		})
+	}
+}
+
+func TestService_OutboundVisibility(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "https://base",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil).(service)
+	cases := map[string]struct {
+		typ   string
+		attrs map[string]*pb.CloudEventAttributeValue
+		out   model.Visibility
+	}{
+		"other source": {
+			typ: "com_awakari_feeds_v1",
+			out: model.VisibilityPublic,
+		},
+		"fediverse source": {
+			typ: "foo",
+			out: model.VisibilityFollowers,
+		},
+		"followers only source": {
+			typ: "com_awakari_mastodon_v1",
+			out: model.VisibilityFollowers,
+		},
+		"fediverse source can not become more visible": {
+			typ: "foo",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyVisibility: {Attr: &pb.CloudEventAttributeValue_CeString{CeString: "public"}},
+			},
+			out: model.VisibilityFollowers,
+		},
+		"undiscoverable": {
+			typ: "com_awakari_feeds_v1",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyVisibility:   {Attr: &pb.CloudEventAttributeValue_CeString{CeString: "public"}},
+				CeKeyDiscoverable: {Attr: &pb.CloudEventAttributeValue_CeBoolean{CeBoolean: false}},
+			},
+			out: model.VisibilityUnlisted,
+		},
+		"direct": {
+			typ: "com_awakari_feeds_v1",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyVisibility: {Attr: &pb.CloudEventAttributeValue_CeString{CeString: "direct"}},
+			},
+			out: model.VisibilityDirect,
+		},
+		"unknown visibility": {
+			typ: "com_awakari_feeds_v1",
+			attrs: map[string]*pb.CloudEventAttributeValue{
+				CeKeyVisibility: {Attr: &pb.CloudEventAttributeValue_CeString{CeString: "whatever"}},
+			},
+			out: model.VisibilityPublic,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			evt := &pb.CloudEvent{
+				Type:       c.typ,
+				Attributes: c.attrs,
+			}
+			assert.Equal(t, c.out, svc.outboundVisibility(evt))
+		})
+	}
+}
+
+func TestService_ConvertActivityToEvent_Dropped(t *testing.T) {
+	svc := NewService(Options{
+		CeType:    "foo",
+		UrlBase:   "urlBase",
+		ActorType: vocab.ServiceType,
+		Policy:    policyTest,
+		Rewrites:  model.UrlRewritesBuiltin,
+	}, nil)
+	cases := map[string]struct {
+		in  string
+		err string
+	}{
+		"followers": {
+			in:  `{"type":"Create","to":["https://host.social/users/john/followers"],"object":{"type":"Note","content":"hello"}}`,
+			err: "dropped by the visibility policy: followers visibility",
+		},
+		"direct": {
+			in:  `{"type":"Create","to":["https://host.social/users/jane"],"object":{"type":"Note","content":"hello"}}`,
+			err: "dropped by the visibility policy: direct visibility",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := util.DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			actor := vocab.Actor{
+				ID:        "https://host.social/users/john",
+				Followers: vocab.IRI("https://host.social/users/john/followers"),
+			}
+			evt, err := svc.ConvertActivityToEvent(context.TODO(), actor, env)
+			assert.Nil(t, evt)
+			assert.ErrorIs(t, err, ErrDropped)
+			assert.EqualError(t, err, c.err)
+		})
+	}
+}
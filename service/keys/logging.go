@@ -0,0 +1,70 @@
+package keys
+
+import (
+	"context"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	"log/slog"
+	"time"
+)
+
+type logging struct {
+	svc Service
+	log *slog.Logger
+}
+
+func NewLogging(svc Service, log *slog.Logger) Service {
+	return logging{
+		svc: svc,
+		log: log,
+	}
+}
+
+func (l logging) Public(ctx context.Context, id string) (pub string, err error) {
+	pub, err = l.svc.Public(ctx, id)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("keys.Public(id=%s): %d, %s", id, len(pub), err))
+	return
+}
+
+func (l logging) Signing(ctx context.Context, id string) (k model.Key, err error) {
+	k, err = l.svc.Signing(ctx, id)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("keys.Signing(id=%s): %s, %s", id, k.Id, err))
+	return
+}
+
+func (l logging) Announce(ctx context.Context, id, followerId string) (first bool, err error) {
+	first, err = l.svc.Announce(ctx, id, followerId)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("keys.Announce(id=%s, followerId=%s): %t, %s", id, followerId, first, err))
+	return
+}
+
+func (l logging) AnnounceDone(ctx context.Context, id string) (err error) {
+	err = l.svc.AnnounceDone(ctx, id)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("keys.AnnounceDone(id=%s): %s", id, err))
+	return
+}
+
+func (l logging) Instance(ctx context.Context) (ks []model.Key, err error) {
+	ks, err = l.svc.Instance(ctx)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("keys.Instance(): %d, %s", len(ks), err))
+	return
+}
+
+func (l logging) Seed(ctx context.Context, pub, priv string) (err error) {
+	err = l.svc.Seed(ctx, pub, priv)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("keys.Seed(pub=%d, priv=%d): %s", len(pub), len(priv), err))
+	return
+}
+
+func (l logging) Rotate(ctx context.Context, grace time.Duration) (k model.Key, err error) {
+	k, err = l.svc.Rotate(ctx, grace)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("keys.Rotate(grace=%s): %s, %s", grace, k.Id, err))
+	return
+}
+
+func (l logging) Retire(ctx context.Context, id string) (err error) {
+	err = l.svc.Retire(ctx, id)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("keys.Retire(id=%s): %s", id, err))
+	return
+}
@@ -0,0 +1,267 @@
+package keys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/storage/keys"
+	"strings"
+	"time"
+)
+
+type Service interface {
+
+	// Public returns the PEM encoded public key by the key id. Generates a new key pair if missing.
+	Public(ctx context.Context, id string) (pub string, err error)
+
+	// Signing returns the key pair to sign the outgoing requests with on behalf of the key id. Generates a new key
+	// pair if missing. Any instance key id resolves to the instance key which is currently active, so the resulting
+	// key id may differ from the requested one.
+	Signing(ctx context.Context, id string) (k model.Key, err error)
+
+	// Announce returns true when the key should be announced to the follower, i.e. it's not been announced before.
+	Announce(ctx context.Context, id, followerId string) (first bool, err error)
+
+	// AnnounceDone marks the key as announced to all the followers, so it's not announced anymore.
+	AnnounceDone(ctx context.Context, id string) (err error)
+
+	// Instance returns the published instance keys. The one which is currently used for signing comes first.
+	Instance(ctx context.Context) (ks []model.Key, err error)
+
+	// Seed persists the initial instance key when there is no any instance key yet.
+	// Generates a new key pair when the PEM encoded keys are not specified.
+	Seed(ctx context.Context, pub, priv string) (err error)
+
+	// Rotate generates the new instance key which replaces the current one for signing after the grace period.
+	// All previous instance keys are marked as deprecated but remain published until retired.
+	// The grace period should be long enough for every replica to see the new key before it's activated.
+	Rotate(ctx context.Context, grace time.Duration) (k model.Key, err error)
+
+	// Retire removes the deprecated instance key which is not used for signing anymore.
+	Retire(ctx context.Context, id string) (err error)
+}
+
+type service struct {
+	stor           keys.Storage
+	instanceId     string
+	instancePrefix string
+}
+
+const keyLenBits = 2048
+
+var ErrGenerate = errors.New("failed to generate a key pair")
+var ErrInvalid = errors.New("invalid key")
+
+// NewService returns the key service. The instance keys are those having the id starting with the instance actor id.
+func NewService(stor keys.Storage, actorId string) Service {
+	return service{
+		stor:           stor,
+		instanceId:     actorId + "#main-key",
+		instancePrefix: actorId + "#",
+	}
+}
+
+func (svc service) Public(ctx context.Context, id string) (pub string, err error) {
+	var k model.Key
+	switch svc.isInstance(id) {
+	case true:
+		k, err = svc.stor.Read(ctx, id)
+	default:
+		k, err = svc.resolve(ctx, id)
+	}
+	if err == nil {
+		pub = k.Public
+	}
+	return
+}
+
+func (svc service) Signing(ctx context.Context, id string) (k model.Key, err error) {
+	switch svc.isInstance(id) {
+	case true:
+		var ks []model.Key
+		ks, err = svc.Instance(ctx)
+		switch {
+		case err != nil:
+		case len(ks) == 0:
+			err = fmt.Errorf("%w: no instance key", keys.ErrNotFound)
+		default:
+			k = ks[0]
+		}
+	default:
+		k, err = svc.resolve(ctx, id)
+	}
+	return
+}
+
+func (svc service) Announce(ctx context.Context, id, followerId string) (first bool, err error) {
+	if !svc.isInstance(id) {
+		first, err = svc.stor.Announce(ctx, id, followerId)
+	}
+	return
+}
+
+func (svc service) AnnounceDone(ctx context.Context, id string) (err error) {
+	if svc.isInstance(id) {
+		return
+	}
+	var k model.Key
+	k, err = svc.stor.Read(ctx, id)
+	if err == nil && k.Announced.IsZero() {
+		k.Announced = time.Now().UTC()
+		err = svc.stor.Update(ctx, k)
+	}
+	return
+}
+
+func (svc service) Instance(ctx context.Context) (ks []model.Key, err error) {
+	var all []model.Key
+	all, err = svc.stor.List(ctx, svc.instancePrefix)
+	if err == nil && len(all) > 0 {
+		now := time.Now().UTC()
+		active := -1
+		for i, k := range all {
+			if !k.Activated.After(now) && (active < 0 || !k.Activated.Before(all[active].Activated)) {
+				active = i
+			}
+		}
+		if active < 0 {
+			// none is activated yet, fall back to the oldest one
+			active = 0
+		}
+		ks = append(ks, all[active])
+		for i := len(all) - 1; i >= 0; i-- {
+			if i != active {
+				ks = append(ks, all[i])
+			}
+		}
+	}
+	return
+}
+
+func (svc service) Seed(ctx context.Context, pub, priv string) (err error) {
+	var ks []model.Key
+	ks, err = svc.stor.List(ctx, svc.instancePrefix)
+	if err == nil && len(ks) == 0 {
+		var k model.Key
+		switch {
+		case pub != "" && priv != "":
+			k.Id = svc.instanceId
+			k.Public = pub
+			k.Private = priv
+			k.Created = time.Now().UTC()
+		default:
+			k, err = generate(svc.instanceId)
+		}
+		if err == nil {
+			k.Activated = k.Created
+			err = svc.stor.Create(ctx, k)
+		}
+		if errors.Is(err, keys.ErrConflict) {
+			// seeded concurrently by another instance
+			err = nil
+		}
+	}
+	return
+}
+
+func (svc service) Rotate(ctx context.Context, grace time.Duration) (k model.Key, err error) {
+	if grace < keys.CacheListsTtlMax {
+		err = fmt.Errorf("%w: grace period %s is shorter than %s", ErrInvalid, grace, keys.CacheListsTtlMax)
+		return
+	}
+	var ks []model.Key
+	ks, err = svc.stor.List(ctx, svc.instancePrefix)
+	if err == nil {
+		k, err = generate(fmt.Sprintf("%skey-%d", svc.instancePrefix, time.Now().UnixMilli()))
+	}
+	if err == nil {
+		k.Activated = k.Created.Add(grace)
+		err = svc.stor.Create(ctx, k)
+	}
+	if err == nil {
+		for _, prev := range ks {
+			if prev.Deprecated.IsZero() {
+				prev.Deprecated = k.Created
+				err = errors.Join(err, svc.stor.Update(ctx, prev))
+			}
+		}
+	}
+	return
+}
+
+func (svc service) Retire(ctx context.Context, id string) (err error) {
+	if !svc.isInstance(id) {
+		err = fmt.Errorf("%w: not an instance key: %s", ErrInvalid, id)
+	}
+	var k model.Key
+	if err == nil {
+		k, err = svc.stor.Read(ctx, id)
+	}
+	if err == nil && k.Deprecated.IsZero() {
+		err = fmt.Errorf("%w: not deprecated: %s", ErrInvalid, id)
+	}
+	var active model.Key
+	if err == nil {
+		active, err = svc.Signing(ctx, id)
+	}
+	if err == nil && active.Id == id {
+		err = fmt.Errorf("%w: still used for signing: %s", ErrInvalid, id)
+	}
+	if err == nil {
+		err = svc.stor.Delete(ctx, id)
+	}
+	return
+}
+
+func (svc service) isInstance(id string) bool {
+	return strings.HasPrefix(id, svc.instancePrefix)
+}
+
+func (svc service) resolve(ctx context.Context, id string) (k model.Key, err error) {
+	k, err = svc.stor.Read(ctx, id)
+	if errors.Is(err, keys.ErrNotFound) {
+		k, err = generate(id)
+		if err == nil {
+			err = svc.stor.Create(ctx, k)
+		}
+		if errors.Is(err, keys.ErrConflict) {
+			// created concurrently, use the persisted one
+			k, err = svc.stor.Read(ctx, id)
+		}
+	}
+	return
+}
+
+func generate(id string) (k model.Key, err error) {
+	var priv *rsa.PrivateKey
+	priv, err = rsa.GenerateKey(rand.Reader, keyLenBits)
+	var privDer []byte
+	if err == nil {
+		privDer, err = x509.MarshalPKCS8PrivateKey(priv)
+	}
+	var pubDer []byte
+	if err == nil {
+		pubDer, err = x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	}
+	switch err {
+	case nil:
+		k.Id = id
+		k.Private = string(pem.EncodeToMemory(&pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: privDer,
+		}))
+		k.Public = string(pem.EncodeToMemory(&pem.Block{
+			Type:  "PUBLIC KEY",
+			Bytes: pubDer,
+		}))
+		k.Created = time.Now().UTC()
+	default:
+		err = fmt.Errorf("%w %s: %s", ErrGenerate, id, err)
+	}
+	return
+}
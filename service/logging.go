@@ -7,6 +7,7 @@ import (
 	"github.com/awakari/int-activitypub/util"
 	vocab "github.com/go-ap/activitypub"
 	"log/slog"
+	"time"
 )
 
 type logging struct {
@@ -32,14 +33,12 @@ func (l logging) HandleActivity(
 	actorIdLocal, pubKeyId string,
 	actor vocab.Actor,
 	actorTags util.ObjectTags,
-	activity vocab.Activity,
-	activityTags util.ActivityTags,
-	cm util.ActivityContentMap,
+	env util.Envelope,
 ) (post func(), err error) {
-	post, err = l.svc.HandleActivity(ctx, actorIdLocal, pubKeyId, actor, actorTags, activity, activityTags, cm)
+	post, err = l.svc.HandleActivity(ctx, actorIdLocal, pubKeyId, actor, actorTags, env)
 	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf(
 		"service.HandleActivity(actorIdLocal=%s, actor.Id=%s, actor.Tags=%d, activity.Type=%s, activity.Tags=%d): err=%s",
-		actorIdLocal, actor.ID, len(actorTags.Tag), activity.Type, len(activityTags.Tag), err,
+		actorIdLocal, actor.ID, len(actorTags.Tag), env.Activity.Type, len(env.Tags)+len(env.Object.Tags), err,
 	))
 	return
 }
@@ -61,3 +60,63 @@ func (l logging) Unfollow(ctx context.Context, url vocab.IRI, groupId, userId st
 	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.Unfollow(url=%s, groupId=%s, userId=%s): %s", url, groupId, userId, err))
 	return
 }
+
+func (l logging) DeleteInterestActor(ctx context.Context, interestId string, follower vocab.Actor) (err error) {
+	err = l.svc.DeleteInterestActor(ctx, interestId, follower)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.DeleteInterestActor(interestId=%s, follower.Id=%s): %s", interestId, follower.ID, err))
+	return
+}
+
+func (l logging) DeleteInterest(ctx context.Context, interestId string) (notified uint32, err error) {
+	notified, err = l.svc.DeleteInterest(ctx, interestId)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.DeleteInterest(interestId=%s): %d, %s", interestId, notified, err))
+	return
+}
+
+func (l logging) AnnounceKeys(ctx context.Context) (announced uint32, err error) {
+	announced, err = l.svc.AnnounceKeys(ctx)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.AnnounceKeys(): %d, %s", announced, err))
+	return
+}
+
+func (l logging) SweepInterests(ctx context.Context) (swept uint32, err error) {
+	swept, err = l.svc.SweepInterests(ctx)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.SweepInterests(): %d, %s", swept, err))
+	return
+}
+
+func (l logging) RotateKey(ctx context.Context, grace time.Duration) (k model.Key, err error) {
+	k, err = l.svc.RotateKey(ctx, grace)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.RotateKey(grace=%s): %s, %s", grace, k.Id, err))
+	return
+}
+
+func (l logging) RetireKey(ctx context.Context, id string) (err error) {
+	err = l.svc.RetireKey(ctx, id)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.RetireKey(id=%s): %s", id, err))
+	return
+}
+
+func (l logging) ListKeys(ctx context.Context) (ks []model.Key, err error) {
+	ks, err = l.svc.ListKeys(ctx)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.ListKeys(): %d, %s", len(ks), err))
+	return
+}
+
+func (l logging) ListAudit(ctx context.Context, filter model.AuditFilter, limit uint32, cursor string, order model.Order) (page []model.AuditEvent, err error) {
+	page, err = l.svc.ListAudit(ctx, filter, limit, cursor, order)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.ListAudit(filter=%+v, limit=%d, cursor=%s, order=%s): %d, %s", filter, limit, cursor, order, len(page), err))
+	return
+}
+
+func (l logging) ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error) {
+	page, err = l.svc.ListReports(ctx, filter, limit, cursor)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.ListReports(filter=%+v, limit=%d, cursor=%s): %d, %s", filter, limit, cursor, len(page), err))
+	return
+}
+
+func (l logging) ResolveReport(ctx context.Context, id string, action model.ReportAction, noteIds []string) (deleted uint32, err error) {
+	deleted, err = l.svc.ResolveReport(ctx, id, action, noteIds)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("service.ResolveReport(id=%s, action=%s, noteIds=%d): %d, %s", id, action, len(noteIds), deleted, err))
+	return
+}
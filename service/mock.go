@@ -4,9 +4,15 @@ import (
 	"context"
 	"github.com/awakari/int-activitypub/model"
 	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/service/moderation"
 	"github.com/awakari/int-activitypub/storage"
+	"github.com/awakari/int-activitypub/storage/audit"
+	"github.com/awakari/int-activitypub/storage/followers"
+	"github.com/awakari/int-activitypub/storage/keys"
+	storageModeration "github.com/awakari/int-activitypub/storage/moderation"
 	"github.com/awakari/int-activitypub/util"
 	vocab "github.com/go-ap/activitypub"
+	"time"
 )
 
 type mock struct {
@@ -26,13 +32,15 @@ func (m mock) RequestFollow(ctx context.Context, addr, groupId, userId, subId, t
 		err = storage.ErrConflict
 	case "fail":
 		err = storage.ErrInternal
+	case "blocked":
+		err = ErrBlocked
 	default:
 		url = addr
 	}
 	return
 }
 
-func (m mock) HandleActivity(ctx context.Context, actorIdLocal, pubKeyId string, actor vocab.Actor, actorTags util.ObjectTags, activity vocab.Activity, tags util.ActivityTags, cm util.ActivityContentMap) (post func(), err error) {
+func (m mock) HandleActivity(ctx context.Context, actorIdLocal, pubKeyId string, actor vocab.Actor, actorTags util.ObjectTags, env util.Envelope) (post func(), err error) {
 	switch actor.ID {
 	case "fail":
 		err = storage.ErrInternal
@@ -85,3 +93,124 @@ func (m mock) Unfollow(ctx context.Context, url vocab.IRI, groupId, userId strin
 	}
 	return
 }
+
+func (m mock) DeleteInterestActor(ctx context.Context, interestId string, follower vocab.Actor) (err error) {
+	switch interestId {
+	case "activitypub_fail":
+		err = activitypub.ErrActivitySend
+	}
+	return
+}
+
+func (m mock) DeleteInterest(ctx context.Context, interestId string) (notified uint32, err error) {
+	switch interestId {
+	case "fail":
+		err = followers.ErrInternal
+	default:
+		notified = 1
+	}
+	return
+}
+
+func (m mock) SweepInterests(ctx context.Context) (swept uint32, err error) {
+	return
+}
+
+func (m mock) AnnounceKeys(ctx context.Context) (announced uint32, err error) {
+	return
+}
+
+func (m mock) RotateKey(ctx context.Context, grace time.Duration) (k model.Key, err error) {
+	switch {
+	case grace < 0:
+		err = ErrInvalid
+	default:
+		k.Id = "https://test.social/actor#key-next"
+		k.Public = "-----BEGIN PUBLIC KEY-----\nnext\n-----END PUBLIC KEY-----\n"
+		k.Created = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		k.Activated = k.Created.Add(grace)
+	}
+	return
+}
+
+func (m mock) RetireKey(ctx context.Context, id string) (err error) {
+	switch id {
+	case "fail":
+		err = keys.ErrInternal
+	case "missing":
+		err = keys.ErrNotFound
+	case "https://test.social/actor#main-key":
+		err = ErrInvalid
+	}
+	return
+}
+
+func (m mock) ListKeys(ctx context.Context) (ks []model.Key, err error) {
+	ks = []model.Key{
+		{
+			Id:        "https://test.social/actor#main-key",
+			Public:    "-----BEGIN PUBLIC KEY-----\nmain\n-----END PUBLIC KEY-----\n",
+			Created:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Activated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	return
+}
+
+func (m mock) ListAudit(ctx context.Context, filter model.AuditFilter, limit uint32, cursor string, order model.Order) (page []model.AuditEvent, err error) {
+	switch filter.ActorId {
+	case "fail":
+		err = audit.ErrInternal
+	default:
+		page = []model.AuditEvent{
+			{
+				Id:      "2jVY3OXbYgEqHxfA3yDmS1qzNPd",
+				Time:    time.Date(2024, 11, 12, 13, 14, 15, 0, time.UTC),
+				Kind:    model.AuditKindDrop,
+				ActorId: filter.ActorId,
+				GroupId: "group0",
+				UserId:  "user1",
+				Reason:  model.AuditReasonPrivate + ": dropped by the visibility policy: followers visibility",
+			},
+		}
+	}
+	return
+}
+
+func (m mock) ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error) {
+	switch filter.InterestId {
+	case "fail":
+		err = storageModeration.ErrInternal
+	default:
+		page = []model.Report{
+			{
+				Id:         "2jVY3OXbYgEqHxfA3yDmS1qzNPd",
+				ActivityId: "https://host.social/flags/1",
+				ReporterId: "https://host.social/actor",
+				InterestId: filter.InterestId,
+				Objects: []string{
+					"https://test.social/note1",
+				},
+				Content: "spam",
+				Created: time.Date(2024, 11, 12, 13, 14, 15, 0, time.UTC),
+			},
+		}
+	}
+	return
+}
+
+func (m mock) ResolveReport(ctx context.Context, id string, action model.ReportAction, noteIds []string) (deleted uint32, err error) {
+	switch id {
+	case "fail":
+		err = storageModeration.ErrInternal
+	case "missing":
+		err = storageModeration.ErrNotFound
+	case "invalid":
+		err = moderation.ErrInvalid
+	default:
+		if action == model.ReportActionDeleteNotes {
+			deleted = 2
+		}
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/util"
+	vocab "github.com/go-ap/activitypub"
+	"log/slog"
+)
+
+type logging struct {
+	svc Service
+	log *slog.Logger
+}
+
+func NewLogging(svc Service, log *slog.Logger) Service {
+	return logging{
+		svc: svc,
+		log: log,
+	}
+}
+
+func (l logging) Report(ctx context.Context, reporterId string, activity vocab.Activity) (r model.Report, err error) {
+	r, err = l.svc.Report(ctx, reporterId, activity)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("moderation.Report(reporterId=%s, activity.Id=%s): %s, %s", reporterId, activity.ID, r.Id, err))
+	return
+}
+
+func (l logging) ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error) {
+	page, err = l.svc.ListReports(ctx, filter, limit, cursor)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("moderation.ListReports(filter=%+v, limit=%d, cursor=%s): %d, %s", filter, limit, cursor, len(page), err))
+	return
+}
+
+func (l logging) Resolve(ctx context.Context, id string, action model.ReportAction, noteIds []string) (deleted uint32, err error) {
+	deleted, err = l.svc.Resolve(ctx, id, action, noteIds)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("moderation.Resolve(id=%s, action=%s, noteIds=%d): %d, %s", id, action, len(noteIds), deleted, err))
+	return
+}
+
+func (l logging) Suspended(ctx context.Context, interestId string) (suspended bool, err error) {
+	suspended, err = l.svc.Suspended(ctx, interestId)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("moderation.Suspended(interestId=%s): %t, %s", interestId, suspended, err))
+	return
+}
+
+func (l logging) RecordDeliveries(ctx context.Context, ds []model.Delivery) (err error) {
+	err = l.svc.RecordDeliveries(ctx, ds)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("moderation.RecordDeliveries(count=%d): %s", len(ds), err))
+	return
+}
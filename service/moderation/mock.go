@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/storage/moderation"
+	vocab "github.com/go-ap/activitypub"
+)
+
+type mock struct {
+}
+
+func NewServiceMock() Service {
+	return mock{}
+}
+
+func (m mock) Report(ctx context.Context, reporterId string, activity vocab.Activity) (r model.Report, err error) {
+	switch activity.ID {
+	case "":
+		err = ErrInvalid
+	case "https://fail.social/flags/1":
+		err = moderation.ErrInternal
+	default:
+		r = model.Report{
+			Id:         "report0",
+			ActivityId: activity.ID.String(),
+			ReporterId: reporterId,
+			Objects:    flagObjects(activity.Object),
+		}
+	}
+	return
+}
+
+func (m mock) ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error) {
+	switch filter.InterestId {
+	case "fail":
+		err = moderation.ErrInternal
+	case "interest0":
+		page = []model.Report{
+			{
+				Id:         "report0",
+				ActivityId: "https://host.social/flags/1",
+				ReporterId: "https://host.social/actor",
+				InterestId: "interest0",
+				Objects: []string{
+					"https://test.social/note1",
+				},
+				Content: "spam",
+			},
+		}
+	}
+	return
+}
+
+func (m mock) Resolve(ctx context.Context, id string, action model.ReportAction, noteIds []string) (deleted uint32, err error) {
+	switch id {
+	case "fail":
+		err = moderation.ErrInternal
+	case "missing":
+		err = moderation.ErrNotFound
+	case "invalid":
+		err = ErrInvalid
+	default:
+		if action == model.ReportActionDeleteNotes {
+			deleted = uint32(len(noteIds))
+		}
+	}
+	return
+}
+
+func (m mock) Suspended(ctx context.Context, interestId string) (suspended bool, err error) {
+	switch interestId {
+	case "fail":
+		err = moderation.ErrInternal
+	case "suspended":
+		suspended = true
+	}
+	return
+}
+
+func (m mock) RecordDeliveries(ctx context.Context, ds []model.Delivery) (err error) {
+	for _, d := range ds {
+		switch d.NoteId {
+		case "fail":
+			err = moderation.ErrInternal
+		}
+	}
+	return
+}
@@ -0,0 +1,210 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/storage/moderation"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// Service handles the remote moderators' reports about the local interest actors and their notes.
+type Service interface {
+
+	// Report stores the inbound Flag activity sent by the reporter actor.
+	Report(ctx context.Context, reporterId string, activity vocab.Activity) (r model.Report, err error)
+
+	ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error)
+
+	// Resolve takes the action on the report. For the model.ReportActionDeleteNotes, the noteIds are the notes to
+	// delete, defaults to all reported objects when empty. The boosts are undone instead of the deletion.
+	// Every delivery is retracted once, so the retry after a partial failure sends only the remaining ones.
+	// Returns the count of the Delete and Undo activities sent.
+	Resolve(ctx context.Context, id string, action model.ReportAction, noteIds []string) (deleted uint32, err error)
+
+	// Suspended returns true when the interest actor's federation is suspended.
+	Suspended(ctx context.Context, interestId string) (suspended bool, err error)
+
+	// RecordDeliveries remembers the inboxes the notes were delivered to, so the notes may be deleted there later.
+	// The delivery's Announced is the boosted original object IRI when the delivered activity is Announce.
+	RecordDeliveries(ctx context.Context, ds []model.Delivery) (err error)
+}
+
+type service struct {
+	stor           moderation.Storage
+	ap             activitypub.Service
+	actorUrlPrefix string
+}
+
+var ErrInvalid = errors.New("invalid moderation request")
+
+func NewService(stor moderation.Storage, ap activitypub.Service, hostSelf string) Service {
+	return service{
+		stor:           stor,
+		ap:             ap,
+		actorUrlPrefix: fmt.Sprintf("https://%s/actor/", hostSelf),
+	}
+}
+
+func (svc service) Report(ctx context.Context, reporterId string, activity vocab.Activity) (r model.Report, err error) {
+	r.ActivityId = activity.ID.String()
+	r.ReporterId = reporterId
+	r.Objects = flagObjects(activity.Object)
+	r.Created = time.Now().UTC()
+	if len(activity.Content) > 0 {
+		r.Content = activity.Content.First().Value.String()
+	}
+	switch {
+	case r.ActivityId == "":
+		err = fmt.Errorf("%w: missing flag activity id", ErrInvalid)
+	case len(r.Objects) == 0:
+		err = fmt.Errorf("%w: no objects flagged", ErrInvalid)
+	}
+	if err == nil {
+		r.InterestId, err = svc.resolveInterestId(ctx, r.Objects)
+	}
+	if err == nil {
+		r.Id, err = svc.stor.CreateReport(ctx, r)
+	}
+	return
+}
+
+func flagObjects(o vocab.Item) (objs []string) {
+	switch t := o.(type) {
+	case nil:
+	case vocab.ItemCollection:
+		for _, item := range t {
+			objs = append(objs, flagObjects(item)...)
+		}
+	case *vocab.ItemCollection:
+		objs = flagObjects(*t)
+	default:
+		if l := o.GetLink(); l != "" {
+			objs = append(objs, l.String())
+		}
+	}
+	return
+}
+
+func (svc service) resolveInterestId(ctx context.Context, objs []string) (interestId string, err error) {
+	for _, o := range objs {
+		if strings.HasPrefix(o, svc.actorUrlPrefix) {
+			interestId = strings.TrimPrefix(o, svc.actorUrlPrefix)
+			return
+		}
+	}
+	for _, o := range objs {
+		var ds []model.Delivery
+		ds, err = svc.stor.ListDeliveries(ctx, o)
+		if err != nil || len(ds) > 0 {
+			if len(ds) > 0 {
+				interestId = ds[0].InterestId
+			}
+			break
+		}
+	}
+	return
+}
+
+func (svc service) ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error) {
+	page, err = svc.stor.ListReports(ctx, filter, limit, cursor)
+	return
+}
+
+func (svc service) Resolve(ctx context.Context, id string, action model.ReportAction, noteIds []string) (deleted uint32, err error) {
+	var r model.Report
+	r, err = svc.stor.ReadReport(ctx, id)
+	if err == nil {
+		switch action {
+		case model.ReportActionDismiss:
+		case model.ReportActionSuspend, model.ReportActionReinstate:
+			switch r.InterestId {
+			case "":
+				err = fmt.Errorf("%w: reported interest is unknown", ErrInvalid)
+			default:
+				err = svc.stor.SetSuspended(ctx, r.InterestId, action == model.ReportActionSuspend)
+			}
+		case model.ReportActionDeleteNotes:
+			if len(noteIds) == 0 {
+				noteIds = r.Objects
+			}
+			deleted, err = svc.deleteNotes(ctx, noteIds)
+		default:
+			err = fmt.Errorf("%w: unknown action %s", ErrInvalid, action)
+		}
+	}
+	if err == nil {
+		err = svc.stor.ResolveReport(ctx, id, action, time.Now().UTC())
+	}
+	return
+}
+
+func (svc service) deleteNotes(ctx context.Context, noteIds []string) (deleted uint32, err error) {
+	for _, noteId := range noteIds {
+		var ds []model.Delivery
+		ds, err = svc.stor.ListDeliveries(ctx, noteId)
+		if err != nil {
+			break
+		}
+		// the noteId may be the boosted original object, then the deliveries are the Announces of it
+		sent := map[string]bool{}
+		for _, d := range ds {
+			if sent[d.NoteId+" "+d.Inbox] || !d.Retracted.IsZero() {
+				continue
+			}
+			sent[d.NoteId+" "+d.Inbox] = true
+			actorId := vocab.IRI(svc.actorUrlPrefix + d.InterestId)
+			activity := vocab.Activity{
+				Type:   vocab.DeleteType,
+				ID:     vocab.ID(fmt.Sprintf("%s#delete-%s", d.NoteId, uuid.NewString())),
+				Actor:  actorId,
+				Object: vocab.IRI(d.NoteId),
+				To: vocab.ItemCollection{
+					vocab.PublicNS,
+				},
+			}
+			if d.Announced != "" {
+				// the original object is not ours, undo the boost only
+				activity.Type = vocab.UndoType
+				activity.ID = vocab.ID(fmt.Sprintf("%s#undo-%s", d.NoteId, uuid.NewString()))
+				activity.Object = &vocab.Activity{
+					Type:   vocab.AnnounceType,
+					ID:     vocab.ID(d.NoteId),
+					Actor:  actorId,
+					Object: vocab.IRI(d.Announced),
+				}
+			}
+			errSend := svc.ap.SendActivity(ctx, activity, vocab.IRI(d.Inbox), string(actorId)+"#main-key")
+			switch errSend {
+			case nil:
+				deleted++
+				// remember the progress, so the retry doesn't send it again
+				err = errors.Join(err, svc.stor.SetDeliveryRetracted(ctx, d.NoteId, d.Inbox, time.Now().UTC()))
+			default:
+				err = errors.Join(err, errSend)
+			}
+		}
+	}
+	return
+}
+
+func (svc service) Suspended(ctx context.Context, interestId string) (suspended bool, err error) {
+	suspended, err = svc.stor.Suspended(ctx, interestId)
+	return
+}
+
+func (svc service) RecordDeliveries(ctx context.Context, ds []model.Delivery) (err error) {
+	now := time.Now().UTC()
+	for i := range ds {
+		if ds[i].Created.IsZero() {
+			ds[i].Created = now
+		}
+	}
+	err = svc.stor.AddDeliveries(ctx, ds)
+	return
+}
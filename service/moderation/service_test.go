@@ -0,0 +1,266 @@
+package moderation
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/storage/moderation"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+func TestService_Report(t *testing.T) {
+	svc := NewService(moderation.NewStorageMock(), activitypub.NewServiceMock(), "test.social")
+	svc = NewLogging(svc, slog.Default())
+	cases := map[string]struct {
+		activity vocab.Activity
+		out      model.Report
+		err      error
+	}{
+		"interest actor": {
+			activity: vocab.Activity{
+				ID:      "https://host.social/flags/1",
+				Type:    vocab.FlagType,
+				Content: vocab.DefaultNaturalLanguageValue("spam"),
+				Object: vocab.ItemCollection{
+					vocab.IRI("https://test.social/actor/interest0"),
+					vocab.IRI("https://test.social/note1"),
+				},
+			},
+			out: model.Report{
+				Id:         "report0",
+				ActivityId: "https://host.social/flags/1",
+				ReporterId: "https://host.social/actor",
+				InterestId: "interest0",
+				Objects: []string{
+					"https://test.social/actor/interest0",
+					"https://test.social/note1",
+				},
+				Content: "spam",
+			},
+		},
+		"delivered note": {
+			activity: vocab.Activity{
+				ID:     "https://host.social/flags/2",
+				Type:   vocab.FlagType,
+				Object: vocab.IRI("https://test.social/note2"),
+			},
+			out: model.Report{
+				Id:         "report0",
+				ActivityId: "https://host.social/flags/2",
+				ReporterId: "https://host.social/actor",
+				InterestId: "interest0",
+				Objects: []string{
+					"https://test.social/note2",
+				},
+			},
+		},
+		"boosted original": {
+			activity: vocab.Activity{
+				ID:     "https://host.social/flags/4",
+				Type:   vocab.FlagType,
+				Object: vocab.IRI("https://origin.social/users/jane/statuses/1"),
+			},
+			out: model.Report{
+				Id:         "report0",
+				ActivityId: "https://host.social/flags/4",
+				ReporterId: "https://host.social/actor",
+				InterestId: "interest0",
+				Objects: []string{
+					"https://origin.social/users/jane/statuses/1",
+				},
+			},
+		},
+		"unknown note": {
+			activity: vocab.Activity{
+				ID:     "https://host.social/flags/3",
+				Type:   vocab.FlagType,
+				Object: vocab.IRI("https://test.social/note_unknown"),
+			},
+			out: model.Report{
+				Id:         "report0",
+				ActivityId: "https://host.social/flags/3",
+				ReporterId: "https://host.social/actor",
+				Objects: []string{
+					"https://test.social/note_unknown",
+				},
+			},
+		},
+		"missing id": {
+			activity: vocab.Activity{
+				Type:   vocab.FlagType,
+				Object: vocab.IRI("https://test.social/note1"),
+			},
+			err: ErrInvalid,
+		},
+		"no objects": {
+			activity: vocab.Activity{
+				ID:   "https://host.social/flags/4",
+				Type: vocab.FlagType,
+			},
+			err: ErrInvalid,
+		},
+		"conflict": {
+			activity: vocab.Activity{
+				ID:     "https://host.social/flags/conflict",
+				Type:   vocab.FlagType,
+				Object: vocab.IRI("https://test.social/note1"),
+			},
+			err: moderation.ErrConflict,
+		},
+		"fail": {
+			activity: vocab.Activity{
+				ID:     "https://fail.social/flags/1",
+				Type:   vocab.FlagType,
+				Object: vocab.IRI("https://test.social/note1"),
+			},
+			err: moderation.ErrInternal,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			r, err := svc.Report(context.TODO(), "https://host.social/actor", c.activity)
+			assert.ErrorIs(t, err, c.err)
+			if c.err == nil {
+				assert.False(t, r.Created.IsZero())
+				r.Created = c.out.Created
+				assert.Equal(t, c.out, r)
+			}
+		})
+	}
+}
+
+func TestService_Resolve(t *testing.T) {
+	svc := NewService(moderation.NewStorageMock(), activitypub.NewServiceMock(), "test.social")
+	svc = NewLogging(svc, slog.Default())
+	cases := map[string]struct {
+		id      string
+		action  model.ReportAction
+		noteIds []string
+		deleted uint32
+		err     error
+	}{
+		"dismiss": {
+			id:     "report_interest",
+			action: model.ReportActionDismiss,
+		},
+		"suspend": {
+			id:     "report_interest",
+			action: model.ReportActionSuspend,
+		},
+		"reinstate": {
+			id:     "report_interest",
+			action: model.ReportActionReinstate,
+		},
+		"suspend unknown interest": {
+			id:     "report_unknown",
+			action: model.ReportActionSuspend,
+			err:    ErrInvalid,
+		},
+		"delete all reported notes": {
+			id:      "report_notes",
+			action:  model.ReportActionDeleteNotes,
+			deleted: 4,
+		},
+		"delete the specified note": {
+			id:     "report_notes",
+			action: model.ReportActionDeleteNotes,
+			noteIds: []string{
+				"https://test.social/note2",
+			},
+			deleted: 2,
+		},
+		"skip the retracted deliveries": {
+			id:     "report_notes",
+			action: model.ReportActionDeleteNotes,
+			noteIds: []string{
+				"https://test.social/note3",
+			},
+			deleted: 1,
+		},
+		"undo the boost": {
+			id:     "report_notes",
+			action: model.ReportActionDeleteNotes,
+			noteIds: []string{
+				"https://test.social/announce1",
+			},
+			deleted: 1,
+		},
+		"undo the boost of the original": {
+			id:     "report_notes",
+			action: model.ReportActionDeleteNotes,
+			noteIds: []string{
+				"https://origin.social/users/jane/statuses/1",
+			},
+			deleted: 1,
+		},
+		"delete undelivered notes": {
+			id:     "report_unknown",
+			action: model.ReportActionDeleteNotes,
+		},
+		"unknown action": {
+			id:     "report_interest",
+			action: "ban",
+			err:    ErrInvalid,
+		},
+		"missing": {
+			id:     "missing",
+			action: model.ReportActionDismiss,
+			err:    moderation.ErrNotFound,
+		},
+		"fail": {
+			id:     "fail",
+			action: model.ReportActionDismiss,
+			err:    moderation.ErrInternal,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			deleted, err := svc.Resolve(context.TODO(), c.id, c.action, c.noteIds)
+			assert.Equal(t, c.deleted, deleted)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestService_RecordDeliveries(t *testing.T) {
+	svc := NewService(moderation.NewStorageMock(), activitypub.NewServiceMock(), "test.social")
+	svc = NewLogging(svc, slog.Default())
+	cases := map[string]struct {
+		ds  []model.Delivery
+		err error
+	}{
+		"ok": {
+			ds: []model.Delivery{
+				{
+					NoteId:     "https://test.social/note1",
+					InterestId: "interest0",
+					Inbox:      "https://host.social/inbox",
+				},
+				{
+					NoteId:     "https://test.social/announce1",
+					InterestId: "interest0",
+					Inbox:      "https://host.social/inbox",
+					Announced:  "https://origin.social/users/jane/statuses/1",
+				},
+			},
+		},
+		"empty": {},
+		"fail": {
+			ds: []model.Delivery{
+				{
+					NoteId: "fail",
+				},
+			},
+			err: moderation.ErrInternal,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			err := svc.RecordDeliveries(context.TODO(), c.ds)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
@@ -6,24 +6,14 @@ import (
 
 const NoBot = "#nobot"
 
-func ActorHasNoBotTag(o util.ObjectTags) (contains bool) {
-	for _, t := range o.Tag {
+func ActivityHasNoBotTag(env util.Envelope) (contains bool) {
+	for _, t := range env.Tags {
 		if t.Name == NoBot {
 			contains = true
 			break
 		}
 	}
-	return
-}
-
-func ActivityHasNoBotTag(a util.ActivityTags) (contains bool) {
-	for _, t := range a.Tag {
-		if t.Name == NoBot {
-			contains = true
-			break
-		}
-	}
-	for _, t := range a.Object.Tag {
+	for _, t := range env.Object.Tags {
 		if t.Name == NoBot {
 			contains = true
 			break
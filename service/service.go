@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/awakari/int-activitypub/api/http/interests"
 	"github.com/awakari/int-activitypub/api/http/pub"
 	"github.com/awakari/int-activitypub/api/http/subscriptions"
 	"github.com/awakari/int-activitypub/util"
@@ -12,12 +13,21 @@ import (
 	"github.com/google/uuid"
 	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/awakari/int-activitypub/model"
 	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/service/consent"
 	"github.com/awakari/int-activitypub/service/converter"
+	"github.com/awakari/int-activitypub/service/keys"
+	"github.com/awakari/int-activitypub/service/moderation"
 	"github.com/awakari/int-activitypub/storage"
+	"github.com/awakari/int-activitypub/storage/audit"
+	"github.com/awakari/int-activitypub/storage/blocks"
+	"github.com/awakari/int-activitypub/storage/followers"
+	storageModeration "github.com/awakari/int-activitypub/storage/moderation"
 	"github.com/cloudevents/sdk-go/binding/format/protobuf/v2/pb"
 	vocab "github.com/go-ap/activitypub"
 )
@@ -30,9 +40,7 @@ type Service interface {
 		actorIdLocal, pubKeyId string,
 		actor vocab.Actor,
 		actorTags util.ObjectTags,
-		activity vocab.Activity,
-		activityTags util.ActivityTags,
-		contentMap util.ActivityContentMap,
+		env util.Envelope,
 	) (
 		post func(),
 		err error,
@@ -52,6 +60,50 @@ type Service interface {
 	)
 
 	Unfollow(ctx context.Context, url vocab.IRI, groupId, userId string) (err error)
+
+	DeleteInterestActor(ctx context.Context, interestId string, follower vocab.Actor) (err error)
+
+	// DeleteInterest sends the interest actor's Delete to all known followers of the deleted or expired interest and
+	// unsubscribes their callbacks. Returns the count of the followers notified.
+	DeleteInterest(ctx context.Context, interestId string) (notified uint32, err error)
+
+	// SweepInterests deletes the actors of the followed interests that are deleted or expired, see DeleteInterest.
+	// Returns the count of the interests swept.
+	SweepInterests(ctx context.Context) (swept uint32, err error)
+
+	// AnnounceKeys sends the Update of the followed interest actors to their followers once per every interest key,
+	// so the followers don't keep using the instance key previously shared by all the interest actors.
+	// Returns the count of the keys announced to all the followers.
+	AnnounceKeys(ctx context.Context) (announced uint32, err error)
+
+	// RotateKey generates the new instance key to be used for signing after the grace period.
+	// The inboxes of the followed sources are notified about the actor's update in background.
+	RotateKey(ctx context.Context, grace time.Duration) (k model.Key, err error)
+
+	// RetireKey removes the deprecated instance key.
+	// The inboxes of the followed sources are notified about the actor's update in background.
+	RetireKey(ctx context.Context, id string) (err error)
+
+	ListKeys(ctx context.Context) (ks []model.Key, err error)
+
+	// ListAudit returns the recorded follow and publish decisions, see model.AuditKind.
+	ListAudit(
+		ctx context.Context,
+		filter model.AuditFilter,
+		limit uint32,
+		cursor string,
+		order model.Order,
+	) (
+		page []model.AuditEvent,
+		err error,
+	)
+
+	// ListReports returns the Flag reports received from the remote moderators.
+	ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error)
+
+	// ResolveReport takes the moderation action on the report.
+	// Returns the count of the Delete activities sent when the action is model.ReportActionDeleteNotes.
+	ResolveReport(ctx context.Context, id string, action model.ReportAction, noteIds []string) (deleted uint32, err error)
 }
 
 type service struct {
@@ -63,15 +115,26 @@ type service struct {
 	backoffTimeLimit time.Duration
 	svcSubs          subscriptions.Service
 	cbUrlBase        string
+	svcKeys          keys.Service
+	svcConsent       consent.Service
+	storAudit        audit.Storage
+	svcMod           moderation.Service
+	svcInterests     interests.Service
+	storFollowers    followers.Storage
+	storBlocks       blocks.Storage
 }
 
 const lastUpdateThreshold = 1 * time.Hour
 const backoffInitDelay = 100 * time.Millisecond
 const defaultResultsInterval = 1 * time.Minute
+const notifyPageSize = 100
+const notifyConcurrency = 16
 
 var ErrInvalid = errors.New("invalid argument")
 var ErrNoAccept = errors.New("follow request is not accepted yet")
-var ErrNoBot = errors.New(fmt.Sprintf("actor or activity contains the %s tag", NoBot))
+var ErrNoBot = errors.New("actor or activity opts out of the indexing")
+var ErrBlocked = errors.New("source has blocked the instance")
+var ErrSuspended = errors.New("interest actor federation is suspended")
 
 func NewService(
 	stor storage.Storage,
@@ -82,6 +145,13 @@ func NewService(
 	backoffTimeLimit time.Duration,
 	svcSubs subscriptions.Service,
 	cbUrlBase string,
+	svcKeys keys.Service,
+	svcConsent consent.Service,
+	storAudit audit.Storage,
+	svcMod moderation.Service,
+	svcInterests interests.Service,
+	storFollowers followers.Storage,
+	storBlocks blocks.Storage,
 ) Service {
 	return service{
 		stor:             stor,
@@ -92,9 +162,25 @@ func NewService(
 		backoffTimeLimit: backoffTimeLimit,
 		svcSubs:          svcSubs,
 		cbUrlBase:        cbUrlBase,
+		svcKeys:          svcKeys,
+		svcConsent:       svcConsent,
+		storAudit:        storAudit,
+		svcMod:           svcMod,
+		svcInterests:     svcInterests,
+		storFollowers:    storFollowers,
+		storBlocks:       storBlocks,
 	}
 }
 
+// auditKinds are the inbound activity types to record when arrived.
+var auditKinds = map[vocab.ActivityVocabularyType]model.AuditKind{
+	vocab.AcceptType: model.AuditKindAccept,
+	vocab.RejectType: model.AuditKindReject,
+	vocab.UndoType:   model.AuditKindUndo,
+	vocab.DeleteType: model.AuditKindDelete,
+	vocab.FlagType:   model.AuditKindFlag,
+}
+
 func (svc service) RequestFollow(ctx context.Context, addr, groupId, userId, interestId, term string, defaultActor bool) (addrResolved string, err error) {
 
 	var addrParsed *url.URL
@@ -144,8 +230,35 @@ func (svc service) RequestFollow(ctx context.Context, addr, groupId, userId, int
 			err = fmt.Errorf("%w: failed to fetch actor: %s, cause: %s", ErrInvalid, addrResolved, err)
 		}
 	}
-	if err == nil && ActorHasNoBotTag(targetTags) {
-		err = fmt.Errorf("%w: actor %s", ErrNoBot, target.ID)
+	var blocked bool
+	if err == nil {
+		blocked, err = svc.storBlocks.Blocked(ctx, target.ID.String())
+		if err == nil && blocked {
+			err = fmt.Errorf("%w: %s", ErrBlocked, target.ID)
+			svc.audit(ctx, model.AuditEvent{
+				Kind:    model.AuditKindFollowDenied,
+				ActorId: target.ID.String(),
+				GroupId: groupId,
+				UserId:  userId,
+				SubId:   interestId,
+				Reason:  ErrBlocked.Error(),
+			})
+		}
+	}
+	var c model.Consent
+	if err == nil {
+		c, err = svc.svcConsent.Evaluate(ctx, target, targetTags)
+	}
+	if err == nil && !c.Allowed {
+		err = fmt.Errorf("%w: actor %s, %s", ErrNoBot, target.ID, c.Reason)
+		svc.audit(ctx, model.AuditEvent{
+			Kind:    model.AuditKindFollowDenied,
+			ActorId: target.ID.String(),
+			GroupId: groupId,
+			UserId:  userId,
+			SubId:   interestId,
+			Reason:  fmt.Sprintf("%s: %s", model.AuditReasonOptOut, c.Reason),
+		})
 	}
 
 	var src model.Source
@@ -183,6 +296,15 @@ func (svc service) RequestFollow(ctx context.Context, addr, groupId, userId, int
 			src.Err = err.Error()
 			_ = svc.stor.Update(ctx, src)
 		}
+		if err == nil {
+			svc.audit(ctx, model.AuditEvent{
+				Kind:    model.AuditKindFollow,
+				ActorId: addrResolved,
+				GroupId: groupId,
+				UserId:  userId,
+				SubId:   interestId,
+			})
+		}
 	}
 
 	return
@@ -193,21 +315,45 @@ func (svc service) HandleActivity(
 	actorIdLocal, pubKeyId string,
 	actor vocab.Actor,
 	actorTags util.ObjectTags,
-	activity vocab.Activity,
-	activityTags util.ActivityTags,
-	cm util.ActivityContentMap,
+	env util.Envelope,
 ) (
 	post func(),
 	err error,
 ) {
+	activity := env.Activity
 	actorId := actor.ID.String()
+	if kind, ok := auditKinds[activity.Type]; ok {
+		svc.audit(ctx, model.AuditEvent{
+			Kind:       kind,
+			ActorId:    actorId,
+			ActivityId: activity.ID.String(),
+			SubId:      actorIdLocal,
+		})
+	}
+	if activity.Type != vocab.FlagType && ActivityHasNoBotTag(env) {
+		err = fmt.Errorf("%w: activity %s contains the %s tag", ErrNoBot, activity.ID, NoBot)
+		svc.audit(ctx, model.AuditEvent{
+			Kind:       model.AuditKindDrop,
+			ActorId:    actorId,
+			ActivityId: activity.ID.String(),
+			SubId:      actorIdLocal,
+			Reason:     fmt.Sprintf("%s: activity contains the %s tag", model.AuditReasonOptOut, NoBot),
+		})
+		return
+	}
 	switch activity.Type {
 	case vocab.FollowType:
 		post, err = svc.handleFollowActivity(ctx, actorIdLocal, pubKeyId, actorId, activity)
 	case vocab.UndoType:
 		err = svc.handleUndoActivity(ctx, actorIdLocal, actorId, activity)
+	case vocab.FlagType:
+		_, err = svc.svcMod.Report(ctx, actorId, activity)
+		if errors.Is(err, storageModeration.ErrConflict) {
+			// the same Flag delivered again
+			err = nil
+		}
 	default:
-		err = svc.handleSourceActivity(ctx, actorId, pubKeyId, actor, actorTags, activity, activityTags, cm)
+		err = svc.handleSourceActivity(ctx, actorId, pubKeyId, actor, actorTags, env)
 	}
 	return
 }
@@ -215,8 +361,19 @@ func (svc service) HandleActivity(
 func (svc service) handleFollowActivity(ctx context.Context, actorIdLocal, pubKeyId, actorId string, activity vocab.Activity) (post func(), err error) {
 	d, _ := sonic.Marshal(activity)
 	fmt.Printf("Follow activity payload: %s\n", d)
+	var suspended bool
+	suspended, err = svc.svcMod.Suspended(ctx, actorIdLocal)
+	if err == nil && suspended {
+		err = fmt.Errorf("%w: %s", ErrSuspended, actorIdLocal)
+	}
 	cbUrl := svc.makeCallbackUrl(actorId)
-	err = svc.svcSubs.Subscribe(ctx, actorIdLocal, model.GroupIdDefault, model.UserIdDefault, cbUrl, defaultResultsInterval)
+	if err == nil {
+		err = svc.svcSubs.Subscribe(ctx, actorIdLocal, model.GroupIdDefault, model.UserIdDefault, cbUrl, defaultResultsInterval)
+	}
+	if err == nil {
+		// remember the follower to notify when the interest is gone
+		err = svc.storFollowers.Put(ctx, actorIdLocal, actorId)
+	}
 	var actor vocab.Actor
 	if err == nil {
 		actor, _, err = svc.ap.FetchActor(ctx, vocab.IRI(actorId), pubKeyId)
@@ -239,6 +396,12 @@ func (svc service) handleUndoActivity(ctx context.Context, actorIdLocal, actorId
 	case vocab.FollowType:
 		cbUrl := svc.makeCallbackUrl(actorId)
 		err = svc.svcSubs.Unsubscribe(ctx, actorIdLocal, model.GroupIdDefault, model.UserIdDefault, cbUrl)
+		if err == nil {
+			err = svc.storFollowers.Delete(ctx, actorIdLocal, actorId)
+		}
+	case vocab.BlockType:
+		// unblocked: the source may be added again but is not followed automatically
+		err = svc.storBlocks.Delete(ctx, actorId)
 	}
 	return
 }
@@ -253,26 +416,89 @@ func (svc service) handleSourceActivity(
 	srcId, pubKeyId string,
 	actor vocab.Actor,
 	actorTags util.ObjectTags,
-	activity vocab.Activity,
-	activityTags util.ActivityTags,
-	cm util.ActivityContentMap,
+	env util.Envelope,
 ) (err error) {
+	activity := env.Activity
 	var src model.Source
 	src, err = svc.stor.Read(ctx, srcId)
+	if activity.Type == vocab.BlockType {
+		// the block targets the instance actor, so it's kept for the actor regardless of the subscribing group/user
+		svc.audit(ctx, model.AuditEvent{
+			Kind:       model.AuditKindBlock,
+			ActorId:    srcId,
+			ActivityId: activity.ID.String(),
+			GroupId:    src.GroupId,
+			UserId:     src.UserId,
+			SubId:      src.SubId,
+		})
+		errBlock := svc.storBlocks.Put(ctx, srcId)
+		if err == nil {
+			src.Accepted = false
+			err = svc.stor.Update(ctx, src)
+		}
+		if errors.Is(err, storage.ErrNotFound) {
+			err = nil
+		}
+		err = errors.Join(errBlock, err)
+		return
+	}
+	var blocked bool
+	if err == nil {
+		blocked, err = svc.storBlocks.Blocked(ctx, srcId)
+	}
+	var c model.Consent
+	if err == nil {
+		c, err = svc.svcConsent.Evaluate(ctx, actor, actorTags)
+	}
 	switch {
 	case err == nil:
 		switch {
+		case blocked:
+			err = fmt.Errorf("%w: %s, activity.Type=%s", ErrBlocked, srcId, activity.Type)
+			svc.audit(ctx, model.AuditEvent{
+				Kind:       model.AuditKindDrop,
+				ActorId:    srcId,
+				ActivityId: activity.ID.String(),
+				GroupId:    src.GroupId,
+				UserId:     src.UserId,
+				SubId:      src.SubId,
+				Reason:     ErrBlocked.Error(),
+			})
 		case activity.Type == vocab.AcceptType:
 			src.Accepted = true
 			err = svc.stor.Update(ctx, src)
 		case activity.Type == vocab.RejectType:
 			src.Rejected = true
 			err = svc.stor.Update(ctx, src)
-		case ActorHasNoBotTag(actorTags):
-			err = svc.stor.Delete(ctx, srcId, src.GroupId, src.UserId)
+		case !c.Allowed:
+			// the consent is withdrawn after the source has been followed
+			err = fmt.Errorf("%w: actor %s, %s", ErrNoBot, srcId, c.Reason)
+			svc.audit(ctx, model.AuditEvent{
+				Kind:       model.AuditKindDrop,
+				ActorId:    srcId,
+				ActivityId: activity.ID.String(),
+				GroupId:    src.GroupId,
+				UserId:     src.UserId,
+				SubId:      src.SubId,
+				Reason:     fmt.Sprintf("%s: %s, unfollowed", model.AuditReasonOptOut, c.Reason),
+			})
+			err = errors.Join(err, svc.unfollow(ctx, actor.ID, pubKeyId))
+			err = errors.Join(err, svc.stor.Delete(ctx, srcId, src.GroupId, src.UserId))
 		case src.Accepted:
 			var evt *pb.CloudEvent
-			evt, _ = svc.conv.ConvertActivityToEvent(ctx, actor, activity, activityTags, cm)
+			var errConv error
+			evt, errConv = svc.conv.ConvertActivityToEvent(ctx, actor, env)
+			if errors.Is(errConv, converter.ErrDropped) {
+				svc.audit(ctx, model.AuditEvent{
+					Kind:       model.AuditKindDrop,
+					ActorId:    srcId,
+					ActivityId: activity.ID.String(),
+					GroupId:    src.GroupId,
+					UserId:     src.UserId,
+					SubId:      src.SubId,
+					Reason:     fmt.Sprintf("%s: %s", model.AuditReasonPrivate, errConv),
+				})
+			}
 			if evt != nil && evt.Data != nil {
 				t := time.Now().UTC()
 				// don't update the storage on every activity but only when difference is higher than the threshold
@@ -305,6 +531,9 @@ func (svc service) handleSourceActivity(
 
 func (svc service) Read(ctx context.Context, url vocab.IRI) (a model.Source, err error) {
 	a, err = svc.stor.Read(ctx, url.String())
+	if err == nil {
+		a.Blocked, err = svc.storBlocks.Blocked(ctx, a.ActorId)
+	}
 	return
 }
 
@@ -316,6 +545,14 @@ func (svc service) List(ctx context.Context, filter model.Filter, limit uint32,
 func (svc service) Unfollow(ctx context.Context, url vocab.IRI, groupId, userId string) (err error) {
 	err = svc.unfollow(ctx, url, fmt.Sprintf("https://%s/actor#main-key", svc.hostSelf))
 	err = errors.Join(err, svc.stor.Delete(ctx, url.String(), groupId, userId))
+	if err == nil {
+		svc.audit(ctx, model.AuditEvent{
+			Kind:    model.AuditKindUnfollow,
+			ActorId: url.String(),
+			GroupId: groupId,
+			UserId:  userId,
+		})
+	}
 	return
 }
 
@@ -341,3 +578,301 @@ func (svc service) unfollow(ctx context.Context, url vocab.IRI, pubKeyId string)
 	}
 	return
 }
+
+func (svc service) DeleteInterestActor(ctx context.Context, interestId string, follower vocab.Actor) (err error) {
+	actorId := vocab.IRI(fmt.Sprintf("https://%s/actor/%s", svc.hostSelf, interestId))
+	activity := vocab.Activity{
+		ID:      vocab.ID(fmt.Sprintf("%s#delete-%s", actorId, uuid.NewString())),
+		Type:    vocab.DeleteType,
+		Context: vocab.IRI(model.NsAs),
+		Actor:   actorId,
+		Object:  actorId,
+		To: vocab.ItemCollection{
+			vocab.PublicNS,
+		},
+	}
+	err = svc.ap.SendActivity(ctx, activity, follower.Inbox.GetLink(), string(actorId)+"#main-key")
+	cbUrl := svc.makeCallbackUrl(follower.ID.String())
+	errUnsub := svc.svcSubs.Unsubscribe(ctx, interestId, model.GroupIdDefault, model.UserIdDefault, cbUrl)
+	if errUnsub != nil && !errors.Is(errUnsub, subscriptions.ErrConflict) {
+		err = errors.Join(err, errUnsub)
+	}
+	if err == nil {
+		err = svc.storFollowers.Delete(ctx, interestId, follower.ID.String())
+	}
+	return
+}
+
+func (svc service) DeleteInterest(ctx context.Context, interestId string) (notified uint32, err error) {
+	pubKeyId := fmt.Sprintf("https://%s/actor/%s#main-key", svc.hostSelf, interestId)
+	var cursor string
+	var errs []error
+	for {
+		var page []string
+		page, err = svc.storFollowers.List(ctx, interestId, notifyPageSize, cursor)
+		for _, followerId := range page {
+			follower, _, errFetch := svc.ap.FetchActor(ctx, vocab.IRI(followerId), pubKeyId)
+			switch {
+			case errors.Is(errFetch, activitypub.ErrActorGone):
+				// nobody to notify, only clean up
+				follower.ID = vocab.IRI(followerId)
+				errFetch = svc.forgetFollower(ctx, interestId, follower)
+			case errFetch == nil:
+				errFetch = svc.DeleteInterestActor(ctx, interestId, follower)
+				if errFetch == nil {
+					notified++
+				}
+			}
+			if errFetch != nil {
+				// the follower is kept to be retried by the next sweep
+				errs = append(errs, fmt.Errorf("follower %s: %w", followerId, errFetch))
+			}
+		}
+		if err != nil || len(page) < notifyPageSize {
+			break
+		}
+		cursor = page[len(page)-1]
+	}
+	err = errors.Join(append(errs, err)...)
+	return
+}
+
+func (svc service) forgetFollower(ctx context.Context, interestId string, follower vocab.Actor) (err error) {
+	cbUrl := svc.makeCallbackUrl(follower.ID.String())
+	err = svc.svcSubs.Unsubscribe(ctx, interestId, model.GroupIdDefault, model.UserIdDefault, cbUrl)
+	if errors.Is(err, subscriptions.ErrConflict) {
+		err = nil
+	}
+	if err == nil {
+		err = svc.storFollowers.Delete(ctx, interestId, follower.ID.String())
+	}
+	return
+}
+
+func (svc service) AnnounceKeys(ctx context.Context) (announced uint32, err error) {
+	var cursor string
+	var errs []error
+	for {
+		var page []string
+		page, err = svc.storFollowers.ListInterests(ctx, notifyPageSize, cursor)
+		for _, interestId := range page {
+			done, errAnnounce := svc.announceKey(ctx, interestId)
+			if done {
+				announced++
+			}
+			if errAnnounce != nil {
+				errs = append(errs, fmt.Errorf("interest %s: %w", interestId, errAnnounce))
+			}
+		}
+		if err != nil || len(page) < notifyPageSize {
+			break
+		}
+		cursor = page[len(page)-1]
+	}
+	err = errors.Join(append(errs, err)...)
+	return
+}
+
+func (svc service) announceKey(ctx context.Context, interestId string) (done bool, err error) {
+	var k model.Key
+	k, err = svc.svcKeys.Signing(ctx, fmt.Sprintf("https://%s/actor/%s#main-key", svc.hostSelf, interestId))
+	if err != nil || !k.Announced.IsZero() {
+		return
+	}
+	actorId := vocab.IRI(fmt.Sprintf("https://%s/actor/%s", svc.hostSelf, interestId))
+	var cursor string
+	var errs []error
+	for {
+		var page []string
+		page, err = svc.storFollowers.List(ctx, interestId, notifyPageSize, cursor)
+		for _, followerId := range page {
+			var first bool
+			first, err = svc.svcKeys.Announce(ctx, k.Id, followerId)
+			if err != nil {
+				break
+			}
+			if first {
+				// the follower failed to receive the update will refetch the actor on the signature mismatch
+				if errSend := svc.sendKeyUpdate(ctx, actorId, k.Id, followerId); errSend != nil {
+					errs = append(errs, fmt.Errorf("follower %s: %w", followerId, errSend))
+				}
+			}
+		}
+		if err != nil || len(page) < notifyPageSize {
+			break
+		}
+		cursor = page[len(page)-1]
+	}
+	if err == nil {
+		err = svc.svcKeys.AnnounceDone(ctx, k.Id)
+		done = err == nil
+	}
+	err = errors.Join(append(errs, err)...)
+	return
+}
+
+func (svc service) sendKeyUpdate(ctx context.Context, actorId vocab.IRI, pubKeyId, followerId string) (err error) {
+	var follower vocab.Actor
+	follower, _, err = svc.ap.FetchActor(ctx, vocab.IRI(followerId), pubKeyId)
+	if err == nil {
+		a := vocab.Activity{
+			ID:      vocab.ID(fmt.Sprintf("%s#update-key-%s", actorId, uuid.NewString())),
+			Type:    vocab.UpdateType,
+			Context: vocab.IRI(model.NsAs),
+			Actor:   actorId,
+			Object:  actorId,
+			To: vocab.ItemCollection{
+				follower.ID,
+			},
+		}
+		err = svc.ap.SendActivity(ctx, a, follower.Inbox.GetLink(), pubKeyId)
+	}
+	return
+}
+
+func (svc service) SweepInterests(ctx context.Context) (swept uint32, err error) {
+	now := time.Now().UTC()
+	var cursor string
+	var errs []error
+	for {
+		var page []string
+		page, err = svc.storFollowers.ListInterests(ctx, notifyPageSize, cursor)
+		for _, interestId := range page {
+			d, errRead := svc.svcInterests.Read(ctx, model.GroupIdDefault, model.UserIdDefault, interestId)
+			switch {
+			case errors.Is(errRead, interests.ErrNotFound), errRead == nil && d.Expired(now):
+				_, errRead = svc.DeleteInterest(ctx, interestId)
+				if errRead == nil {
+					swept++
+				}
+			}
+			if errRead != nil {
+				errs = append(errs, fmt.Errorf("interest %s: %w", interestId, errRead))
+			}
+		}
+		if err != nil || len(page) < notifyPageSize {
+			break
+		}
+		cursor = page[len(page)-1]
+	}
+	err = errors.Join(append(errs, err)...)
+	return
+}
+
+func (svc service) RotateKey(ctx context.Context, grace time.Duration) (k model.Key, err error) {
+	if grace < 0 {
+		err = fmt.Errorf("%w: negative grace period %s", ErrInvalid, grace)
+	}
+	if err == nil {
+		k, err = svc.svcKeys.Rotate(ctx, grace)
+	}
+	if err == nil {
+		go svc.notifyActorUpdateBackground()
+	}
+	return
+}
+
+func (svc service) RetireKey(ctx context.Context, id string) (err error) {
+	err = svc.svcKeys.Retire(ctx, id)
+	if err == nil {
+		go svc.notifyActorUpdateBackground()
+	}
+	return
+}
+
+func (svc service) ListKeys(ctx context.Context) (ks []model.Key, err error) {
+	ks, err = svc.svcKeys.Instance(ctx)
+	return
+}
+
+func (svc service) ListAudit(ctx context.Context, filter model.AuditFilter, limit uint32, cursor string, order model.Order) (page []model.AuditEvent, err error) {
+	page, err = svc.storAudit.List(ctx, filter, limit, cursor, order)
+	return
+}
+
+func (svc service) ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error) {
+	page, err = svc.svcMod.ListReports(ctx, filter, limit, cursor)
+	return
+}
+
+func (svc service) ResolveReport(ctx context.Context, id string, action model.ReportAction, noteIds []string) (deleted uint32, err error) {
+	deleted, err = svc.svcMod.Resolve(ctx, id, action, noteIds)
+	return
+}
+
+// audit records the decision. The failure to record doesn't fail the decision itself.
+func (svc service) audit(ctx context.Context, evt model.AuditEvent) {
+	evt.Time = time.Now().UTC()
+	if err := svc.storAudit.Append(ctx, evt); err != nil {
+		fmt.Printf("Failed to record the audit event %+v: %s\n", evt, err)
+	}
+}
+
+// notifyActorUpdateBackground outlives the request which has rotated or retired the key.
+func (svc service) notifyActorUpdateBackground() {
+	notified, err := svc.notifyActorUpdate(context.Background())
+	fmt.Printf("Notified %d inboxes about the instance actor update, err: %v\n", notified, err)
+}
+
+// notifyActorUpdate sends the instance actor's Update to the inboxes of all followed sources, notifyConcurrency at
+// once. Every shared inbox receives the update only once.
+// Failures to deliver to a particular inbox are not fatal, these are only excluded from the notified count.
+func (svc service) notifyActorUpdate(ctx context.Context) (notified uint32, err error) {
+	actorSelf := vocab.IRI(fmt.Sprintf("https://%s/actor", svc.hostSelf))
+	pubKeyId := string(actorSelf) + "#main-key"
+	activity := vocab.Activity{
+		ID:      vocab.ID(fmt.Sprintf("%s#update-%d", actorSelf, time.Now().UnixMilli())),
+		Type:    vocab.UpdateType,
+		Context: vocab.IRI(model.NsAs),
+		Actor:   actorSelf,
+		Object:  actorSelf,
+		To: vocab.ItemCollection{
+			vocab.PublicNS,
+		},
+	}
+	var inboxesLock sync.Mutex
+	inboxes := map[vocab.IRI]bool{}
+	var count atomic.Uint32
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, notifyConcurrency)
+	var cursor string
+	for {
+		var page []string
+		page, err = svc.stor.List(ctx, model.Filter{}, notifyPageSize, cursor, model.OrderAsc)
+		for _, srcId := range page {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				if blocked, _ := svc.storBlocks.Blocked(ctx, srcId); blocked {
+					return
+				}
+				actor, _, errFetch := svc.ap.FetchActor(ctx, vocab.IRI(srcId), pubKeyId)
+				if errFetch != nil {
+					return
+				}
+				inbox := actor.Inbox.GetLink()
+				if actor.Endpoints != nil && actor.Endpoints.SharedInbox != nil {
+					inbox = actor.Endpoints.SharedInbox.GetLink()
+				}
+				inboxesLock.Lock()
+				dup := inbox == "" || inboxes[inbox]
+				inboxes[inbox] = true
+				inboxesLock.Unlock()
+				if !dup && svc.ap.SendActivity(ctx, activity, inbox, pubKeyId) == nil {
+					count.Add(1)
+				}
+			}()
+		}
+		if err != nil || len(page) < notifyPageSize {
+			break
+		}
+		cursor = page[len(page)-1]
+	}
+	wg.Wait()
+	notified = count.Load()
+	return
+}
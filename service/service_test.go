@@ -2,12 +2,20 @@ package service
 
 import (
 	"context"
+	"github.com/awakari/int-activitypub/api/http/interests"
 	"github.com/awakari/int-activitypub/api/http/pub"
 	"github.com/awakari/int-activitypub/api/http/subscriptions"
 	"github.com/awakari/int-activitypub/model"
 	"github.com/awakari/int-activitypub/service/activitypub"
+	"github.com/awakari/int-activitypub/service/consent"
 	"github.com/awakari/int-activitypub/service/converter"
+	"github.com/awakari/int-activitypub/service/keys"
+	"github.com/awakari/int-activitypub/service/moderation"
 	"github.com/awakari/int-activitypub/storage"
+	"github.com/awakari/int-activitypub/storage/audit"
+	"github.com/awakari/int-activitypub/storage/blocks"
+	"github.com/awakari/int-activitypub/storage/followers"
+	storageKeys "github.com/awakari/int-activitypub/storage/keys"
 	"github.com/awakari/int-activitypub/util"
 	vocab "github.com/go-ap/activitypub"
 	"github.com/stretchr/testify/assert"
@@ -21,11 +29,22 @@ func TestService_RequestFollow(t *testing.T) {
 		storage.NewStorageMock(),
 		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
 		"test.social",
-		converter.NewLogging(converter.NewService("foo", "urlBase", "", "", vocab.ServiceType), slog.Default()),
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
 		pub.NewLogging(pub.NewMock(), slog.Default()),
 		1*time.Second,
 		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
 		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
 	)
 	svc = NewLogging(svc, slog.Default())
 	cases := map[string]struct {
@@ -70,6 +89,16 @@ func TestService_RequestFollow(t *testing.T) {
 			url:  "https://privacy.social/users/nobot2",
 			err:  ErrNoBot,
 		},
+		"blocked": {
+			addr: "https://host.social/users/blocked",
+			url:  "https://host.social/users/blocked",
+			err:  ErrBlocked,
+		},
+		"nobot in summary": {
+			addr: "https://privacy.social/users/nobot1",
+			url:  "https://privacy.social/users/nobot1",
+			err:  ErrNoBot,
+		},
 		"self-hosted actor 1": {
 			addr: "actor1@test.social",
 			url:  "https://test.social/users/actor1",
@@ -100,25 +129,118 @@ func TestService_HandleActivity(t *testing.T) {
 		storage.NewStorageMock(),
 		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
 		"test.social",
-		converter.NewLogging(converter.NewService("foo", "urlBase", "", "", vocab.ServiceType), slog.Default()),
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
 		pub.NewLogging(pub.NewMock(), slog.Default()),
 		1*time.Second,
 		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
 		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
 	)
 	svc = NewLogging(svc, slog.Default())
 	cases := map[string]struct {
-		url      vocab.IRI
-		activity vocab.Activity
-		err      error
+		actorIdLocal string
+		url          vocab.IRI
+		tags         util.ObjectTags
+		activity     vocab.Activity
+		envTags      []util.ActivityTag
+		err          error
 	}{
 		"ok": {
 			url: "https://host.social/users/existing",
 		},
+		"consent withdrawn": {
+			url: "https://host.social/users/existing",
+			tags: util.ObjectTags{
+				Extra: map[string][]byte{
+					"indexable": []byte("false"),
+				},
+			},
+			err: ErrNoBot,
+		},
+		"block": {
+			url: "https://host.social/users/existing",
+			activity: vocab.Activity{
+				Type: vocab.BlockType,
+			},
+		},
+		"block unknown": {
+			url: "https://host.social/users/unknown",
+			activity: vocab.Activity{
+				Type: vocab.BlockType,
+			},
+		},
+		"blocked source activity": {
+			url: "https://host.social/users/blocked",
+			activity: vocab.Activity{
+				Type: vocab.CreateType,
+			},
+			err: ErrBlocked,
+		},
+		"unblock": {
+			url: "https://host.social/users/blocked",
+			activity: vocab.Activity{
+				Type: vocab.UndoType,
+				Object: &vocab.Activity{
+					Type: vocab.BlockType,
+				},
+			},
+		},
+		"activity has nobot tag": {
+			url: "https://host.social/users/existing",
+			activity: vocab.Activity{
+				Type: vocab.CreateType,
+			},
+			envTags: []util.ActivityTag{
+				{
+					Type: "Hashtag",
+					Name: NoBot,
+				},
+			},
+			err: ErrNoBot,
+		},
+		"flag": {
+			url: "https://host.social/actor",
+			activity: vocab.Activity{
+				ID:     "https://host.social/flags/1",
+				Type:   vocab.FlagType,
+				Object: vocab.IRI("https://test.social/note1"),
+			},
+			envTags: []util.ActivityTag{
+				{
+					Type: "Hashtag",
+					Name: NoBot,
+				},
+			},
+		},
+		"flag invalid": {
+			url: "https://host.social/actor",
+			activity: vocab.Activity{
+				Type: vocab.FlagType,
+			},
+			err: moderation.ErrInvalid,
+		},
+		"follow suspended": {
+			actorIdLocal: "suspended",
+			url:          "https://host.social/users/existing",
+			activity: vocab.Activity{
+				Type: vocab.FollowType,
+			},
+			err: ErrSuspended,
+		},
 	}
 	for k, c := range cases {
 		t.Run(k, func(t *testing.T) {
-			post, err := svc.HandleActivity(context.TODO(), "", "foo.bar#main.key", vocab.Actor{ID: c.url}, util.ObjectTags{}, c.activity, util.ActivityTags{}, util.ActivityContentMap{})
+			post, err := svc.HandleActivity(context.TODO(), c.actorIdLocal, "foo.bar#main.key", vocab.Actor{ID: c.url}, c.tags, util.Envelope{Activity: c.activity, Tags: c.envTags})
 			assert.Nil(t, post)
 			assert.ErrorIs(t, err, c.err)
 		})
@@ -130,11 +252,22 @@ func TestService_Read(t *testing.T) {
 		storage.NewStorageMock(),
 		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
 		"test.social",
-		converter.NewLogging(converter.NewService("foo", "urlBase", "", "", vocab.ServiceType), slog.Default()),
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
 		pub.NewLogging(pub.NewMock(), slog.Default()),
 		1*time.Second,
 		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
 		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
 	)
 	svc = NewLogging(svc, slog.Default())
 	cases := map[string]struct {
@@ -146,6 +279,10 @@ func TestService_Read(t *testing.T) {
 			url:   "https://host.social/users/existing",
 			actor: model.Source{ActorId: "user1@server1.social", GroupId: "group1", UserId: "user2", Type: "Person", Name: "John Doe", Summary: "yohoho", Accepted: true},
 		},
+		"blocked": {
+			url:   "https://host.social/users/blocked",
+			actor: model.Source{ActorId: "https://host.social/users/blocked", GroupId: "group1", UserId: "user2", Type: "Person", Name: "John Doe", Blocked: true},
+		},
 		"fail": {
 			url: "https://host.social/users/storfail",
 			err: storage.ErrInternal,
@@ -168,11 +305,22 @@ func TestService_List(t *testing.T) {
 		storage.NewStorageMock(),
 		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
 		"test.social",
-		converter.NewLogging(converter.NewService("foo", "urlBase", "", "", vocab.ServiceType), slog.Default()),
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
 		pub.NewLogging(pub.NewMock(), slog.Default()),
 		1*time.Second,
 		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
 		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
 	)
 	svc = NewLogging(svc, slog.Default())
 	cases := map[string]struct {
@@ -208,11 +356,22 @@ func TestService_Unfollow(t *testing.T) {
 		storage.NewStorageMock(),
 		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
 		"test.social",
-		converter.NewLogging(converter.NewService("foo", "urlBase", "", "", vocab.ServiceType), slog.Default()),
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
 		pub.NewLogging(pub.NewMock(), slog.Default()),
 		1*time.Second,
 		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
 		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
 	)
 	svc = NewLogging(svc, slog.Default())
 	cases := map[string]struct {
@@ -244,3 +403,351 @@ func TestService_Unfollow(t *testing.T) {
 		})
 	}
 }
+
+func TestService_DeleteInterestActor(t *testing.T) {
+	svc := NewService(
+		storage.NewStorageMock(),
+		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
+		"test.social",
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
+		pub.NewLogging(pub.NewMock(), slog.Default()),
+		1*time.Second,
+		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
+		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
+	)
+	svc = NewLogging(svc, slog.Default())
+	cases := map[string]struct {
+		follower vocab.Actor
+		err      error
+	}{
+		"ok": {
+			follower: vocab.Actor{
+				ID:    "https://host.social/users/johndoe",
+				Inbox: vocab.IRI("https://host.social/users/johndoe/inbox"),
+			},
+		},
+		"callback already removed": {
+			follower: vocab.Actor{
+				ID:    "missing",
+				Inbox: vocab.IRI("https://host.social/users/missing/inbox"),
+			},
+		},
+		"fails to send activity": {
+			follower: vocab.Actor{
+				ID:    "https://host.fail/users/johndoe",
+				Inbox: vocab.IRI("https://host.fail/users/johndoe/inbox"),
+			},
+			err: activitypub.ErrActivitySend,
+		},
+		"fails to unsubscribe": {
+			follower: vocab.Actor{
+				ID:    "fail",
+				Inbox: vocab.IRI("https://host.social/users/fail/inbox"),
+			},
+			err: subscriptions.ErrInternal,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			err := svc.DeleteInterestActor(context.TODO(), "interest1", c.follower)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestService_DeleteInterest(t *testing.T) {
+	svc := NewService(
+		storage.NewStorageMock(),
+		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
+		"test.social",
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
+		pub.NewLogging(pub.NewMock(), slog.Default()),
+		1*time.Second,
+		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
+		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
+	)
+	svc = NewLogging(svc, slog.Default())
+	cases := map[string]struct {
+		interestId string
+		notified   uint32
+		err        error
+	}{
+		"no followers": {
+			interestId: "interest-active",
+		},
+		"ok": {
+			interestId: "interest-expired",
+			notified:   1,
+		},
+		"partial": {
+			interestId: "interest-deleted",
+			notified:   1,
+			err:        activitypub.ErrActorFetch,
+		},
+		"fail": {
+			interestId: "fail",
+			err:        followers.ErrInternal,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			notified, err := svc.DeleteInterest(context.TODO(), c.interestId)
+			assert.Equal(t, c.notified, notified)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestService_SweepInterests(t *testing.T) {
+	svc := NewService(
+		storage.NewStorageMock(),
+		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
+		"test.social",
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
+		pub.NewLogging(pub.NewMock(), slog.Default()),
+		1*time.Second,
+		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
+		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
+	)
+	svc = NewLogging(svc, slog.Default())
+	swept, err := svc.SweepInterests(context.TODO())
+	assert.Equal(t, uint32(1), swept)
+	assert.ErrorIs(t, err, activitypub.ErrActorFetch)
+}
+
+func TestService_AnnounceKeys(t *testing.T) {
+	svc := NewService(
+		storage.NewStorageMock(),
+		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
+		"test.social",
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
+		pub.NewLogging(pub.NewMock(), slog.Default()),
+		1*time.Second,
+		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
+		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
+	)
+	svc = NewLogging(svc, slog.Default())
+	announced, err := svc.AnnounceKeys(context.TODO())
+	assert.Equal(t, uint32(2), announced)
+	assert.ErrorIs(t, err, activitypub.ErrActorFetch)
+}
+
+func TestService_RotateKey(t *testing.T) {
+	svc := NewService(
+		storage.NewStorageMock(),
+		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
+		"test.social",
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
+		pub.NewLogging(pub.NewMock(), slog.Default()),
+		1*time.Second,
+		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
+		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
+	)
+	svc = NewLogging(svc, slog.Default())
+	cases := map[string]struct {
+		grace time.Duration
+		id    string
+		err   error
+	}{
+		"ok": {
+			grace: 24 * time.Hour,
+			id:    "https://test.social/actor#key-next",
+		},
+		"negative grace period": {
+			grace: -1 * time.Hour,
+			err:   ErrInvalid,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			key, err := svc.RotateKey(context.TODO(), c.grace)
+			assert.Equal(t, c.id, key.Id)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestService_RetireKey(t *testing.T) {
+	svc := NewService(
+		storage.NewStorageMock(),
+		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
+		"test.social",
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
+		pub.NewLogging(pub.NewMock(), slog.Default()),
+		1*time.Second,
+		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
+		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
+	)
+	svc = NewLogging(svc, slog.Default())
+	cases := map[string]struct {
+		id  string
+		err error
+	}{
+		"ok": {
+			id: "https://test.social/actor#key-prev",
+		},
+		"still active": {
+			id:  "https://test.social/actor#main-key",
+			err: keys.ErrInvalid,
+		},
+		"missing": {
+			id:  "missing",
+			err: storageKeys.ErrNotFound,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			err := svc.RetireKey(context.TODO(), c.id)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestService_NotifyActorUpdate(t *testing.T) {
+	svc := NewService(
+		storage.NewStorageMock(),
+		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
+		"test.social",
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
+		pub.NewLogging(pub.NewMock(), slog.Default()),
+		1*time.Second,
+		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
+		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
+	)
+	notified, err := svc.(service).notifyActorUpdate(context.TODO())
+	assert.Equal(t, uint32(2), notified)
+	assert.Nil(t, err)
+}
+
+func TestService_ListAudit(t *testing.T) {
+	svc := NewService(
+		storage.NewStorageMock(),
+		activitypub.NewServiceLogging(activitypub.NewServiceMock(), slog.Default()),
+		"test.social",
+		converter.NewLogging(converter.NewService(converter.Options{
+			CeType:    "foo",
+			UrlBase:   "urlBase",
+			ActorType: vocab.ServiceType,
+		}, nil), slog.Default()),
+		pub.NewLogging(pub.NewMock(), slog.Default()),
+		1*time.Second,
+		subscriptions.NewServiceLogging(subscriptions.NewServiceMock(), slog.Default()),
+		"http://int-activitypub:8081",
+		keys.NewLogging(keys.NewServiceMock(), slog.Default()),
+		consent.NewLogging(consent.NewServiceMock(), slog.Default()),
+		audit.NewStorageMock(),
+		moderation.NewLogging(moderation.NewServiceMock(), slog.Default()),
+		interests.NewLogging(interests.NewServiceMock(), slog.Default()),
+		followers.NewStorageMock(),
+		blocks.NewStorageMock(),
+	)
+	svc = NewLogging(svc, slog.Default())
+	cases := map[string]struct {
+		actorId string
+		kinds   []model.AuditKind
+		err     error
+	}{
+		"ok": {
+			actorId: "https://host.social/users/johndoe",
+			kinds: []model.AuditKind{
+				model.AuditKindFollow,
+				model.AuditKindAccept,
+			},
+		},
+		"empty": {
+			actorId: "https://host.social/users/missing",
+		},
+		"fail": {
+			actorId: "fail",
+			err:     audit.ErrInternal,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			page, err := svc.ListAudit(context.TODO(), model.AuditFilter{ActorId: c.actorId}, 10, "", model.OrderAsc)
+			var kinds []model.AuditKind
+			for _, evt := range page {
+				kinds = append(kinds, evt.Kind)
+			}
+			assert.Equal(t, c.kinds, kinds)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"github.com/awakari/int-activitypub/util"
+	"log/slog"
+	"net/http"
+)
+
+type logging struct {
+	svc Service
+	log *slog.Logger
+}
+
+func NewLogging(svc Service, log *slog.Logger) Service {
+	return logging{
+		svc: svc,
+		log: log,
+	}
+}
+
+func (l logging) Sign(ctx context.Context, req *http.Request, body []byte, pubKeyId string) (err error) {
+	err = l.svc.Sign(ctx, req, body, pubKeyId)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("signer.Sign(req=%s %s, body=%d, pubKeyId=%s): %s", req.Method, req.URL, len(body), pubKeyId, err))
+	return
+}
+
+func (l logging) Load(ctx context.Context, pubKeyId string) (err error) {
+	err = l.svc.Load(ctx, pubKeyId)
+	l.log.Log(ctx, util.LogLevel(err), fmt.Sprintf("signer.Load(pubKeyId=%s): %s", pubKeyId, err))
+	return
+}
@@ -0,0 +1,31 @@
+package signer
+
+import (
+	"context"
+	"net/http"
+)
+
+type mock struct {
+}
+
+func NewServiceMock() Service {
+	return mock{}
+}
+
+func (m mock) Sign(ctx context.Context, req *http.Request, body []byte, pubKeyId string) (err error) {
+	switch pubKeyId {
+	case "fail":
+		err = ErrSign
+	default:
+		req.Header.Set("Signature", "keyId=\""+pubKeyId+"\"")
+	}
+	return
+}
+
+func (m mock) Load(ctx context.Context, pubKeyId string) (err error) {
+	switch pubKeyId {
+	case "malformed":
+		err = ErrMalformedKey
+	}
+	return
+}
@@ -0,0 +1,155 @@
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/awakari/int-activitypub/service/keys"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/superseriousbusiness/httpsig"
+	"golang.org/x/crypto/ssh"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Service interface {
+
+	// Sign adds the digest and the HTTP signature headers to the request on behalf of the key id.
+	Sign(ctx context.Context, req *http.Request, body []byte, pubKeyId string) (err error)
+
+	// Load resolves and parses the private key to sign with on behalf of the key id.
+	// Intended to detect the malformed keys before the first request is signed.
+	Load(ctx context.Context, pubKeyId string) (err error)
+}
+
+// parsedKey is the private key parsed once and the signature algorithm it's suitable for.
+type parsedKey struct {
+	priv crypto.PrivateKey
+	algo httpsig.Algorithm
+}
+
+type service struct {
+	svcKeys keys.Service
+	parsed  *expirable.LRU[string, parsedKey]
+	// signers per algorithm: httpsig signers are not safe for concurrent use but are reusable
+	signers map[httpsig.Algorithm]*sync.Pool
+}
+
+const expiresIn = 120
+
+var digestAlgorithm = httpsig.DigestSha256
+var headersToSign = []string{
+	httpsig.RequestTarget,
+	"host",
+	"date",
+	"digest",
+}
+var algorithms = []httpsig.Algorithm{
+	httpsig.RSA_SHA256,
+	httpsig.ED25519,
+}
+
+var ErrMalformedKey = errors.New("malformed private key")
+var ErrSign = errors.New("failed to sign the request")
+
+// NewService returns the signer which keeps up to the cacheSize parsed keys for the cacheTtl.
+// Fails when any of the supported signature algorithms is not available.
+func NewService(svcKeys keys.Service, cacheSize int, cacheTtl time.Duration) (s Service, err error) {
+	signers := make(map[httpsig.Algorithm]*sync.Pool, len(algorithms))
+	for _, algo := range algorithms {
+		var sig httpsig.Signer
+		sig, err = newSigner(algo)
+		if err != nil {
+			return
+		}
+		signers[algo] = &sync.Pool{}
+		signers[algo].Put(sig)
+	}
+	s = service{
+		svcKeys: svcKeys,
+		parsed:  expirable.NewLRU[string, parsedKey](cacheSize, nil, cacheTtl),
+		signers: signers,
+	}
+	return
+}
+
+func newSigner(algo httpsig.Algorithm) (s httpsig.Signer, err error) {
+	s, _, err = httpsig.NewSigner([]httpsig.Algorithm{algo}, digestAlgorithm, headersToSign, httpsig.Signature, expiresIn)
+	if err != nil {
+		err = fmt.Errorf("%w: algorithm %s: %s", ErrSign, algo, err)
+	}
+	return
+}
+
+func (svc service) Sign(ctx context.Context, req *http.Request, body []byte, pubKeyId string) (err error) {
+	var keyId string
+	var pk parsedKey
+	keyId, pk, err = svc.resolve(ctx, pubKeyId)
+	if err == nil {
+		pool := svc.signers[pk.algo]
+		// the pool may be drained by GC, the algorithm is validated by the constructor already
+		s, ok := pool.Get().(httpsig.Signer)
+		if !ok {
+			s, err = newSigner(pk.algo)
+		}
+		if err == nil {
+			err = s.SignRequest(pk.priv, keyId, req, body)
+			pool.Put(s)
+			if err != nil {
+				err = fmt.Errorf("%w with the key %s: %s", ErrSign, keyId, err)
+			}
+		}
+	}
+	return
+}
+
+func (svc service) Load(ctx context.Context, pubKeyId string) (err error) {
+	_, _, err = svc.resolve(ctx, pubKeyId)
+	return
+}
+
+func (svc service) resolve(ctx context.Context, pubKeyId string) (keyId string, pk parsedKey, err error) {
+	var k model.Key
+	k, err = svc.svcKeys.Signing(ctx, pubKeyId)
+	if err != nil {
+		err = fmt.Errorf("%w: failed to resolve the private key %s: %s", ErrSign, pubKeyId, err)
+	}
+	if err == nil {
+		keyId = k.Id
+		var found bool
+		pk, found = svc.parsed.Get(keyId)
+		if !found {
+			pk, err = parse(k)
+			if err == nil {
+				svc.parsed.Add(keyId, pk)
+			}
+		}
+	}
+	return
+}
+
+func parse(k model.Key) (pk parsedKey, err error) {
+	pk.priv, err = ssh.ParseRawPrivateKey([]byte(k.Private))
+	if err == nil {
+		switch priv := pk.priv.(type) {
+		case *rsa.PrivateKey:
+			pk.algo = httpsig.RSA_SHA256
+		case *ed25519.PrivateKey:
+			pk.priv = *priv
+			pk.algo = httpsig.ED25519
+		case ed25519.PrivateKey:
+			pk.algo = httpsig.ED25519
+		default:
+			err = fmt.Errorf("unsupported key type %T", priv)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("%w %s: %s", ErrMalformedKey, k.Id, err)
+	}
+	return
+}
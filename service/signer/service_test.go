@@ -0,0 +1,116 @@
+package signer
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/awakari/int-activitypub/service/keys"
+	storageKeys "github.com/awakari/int-activitypub/storage/keys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superseriousbusiness/httpsig"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestService_Sign(t *testing.T) {
+	svcKeys := keys.NewService(storageKeys.NewLocalCache(storageKeys.NewStorageMock(), 16, time.Hour), "https://test.social/actor")
+	svc, err := NewService(svcKeys, 16, time.Hour)
+	require.Nil(t, err)
+	svc = NewLogging(svc, slog.Default())
+	svcMalformed, err := NewService(keys.NewServiceMock(), 16, time.Hour)
+	require.Nil(t, err)
+	cases := map[string]struct {
+		svc      Service
+		pubKeyId string
+		err      error
+	}{
+		"ok": {
+			svc:      svc,
+			pubKeyId: "https://test.social/actor/interest1#main-key",
+		},
+		"malformed": {
+			svc:      svcMalformed,
+			pubKeyId: "https://test.social/actor/interest1#main-key",
+			err:      ErrMalformedKey,
+		},
+		"fail to resolve": {
+			svc:      svcMalformed,
+			pubKeyId: "fail",
+			err:      ErrSign,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "https://host.social/inbox", strings.NewReader("{}"))
+			req.Header.Set("Host", req.URL.Host)
+			req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+			err := c.svc.Sign(context.TODO(), req, []byte("{}"), c.pubKeyId)
+			assert.ErrorIs(t, err, c.err)
+			if c.err == nil {
+				v, err := httpsig.NewVerifier(req)
+				require.Nil(t, err)
+				assert.Equal(t, c.pubKeyId, v.KeyId())
+				pubPem, err := svcKeys.Public(context.TODO(), c.pubKeyId)
+				require.Nil(t, err)
+				b, _ := pem.Decode([]byte(pubPem))
+				pub, err := x509.ParsePKIXPublicKey(b.Bytes)
+				require.Nil(t, err)
+				assert.Nil(t, v.Verify(pub, httpsig.RSA_SHA256))
+			}
+		})
+	}
+}
+
+func TestService_Sign_Concurrent(t *testing.T) {
+	svcKeys := keys.NewService(storageKeys.NewLocalCache(storageKeys.NewStorageMock(), 16, time.Hour), "https://test.social/actor")
+	svc, err := NewService(svcKeys, 16, time.Hour)
+	require.Nil(t, err)
+	pubKeyId := "https://test.social/actor/interest1#main-key"
+	require.Nil(t, svc.Load(context.TODO(), pubKeyId))
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "https://host.social/users/johndoe", nil)
+			req.Header.Set("Host", req.URL.Host)
+			req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+			assert.Nil(t, svc.Sign(context.TODO(), req, []byte{}, pubKeyId))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestService_Load(t *testing.T) {
+	svcKeys := keys.NewService(storageKeys.NewLocalCache(storageKeys.NewStorageMock(), 16, time.Hour), "https://test.social/actor")
+	svc, err := NewService(svcKeys, 16, time.Hour)
+	require.Nil(t, err)
+	svcMalformed, err := NewService(keys.NewServiceMock(), 16, time.Hour)
+	require.Nil(t, err)
+	cases := map[string]struct {
+		svc      Service
+		pubKeyId string
+		err      error
+	}{
+		"ok": {
+			svc:      svc,
+			pubKeyId: "https://test.social/actor/interest1#main-key",
+		},
+		"malformed": {
+			svc:      svcMalformed,
+			pubKeyId: "https://test.social/actor#main-key",
+			err:      ErrMalformedKey,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			err := NewLogging(c.svc, slog.Default()).Load(context.TODO(), c.pubKeyId)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
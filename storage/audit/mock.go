@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"time"
+)
+
+type mock struct {
+}
+
+func NewStorageMock() Storage {
+	return mock{}
+}
+
+func (s mock) Close() error {
+	return nil
+}
+
+func (s mock) Append(ctx context.Context, evt model.AuditEvent) (err error) {
+	switch evt.ActorId {
+	case "https://fail.social/users/johndoe":
+		err = ErrInternal
+	}
+	return
+}
+
+func (s mock) List(ctx context.Context, filter model.AuditFilter, limit uint32, cursor string, order model.Order) (page []model.AuditEvent, err error) {
+	switch filter.ActorId {
+	case "fail":
+		err = ErrInternal
+	case "https://host.social/users/johndoe":
+		page = []model.AuditEvent{
+			{
+				Id:      "2jVY3OXbYgEqHxfA3yDmS1qzNPd",
+				Time:    time.Date(2024, 11, 12, 13, 14, 15, 0, time.UTC),
+				Kind:    model.AuditKindFollow,
+				ActorId: filter.ActorId,
+				GroupId: "group0",
+				UserId:  "user1",
+			},
+			{
+				Id:         "2jVY4OXbYgEqHxfA3yDmS1qzNPe",
+				Time:       time.Date(2024, 11, 12, 13, 14, 16, 0, time.UTC),
+				Kind:       model.AuditKindAccept,
+				ActorId:    filter.ActorId,
+				ActivityId: "https://host.social/users/johndoe#accepts/follows/1",
+			},
+		}
+	}
+	return
+}
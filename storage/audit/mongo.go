@@ -0,0 +1,247 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/config"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/segmentio/ksuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type recEvent struct {
+	Id         string    `bson:"id"`
+	Time       time.Time `bson:"time"`
+	Kind       string    `bson:"kind"`
+	ActorId    string    `bson:"actorId"`
+	ActivityId string    `bson:"activityId,omitempty"`
+	GroupId    string    `bson:"groupId,omitempty"`
+	UserId     string    `bson:"userId,omitempty"`
+	SubId      string    `bson:"subId,omitempty"`
+	Reason     string    `bson:"reason,omitempty"`
+}
+
+const attrId = "id"
+const attrTime = "time"
+const attrKind = "kind"
+const attrActorId = "actorId"
+const attrGroupId = "groupId"
+const attrUserId = "userId"
+const attrSubId = "subId"
+
+type storageMongo struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+var sortListAsc = bson.D{
+	{
+		Key:   attrId,
+		Value: 1,
+	},
+}
+var sortListDesc = bson.D{
+	{
+		Key:   attrId,
+		Value: -1,
+	},
+}
+
+func NewStorage(ctx context.Context, db *mongo.Database, cfgDb config.DbConfig) (s Storage, err error) {
+	var sm storageMongo
+	coll := db.Collection(cfgDb.Table.Audit.Name)
+	sm.db = db
+	sm.coll = coll
+	_, err = sm.ensureIndices(ctx, cfgDb.Table.Audit.RetentionPeriod)
+	if err == nil {
+		s = sm
+	}
+	return
+}
+
+func (sm storageMongo) ensureIndices(ctx context.Context, retentionPeriod time.Duration) ([]string, error) {
+	return sm.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{
+					Key:   attrId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{
+					Key:   attrTime,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetExpireAfterSeconds(int32(retentionPeriod / time.Second)).
+				SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				{
+					Key:   attrActorId,
+					Value: 1,
+				},
+				{
+					Key:   attrId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				{
+					Key:   attrGroupId,
+					Value: 1,
+				},
+				{
+					Key:   attrUserId,
+					Value: 1,
+				},
+				{
+					Key:   attrId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetSparse(true).
+				SetUnique(false),
+		},
+	})
+}
+
+// Close does nothing, the database client is shared and disconnected by the owner.
+func (sm storageMongo) Close() error {
+	return nil
+}
+
+func (sm storageMongo) Append(ctx context.Context, evt model.AuditEvent) (err error) {
+	t := evt.Time
+	if t.IsZero() {
+		t = time.Now().UTC()
+	}
+	var id ksuid.KSUID
+	id, err = ksuid.NewRandomWithTime(t)
+	if err == nil {
+		rec := recEvent{
+			Id:         id.String(),
+			Time:       t,
+			Kind:       string(evt.Kind),
+			ActorId:    evt.ActorId,
+			ActivityId: evt.ActivityId,
+			GroupId:    evt.GroupId,
+			UserId:     evt.UserId,
+			SubId:      evt.SubId,
+			Reason:     evt.Reason,
+		}
+		_, err = sm.coll.InsertOne(ctx, rec)
+	}
+	err = decodeError(err)
+	return
+}
+
+func (sm storageMongo) List(ctx context.Context, filter model.AuditFilter, limit uint32, cursor string, order model.Order) (page []model.AuditEvent, err error) {
+	q := bson.M{}
+	if filter.ActorId != "" {
+		q[attrActorId] = filter.ActorId
+	}
+	if filter.GroupId != "" {
+		q[attrGroupId] = filter.GroupId
+	}
+	if filter.UserId != "" {
+		q[attrUserId] = filter.UserId
+	}
+	if filter.SubId != "" {
+		q[attrSubId] = filter.SubId
+	}
+	if len(filter.Kinds) > 0 {
+		q[attrKind] = bson.M{
+			"$in": filter.Kinds,
+		}
+	}
+	clauseTime := bson.M{}
+	if !filter.Since.IsZero() {
+		clauseTime["$gte"] = filter.Since
+	}
+	if !filter.Until.IsZero() {
+		clauseTime["$lt"] = filter.Until
+	}
+	if len(clauseTime) > 0 {
+		q[attrTime] = clauseTime
+	}
+	switch {
+	case limit == 0:
+		limit = LimitDefault
+	case limit > LimitMax:
+		limit = LimitMax
+	}
+	optsList := options.
+		Find().
+		SetLimit(int64(limit)).
+		SetShowRecordID(false)
+	switch order {
+	case model.OrderDesc:
+		if cursor != "" {
+			q[attrId] = bson.M{
+				"$lt": cursor,
+			}
+		}
+		optsList = optsList.SetSort(sortListDesc)
+	default:
+		q[attrId] = bson.M{
+			"$gt": cursor,
+		}
+		optsList = optsList.SetSort(sortListAsc)
+	}
+	var cur *mongo.Cursor
+	cur, err = sm.coll.Find(ctx, q, optsList)
+	if err == nil {
+		for cur.Next(ctx) {
+			var rec recEvent
+			err = errors.Join(err, cur.Decode(&rec))
+			if err == nil {
+				page = append(page, rec.decode())
+			}
+		}
+	}
+	err = decodeError(err)
+	return
+}
+
+func (rec recEvent) decode() (evt model.AuditEvent) {
+	evt.Id = rec.Id
+	evt.Time = rec.Time
+	evt.Kind = model.AuditKind(rec.Kind)
+	evt.ActorId = rec.ActorId
+	evt.ActivityId = rec.ActivityId
+	evt.GroupId = rec.GroupId
+	evt.UserId = rec.UserId
+	evt.SubId = rec.SubId
+	evt.Reason = rec.Reason
+	return
+}
+
+func decodeError(src error) (dst error) {
+	switch {
+	case src == nil:
+	default:
+		dst = fmt.Errorf("%w: %s", ErrInternal, src)
+	}
+	return
+}
@@ -0,0 +1,26 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"github.com/awakari/int-activitypub/model"
+	"io"
+)
+
+// Storage is append-only: the events are never updated and are only removed after the retention period.
+type Storage interface {
+	io.Closer
+
+	// Append records the event. The event id is assigned by the storage.
+	Append(ctx context.Context, evt model.AuditEvent) (err error)
+
+	// List returns the page of the events matching the filter, ordered by the id which is time sortable.
+	// The cursor is the last event id from the previous page.
+	// The zero limit means LimitDefault, the limit above LimitMax is reduced to LimitMax.
+	List(ctx context.Context, filter model.AuditFilter, limit uint32, cursor string, order model.Order) (page []model.AuditEvent, err error)
+}
+
+const LimitDefault = 100
+const LimitMax = 1_000
+
+var ErrInternal = errors.New("audit storage internal failure")
@@ -0,0 +1,42 @@
+package blocks
+
+import (
+	"context"
+)
+
+type mock struct {
+}
+
+func NewStorageMock() Storage {
+	return mock{}
+}
+
+func (s mock) Close() error {
+	return nil
+}
+
+func (s mock) Put(ctx context.Context, actorId string) (err error) {
+	switch actorId {
+	case "fail":
+		err = ErrInternal
+	}
+	return
+}
+
+func (s mock) Blocked(ctx context.Context, actorId string) (blocked bool, err error) {
+	switch actorId {
+	case "https://host.social/users/blocksfail":
+		err = ErrInternal
+	case "https://host.social/users/blocked":
+		blocked = true
+	}
+	return
+}
+
+func (s mock) Delete(ctx context.Context, actorId string) (err error) {
+	switch actorId {
+	case "fail":
+		err = ErrInternal
+	}
+	return
+}
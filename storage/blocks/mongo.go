@@ -0,0 +1,100 @@
+package blocks
+
+import (
+	"context"
+	"fmt"
+	"github.com/awakari/int-activitypub/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type recBlock struct {
+	ActorId string    `bson:"actorId"`
+	Created time.Time `bson:"created"`
+}
+
+const attrActorId = "actorId"
+
+type storageMongo struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+func NewStorage(ctx context.Context, db *mongo.Database, cfgDb config.DbConfig) (s Storage, err error) {
+	var sm storageMongo
+	sm.db = db
+	sm.coll = db.Collection(cfgDb.Table.Blocks.Name)
+	err = sm.ensureIndices(ctx)
+	if err == nil {
+		s = sm
+	}
+	return
+}
+
+func (sm storageMongo) ensureIndices(ctx context.Context) (err error) {
+	_, err = sm.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{
+					Key:   attrActorId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(true),
+		},
+	})
+	return
+}
+
+// Close does nothing, the database client is shared and disconnected by the owner.
+func (sm storageMongo) Close() error {
+	return nil
+}
+
+func (sm storageMongo) Put(ctx context.Context, actorId string) (err error) {
+	q := bson.M{
+		attrActorId: actorId,
+	}
+	u := bson.M{
+		"$setOnInsert": recBlock{
+			ActorId: actorId,
+			Created: time.Now().UTC(),
+		},
+	}
+	_, err = sm.coll.UpdateOne(ctx, q, u, options.Update().SetUpsert(true))
+	err = decodeError(err)
+	return
+}
+
+func (sm storageMongo) Blocked(ctx context.Context, actorId string) (blocked bool, err error) {
+	q := bson.M{
+		attrActorId: actorId,
+	}
+	var count int64
+	count, err = sm.coll.CountDocuments(ctx, q, options.Count().SetLimit(1))
+	blocked = count > 0
+	err = decodeError(err)
+	return
+}
+
+func (sm storageMongo) Delete(ctx context.Context, actorId string) (err error) {
+	q := bson.M{
+		attrActorId: actorId,
+	}
+	_, err = sm.coll.DeleteOne(ctx, q)
+	err = decodeError(err)
+	return
+}
+
+func decodeError(src error) (dst error) {
+	switch {
+	case src == nil:
+	default:
+		dst = fmt.Errorf("%w: %s", ErrInternal, src)
+	}
+	return
+}
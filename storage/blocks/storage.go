@@ -0,0 +1,24 @@
+package blocks
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Storage keeps the remote actors blocked the instance actor. The block is instance wide, regardless of the group/user
+// subscribing the actor, and remains after the source itself is gone.
+type Storage interface {
+	io.Closer
+
+	// Put remembers the actor has blocked the instance. Does nothing when already known.
+	Put(ctx context.Context, actorId string) (err error)
+
+	// Blocked returns true when the actor has blocked the instance.
+	Blocked(ctx context.Context, actorId string) (blocked bool, err error)
+
+	// Delete forgets the actor's block. Does nothing when there's no block.
+	Delete(ctx context.Context, actorId string) (err error)
+}
+
+var ErrInternal = errors.New("blocks storage internal failure")
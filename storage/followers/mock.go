@@ -0,0 +1,64 @@
+package followers
+
+import (
+	"context"
+)
+
+type mock struct {
+}
+
+func NewStorageMock() Storage {
+	return mock{}
+}
+
+func (s mock) Close() error {
+	return nil
+}
+
+func (s mock) Put(ctx context.Context, interestId, followerId string) (err error) {
+	switch followerId {
+	case "fail":
+		err = ErrInternal
+	}
+	return
+}
+
+func (s mock) Delete(ctx context.Context, interestId, followerId string) (err error) {
+	switch interestId {
+	case "fail":
+		err = ErrInternal
+	}
+	return
+}
+
+func (s mock) List(ctx context.Context, interestId string, limit uint32, cursor string) (page []string, err error) {
+	switch interestId {
+	case "fail":
+		err = ErrInternal
+	case "interest-deleted":
+		if cursor == "" {
+			page = []string{
+				"https://host.social/users/johndoe",
+				"https://fail.social/users/johndoe",
+			}
+		}
+	case "interest-expired":
+		if cursor == "" {
+			page = []string{
+				"https://host.social/users/johndoe",
+			}
+		}
+	}
+	return
+}
+
+func (s mock) ListInterests(ctx context.Context, limit uint32, cursor string) (page []string, err error) {
+	if cursor == "" {
+		page = []string{
+			"interest-active",
+			"interest-deleted",
+			"interest-expired",
+		}
+	}
+	return
+}
@@ -0,0 +1,193 @@
+package followers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type recFollower struct {
+	InterestId string    `bson:"interestId"`
+	FollowerId string    `bson:"followerId"`
+	Created    time.Time `bson:"created"`
+}
+
+const attrInterestId = "interestId"
+const attrFollowerId = "followerId"
+const attrCreated = "created"
+
+type storageMongo struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+var sortListAsc = bson.D{
+	{
+		Key:   attrFollowerId,
+		Value: 1,
+	},
+}
+var projList = bson.D{
+	{
+		Key:   attrFollowerId,
+		Value: 1,
+	},
+}
+
+func NewStorage(ctx context.Context, db *mongo.Database, cfgDb config.DbConfig) (s Storage, err error) {
+	var sm storageMongo
+	sm.db = db
+	sm.coll = db.Collection(cfgDb.Table.Followers.Name)
+	err = sm.ensureIndices(ctx)
+	if err == nil {
+		s = sm
+	}
+	return
+}
+
+func (sm storageMongo) ensureIndices(ctx context.Context) (err error) {
+	_, err = sm.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{
+					Key:   attrInterestId,
+					Value: 1,
+				},
+				{
+					Key:   attrFollowerId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(true),
+		},
+	})
+	return
+}
+
+// Close does nothing, the database client is shared and disconnected by the owner.
+func (sm storageMongo) Close() error {
+	return nil
+}
+
+func (sm storageMongo) Put(ctx context.Context, interestId, followerId string) (err error) {
+	q := bson.M{
+		attrInterestId: interestId,
+		attrFollowerId: followerId,
+	}
+	u := bson.M{
+		"$setOnInsert": recFollower{
+			InterestId: interestId,
+			FollowerId: followerId,
+			Created:    time.Now().UTC(),
+		},
+	}
+	_, err = sm.coll.UpdateOne(ctx, q, u, options.Update().SetUpsert(true))
+	err = decodeError(err)
+	return
+}
+
+func (sm storageMongo) Delete(ctx context.Context, interestId, followerId string) (err error) {
+	q := bson.M{
+		attrInterestId: interestId,
+		attrFollowerId: followerId,
+	}
+	_, err = sm.coll.DeleteOne(ctx, q)
+	err = decodeError(err)
+	return
+}
+
+func (sm storageMongo) List(ctx context.Context, interestId string, limit uint32, cursor string) (page []string, err error) {
+	q := bson.M{
+		attrInterestId: interestId,
+		attrFollowerId: bson.M{
+			"$gt": cursor,
+		},
+	}
+	optsList := options.
+		Find().
+		SetLimit(int64(limit)).
+		SetProjection(projList).
+		SetShowRecordID(false).
+		SetSort(sortListAsc)
+	var cur *mongo.Cursor
+	cur, err = sm.coll.Find(ctx, q, optsList)
+	if err == nil {
+		for cur.Next(ctx) {
+			var rec recFollower
+			err = errors.Join(err, cur.Decode(&rec))
+			if err == nil {
+				page = append(page, rec.FollowerId)
+			}
+		}
+	}
+	err = decodeError(err)
+	return
+}
+
+func (sm storageMongo) ListInterests(ctx context.Context, limit uint32, cursor string) (page []string, err error) {
+	pipeline := mongo.Pipeline{
+		{
+			{
+				Key: "$match",
+				Value: bson.M{
+					attrInterestId: bson.M{
+						"$gt": cursor,
+					},
+				},
+			},
+		},
+		{
+			{
+				Key: "$group",
+				Value: bson.M{
+					"_id": "$" + attrInterestId,
+				},
+			},
+		},
+		{
+			{
+				Key: "$sort",
+				Value: bson.M{
+					"_id": 1,
+				},
+			},
+		},
+		{
+			{
+				Key:   "$limit",
+				Value: int64(limit),
+			},
+		},
+	}
+	var cur *mongo.Cursor
+	cur, err = sm.coll.Aggregate(ctx, pipeline)
+	if err == nil {
+		for cur.Next(ctx) {
+			var rec struct {
+				Id string `bson:"_id"`
+			}
+			err = errors.Join(err, cur.Decode(&rec))
+			if err == nil {
+				page = append(page, rec.Id)
+			}
+		}
+	}
+	err = decodeError(err)
+	return
+}
+
+func decodeError(src error) (dst error) {
+	switch {
+	case src == nil:
+	default:
+		dst = fmt.Errorf("%w: %s", ErrInternal, src)
+	}
+	return
+}
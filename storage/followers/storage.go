@@ -0,0 +1,28 @@
+package followers
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Storage keeps the remote actors following the interest actors, so these may be notified when the interest is gone.
+type Storage interface {
+	io.Closer
+
+	// Put remembers the follower of the interest. Does nothing when the follower is already known.
+	Put(ctx context.Context, interestId, followerId string) (err error)
+
+	// Delete forgets the follower of the interest. Does nothing when the follower is not known.
+	Delete(ctx context.Context, interestId, followerId string) (err error)
+
+	// List returns the page of the interest's followers ordered by the id. The cursor is the last follower id from
+	// the previous page.
+	List(ctx context.Context, interestId string, limit uint32, cursor string) (page []string, err error)
+
+	// ListInterests returns the page of the distinct followed interest ids in the ascending order. The cursor is the
+	// last interest id from the previous page.
+	ListInterests(ctx context.Context, limit uint32, cursor string) (page []string, err error)
+}
+
+var ErrInternal = errors.New("followers storage internal failure")
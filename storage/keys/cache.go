@@ -0,0 +1,88 @@
+package keys
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"time"
+)
+
+type localCache struct {
+	stor  Storage
+	cache *expirable.LRU[string, model.Key]
+	lists *expirable.LRU[string, []model.Key]
+}
+
+const cacheListsSize = 16
+
+// CacheListsTtlMax is the longest time the listed keys are cached, so every replica sees the rotated instance keys
+// not later than this.
+const CacheListsTtlMax = 1 * time.Minute
+
+func NewLocalCache(stor Storage, size int, ttl time.Duration) Storage {
+	c := expirable.NewLRU[string, model.Key](size, nil, ttl)
+	l := expirable.NewLRU[string, []model.Key](cacheListsSize, nil, min(ttl, CacheListsTtlMax))
+	return localCache{
+		stor:  stor,
+		cache: c,
+		lists: l,
+	}
+}
+
+func (lc localCache) Close() error {
+	lc.cache.Purge()
+	lc.lists.Purge()
+	return lc.stor.Close()
+}
+
+func (lc localCache) Create(ctx context.Context, k model.Key) (err error) {
+	err = lc.stor.Create(ctx, k)
+	if err == nil {
+		lc.cache.Add(k.Id, k)
+		lc.lists.Purge()
+	}
+	return
+}
+
+func (lc localCache) Read(ctx context.Context, id string) (k model.Key, err error) {
+	var found bool
+	k, found = lc.cache.Get(id)
+	if !found {
+		k, err = lc.stor.Read(ctx, id)
+		if err == nil {
+			lc.cache.Add(id, k)
+		}
+	}
+	return
+}
+
+func (lc localCache) Update(ctx context.Context, k model.Key) (err error) {
+	err = lc.stor.Update(ctx, k)
+	lc.cache.Remove(k.Id)
+	lc.lists.Purge()
+	return
+}
+
+func (lc localCache) Delete(ctx context.Context, id string) (err error) {
+	err = lc.stor.Delete(ctx, id)
+	lc.cache.Remove(id)
+	lc.lists.Purge()
+	return
+}
+
+func (lc localCache) List(ctx context.Context, idPrefix string) (ks []model.Key, err error) {
+	var found bool
+	ks, found = lc.lists.Get(idPrefix)
+	if !found {
+		ks, err = lc.stor.List(ctx, idPrefix)
+		if err == nil {
+			lc.lists.Add(idPrefix, ks)
+		}
+	}
+	return
+}
+
+func (lc localCache) Announce(ctx context.Context, id, followerId string) (first bool, err error) {
+	first, err = lc.stor.Announce(ctx, id, followerId)
+	return
+}
@@ -0,0 +1,77 @@
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// kek is the key encryption key used to keep the private keys encrypted at rest.
+type kek struct {
+	aead cipher.AEAD
+}
+
+// kekPrefix marks the encrypted private key, the rest is the base64 encoded nonce followed by the sealed key.
+const kekPrefix = "kek:"
+const kekLen = 32
+
+var ErrKek = errors.New("invalid key encryption key")
+
+// newKek expects the base64 encoded 256-bit AES key.
+func newKek(src string) (k kek, err error) {
+	var raw []byte
+	raw, err = base64.StdEncoding.DecodeString(src)
+	if err == nil && len(raw) != kekLen {
+		err = fmt.Errorf("expected %d bytes, got %d", kekLen, len(raw))
+	}
+	var b cipher.Block
+	if err == nil {
+		b, err = aes.NewCipher(raw)
+	}
+	if err == nil {
+		k.aead, err = cipher.NewGCM(b)
+	}
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrKek, err)
+	}
+	return
+}
+
+// seal encrypts the private key bound to the key id, so the sealed value can not be moved to another key.
+func (k kek) seal(id, plain string) (sealed string, err error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err == nil {
+		sealed = kekPrefix + base64.StdEncoding.EncodeToString(k.aead.Seal(nonce, nonce, []byte(plain), []byte(id)))
+	}
+	return
+}
+
+// open decrypts the sealed private key. The legacy plain private key is returned as is.
+func (k kek) open(id, sealed string) (plain string, err error) {
+	if !strings.HasPrefix(sealed, kekPrefix) {
+		plain = sealed
+		return
+	}
+	var raw []byte
+	raw, err = base64.StdEncoding.DecodeString(sealed[len(kekPrefix):])
+	if err == nil && len(raw) < k.aead.NonceSize() {
+		err = errors.New("sealed value is too short")
+	}
+	var dec []byte
+	if err == nil {
+		ns := k.aead.NonceSize()
+		dec, err = k.aead.Open(nil, raw[:ns], raw[ns:], []byte(id))
+	}
+	switch err {
+	case nil:
+		plain = string(dec)
+	default:
+		err = fmt.Errorf("%w: failed to decrypt the key %s: %s", ErrKek, id, err)
+	}
+	return
+}
@@ -0,0 +1,310 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/config"
+	"github.com/awakari/int-activitypub/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
+	"time"
+)
+
+type recKey struct {
+	Id         string    `bson:"id"`
+	Public     string    `bson:"public"`
+	Private    string    `bson:"private"`
+	Created    time.Time `bson:"created"`
+	Activated  time.Time `bson:"activated,omitempty"`
+	Deprecated time.Time `bson:"deprecated,omitempty"`
+	Announced  time.Time `bson:"announcedAll,omitempty"`
+}
+
+type recAnnouncement struct {
+	KeyId      string    `bson:"keyId"`
+	FollowerId string    `bson:"followerId"`
+	Created    time.Time `bson:"created"`
+}
+
+const attrId = "id"
+const attrPublic = "public"
+const attrPrivate = "private"
+const attrCreated = "created"
+const attrActivated = "activated"
+const attrDeprecated = "deprecated"
+const attrAnnounced = "announcedAll"
+const attrKeyId = "keyId"
+const attrFollowerId = "followerId"
+
+type storageMongo struct {
+	db            *mongo.Database
+	coll          *mongo.Collection
+	collAnnounced *mongo.Collection
+	kek           kek
+}
+
+var optsRead = options.
+	FindOne().
+	SetShowRecordID(false).
+	SetProjection(projRead)
+var projRead = bson.D{
+	{
+		Key:   attrId,
+		Value: 1,
+	},
+	{
+		Key:   attrPublic,
+		Value: 1,
+	},
+	{
+		Key:   attrPrivate,
+		Value: 1,
+	},
+	{
+		Key:   attrCreated,
+		Value: 1,
+	},
+	{
+		Key:   attrActivated,
+		Value: 1,
+	},
+	{
+		Key:   attrDeprecated,
+		Value: 1,
+	},
+	{
+		Key:   attrAnnounced,
+		Value: 1,
+	},
+}
+var optsList = options.
+	Find().
+	SetShowRecordID(false).
+	SetProjection(projRead).
+	SetSort(sortList)
+var sortList = bson.D{
+	{
+		Key:   attrCreated,
+		Value: 1,
+	},
+}
+
+func NewStorage(ctx context.Context, db *mongo.Database, cfgDb config.DbConfig) (s Storage, err error) {
+	var sm storageMongo
+	sm.kek, err = newKek(cfgDb.Table.Keys.Kek)
+	if err == nil {
+		coll := db.Collection(cfgDb.Table.Keys.Name)
+		sm.db = db
+		sm.coll = coll
+		sm.collAnnounced = db.Collection(cfgDb.Table.Announcements.Name)
+		_, err = sm.ensureIndices(ctx)
+	}
+	if err == nil {
+		s = sm
+	}
+	return
+}
+
+func (sm storageMongo) ensureIndices(ctx context.Context) (names []string, err error) {
+	names, err = sm.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{
+					Key:   attrId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(true),
+		},
+	})
+	if err == nil {
+		_, err = sm.collAnnounced.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{
+						Key:   attrKeyId,
+						Value: 1,
+					},
+					{
+						Key:   attrFollowerId,
+						Value: 1,
+					},
+				},
+				Options: options.
+					Index().
+					SetUnique(true),
+			},
+		})
+	}
+	return
+}
+
+// Close does nothing, the database client is shared and disconnected by the owner.
+func (sm storageMongo) Close() error {
+	return nil
+}
+
+func (sm storageMongo) Create(ctx context.Context, k model.Key) (err error) {
+	rec := recKey{
+		Id:         k.Id,
+		Public:     k.Public,
+		Created:    k.Created,
+		Activated:  k.Activated,
+		Deprecated: k.Deprecated,
+		Announced:  k.Announced,
+	}
+	rec.Private, err = sm.kek.seal(k.Id, k.Private)
+	if err == nil {
+		_, err = sm.coll.InsertOne(ctx, rec)
+		err = decodeError(err, k.Id)
+	}
+	return
+}
+
+func (sm storageMongo) Read(ctx context.Context, id string) (k model.Key, err error) {
+	q := bson.M{
+		attrId: id,
+	}
+	var result *mongo.SingleResult
+	result = sm.coll.FindOne(ctx, q, optsRead)
+	err = result.Err()
+	var rec recKey
+	if err == nil {
+		err = result.Decode(&rec)
+	}
+	err = decodeError(err, id)
+	if err == nil {
+		k, err = rec.decode(sm.kek)
+	}
+	return
+}
+
+func (sm storageMongo) Update(ctx context.Context, k model.Key) (err error) {
+	q := bson.M{
+		attrId: k.Id,
+	}
+	u := bson.M{
+		"$set": bson.M{
+			attrActivated:  k.Activated,
+			attrDeprecated: k.Deprecated,
+			attrAnnounced:  k.Announced,
+		},
+	}
+	var result *mongo.UpdateResult
+	result, err = sm.coll.UpdateOne(ctx, q, u)
+	switch err {
+	case nil:
+		if result.MatchedCount < 1 {
+			err = fmt.Errorf("%w: %s", ErrNotFound, k.Id)
+		}
+	default:
+		err = decodeError(err, k.Id)
+	}
+	if err == nil && !k.Announced.IsZero() {
+		err = sm.forgetAnnounced(ctx, k.Id)
+	}
+	return
+}
+
+func (sm storageMongo) Delete(ctx context.Context, id string) (err error) {
+	q := bson.M{
+		attrId: id,
+	}
+	var result *mongo.DeleteResult
+	result, err = sm.coll.DeleteOne(ctx, q)
+	switch err {
+	case nil:
+		if result.DeletedCount < 1 {
+			err = fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+	default:
+		err = decodeError(err, id)
+	}
+	if err == nil {
+		err = sm.forgetAnnounced(ctx, id)
+	}
+	return
+}
+
+func (sm storageMongo) forgetAnnounced(ctx context.Context, id string) (err error) {
+	q := bson.M{
+		attrKeyId: id,
+	}
+	_, err = sm.collAnnounced.DeleteMany(ctx, q)
+	err = decodeError(err, id)
+	return
+}
+
+func (sm storageMongo) List(ctx context.Context, idPrefix string) (ks []model.Key, err error) {
+	q := bson.M{
+		attrId: bson.M{
+			"$regex": "^" + regexp.QuoteMeta(idPrefix),
+		},
+	}
+	var cur *mongo.Cursor
+	cur, err = sm.coll.Find(ctx, q, optsList)
+	if err == nil {
+		for cur.Next(ctx) {
+			var rec recKey
+			err = errors.Join(err, cur.Decode(&rec))
+			var k model.Key
+			if err == nil {
+				k, err = rec.decode(sm.kek)
+			}
+			if err == nil {
+				ks = append(ks, k)
+			}
+		}
+	}
+	if !errors.Is(err, ErrKek) {
+		err = decodeError(err, idPrefix)
+	}
+	return
+}
+
+func (sm storageMongo) Announce(ctx context.Context, id, followerId string) (first bool, err error) {
+	rec := recAnnouncement{
+		KeyId:      id,
+		FollowerId: followerId,
+		Created:    time.Now().UTC(),
+	}
+	_, err = sm.collAnnounced.InsertOne(ctx, rec)
+	switch {
+	case err == nil:
+		first = true
+	case mongo.IsDuplicateKeyError(err):
+		err = nil
+	default:
+		err = decodeError(err, id)
+	}
+	return
+}
+
+func (rec recKey) decode(kek kek) (k model.Key, err error) {
+	k.Id = rec.Id
+	k.Public = rec.Public
+	k.Private, err = kek.open(rec.Id, rec.Private)
+	k.Created = rec.Created
+	k.Activated = rec.Activated
+	k.Deprecated = rec.Deprecated
+	k.Announced = rec.Announced
+	return
+}
+
+func decodeError(src error, id string) (dst error) {
+	switch {
+	case src == nil:
+	case errors.Is(src, mongo.ErrNoDocuments):
+		dst = fmt.Errorf("%w: %s", ErrNotFound, id)
+	case mongo.IsDuplicateKeyError(src):
+		dst = fmt.Errorf("%w: %s", ErrConflict, id)
+	default:
+		dst = fmt.Errorf("%w: %s", ErrInternal, src)
+	}
+	return
+}
@@ -0,0 +1,32 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"github.com/awakari/int-activitypub/model"
+	"io"
+)
+
+type Storage interface {
+	io.Closer
+	Create(ctx context.Context, k model.Key) (err error)
+	Read(ctx context.Context, id string) (k model.Key, err error)
+
+	// Update sets the key activation, deprecation and announcement times.
+	// The key announced to all the followers forgets the followers it was announced to before.
+	Update(ctx context.Context, k model.Key) (err error)
+
+	Delete(ctx context.Context, id string) (err error)
+
+	// List returns all keys having the id starting with the specified prefix, ordered by the creation time.
+	List(ctx context.Context, idPrefix string) (ks []model.Key, err error)
+
+	// Announce marks the key as announced to the follower.
+	// Returns true only when the key has not been announced to the follower before.
+	// The announcements are kept apart from the key until it's announced to all the followers.
+	Announce(ctx context.Context, id, followerId string) (first bool, err error)
+}
+
+var ErrInternal = errors.New("key storage internal failure")
+var ErrConflict = errors.New("key already exists")
+var ErrNotFound = errors.New("key not found")
@@ -38,6 +38,12 @@ func (s mock) Read(ctx context.Context, addr string) (a model.Source, err error)
 		a.Type = "Person"
 		a.Summary = "yohoho"
 		a.Accepted = true
+	case "https://host.social/users/blocked":
+		a.ActorId = addr
+		a.UserId = "user2"
+		a.GroupId = "group1"
+		a.Name = "John Doe"
+		a.Type = "Person"
 	default:
 		err = ErrNotFound
 	}
@@ -0,0 +1,181 @@
+package moderation
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"time"
+)
+
+type mock struct {
+}
+
+func NewStorageMock() Storage {
+	return mock{}
+}
+
+func (s mock) Close() error {
+	return nil
+}
+
+func (s mock) CreateReport(ctx context.Context, r model.Report) (id string, err error) {
+	switch r.ActivityId {
+	case "https://fail.social/flags/1":
+		err = ErrInternal
+	case "https://host.social/flags/conflict":
+		err = ErrConflict
+	default:
+		id = "report0"
+	}
+	return
+}
+
+func (s mock) ReadReport(ctx context.Context, id string) (r model.Report, err error) {
+	switch id {
+	case "fail":
+		err = ErrInternal
+	case "report_interest":
+		r = model.Report{
+			Id:         id,
+			ActivityId: "https://host.social/flags/1",
+			ReporterId: "https://host.social/actor",
+			InterestId: "interest0",
+			Objects: []string{
+				"https://test.social/actor/interest0",
+			},
+			Created: time.Date(2024, 11, 12, 13, 14, 15, 0, time.UTC),
+		}
+	case "report_notes":
+		r = model.Report{
+			Id:         id,
+			ActivityId: "https://host.social/flags/2",
+			ReporterId: "https://host.social/actor",
+			InterestId: "interest0",
+			Objects: []string{
+				"https://test.social/note1",
+				"https://test.social/note2",
+				"https://test.social/note_unknown",
+			},
+			Created: time.Date(2024, 11, 12, 13, 14, 15, 0, time.UTC),
+		}
+	case "report_unknown":
+		r = model.Report{
+			Id:         id,
+			ActivityId: "https://host.social/flags/3",
+			ReporterId: "https://host.social/actor",
+			Objects: []string{
+				"https://test.social/note_unknown",
+			},
+		}
+	default:
+		err = ErrNotFound
+	}
+	return
+}
+
+func (s mock) ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error) {
+	switch filter.InterestId {
+	case "fail":
+		err = ErrInternal
+	case "interest0":
+		page = []model.Report{
+			{
+				Id:         "report_interest",
+				ActivityId: "https://host.social/flags/1",
+				ReporterId: "https://host.social/actor",
+				InterestId: "interest0",
+				Objects: []string{
+					"https://test.social/actor/interest0",
+				},
+				Content: "spam",
+				Created: time.Date(2024, 11, 12, 13, 14, 15, 0, time.UTC),
+			},
+		}
+	}
+	return
+}
+
+func (s mock) ResolveReport(ctx context.Context, id string, action model.ReportAction, t time.Time) (err error) {
+	switch id {
+	case "fail_resolve":
+		err = ErrInternal
+	}
+	return
+}
+
+func (s mock) SetSuspended(ctx context.Context, interestId string, suspended bool) (err error) {
+	switch interestId {
+	case "fail":
+		err = ErrInternal
+	}
+	return
+}
+
+func (s mock) Suspended(ctx context.Context, interestId string) (suspended bool, err error) {
+	switch interestId {
+	case "fail":
+		err = ErrInternal
+	case "suspended":
+		suspended = true
+	}
+	return
+}
+
+func (s mock) AddDeliveries(ctx context.Context, ds []model.Delivery) (err error) {
+	for _, d := range ds {
+		switch d.NoteId {
+		case "fail":
+			err = ErrInternal
+		}
+	}
+	return
+}
+
+func (s mock) ListDeliveries(ctx context.Context, noteId string) (ds []model.Delivery, err error) {
+	switch noteId {
+	case "https://test.social/announce1", "https://origin.social/users/jane/statuses/1":
+		ds = []model.Delivery{
+			{
+				NoteId:     "https://test.social/announce1",
+				InterestId: "interest0",
+				Inbox:      "https://host.social/inbox",
+				Announced:  "https://origin.social/users/jane/statuses/1",
+			},
+		}
+	case "https://test.social/note3":
+		ds = []model.Delivery{
+			{
+				NoteId:     noteId,
+				InterestId: "interest0",
+				Inbox:      "https://host.social/inbox",
+				Retracted:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				NoteId:     noteId,
+				InterestId: "interest0",
+				Inbox:      "https://other.social/users/jane/inbox",
+			},
+		}
+	case "https://test.social/note1", "https://test.social/note2":
+		ds = []model.Delivery{
+			{
+				NoteId:     noteId,
+				InterestId: "interest0",
+				Inbox:      "https://host.social/inbox",
+			},
+			{
+				NoteId:     noteId,
+				InterestId: "interest0",
+				Inbox:      "https://other.social/users/jane/inbox",
+			},
+		}
+	}
+	return
+}
+
+func (s mock) SetDeliveryRetracted(ctx context.Context, noteId, inbox string, t time.Time) (err error) {
+	switch noteId {
+	case "fail":
+		err = ErrInternal
+	}
+	return
+}
@@ -0,0 +1,430 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/config"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/segmentio/ksuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type recReport struct {
+	Id         string    `bson:"id"`
+	ActivityId string    `bson:"activityId"`
+	ReporterId string    `bson:"reporterId"`
+	InterestId string    `bson:"interestId,omitempty"`
+	Objects    []string  `bson:"objects"`
+	Content    string    `bson:"content,omitempty"`
+	Created    time.Time `bson:"created"`
+	Resolution string    `bson:"resolution,omitempty"`
+	Resolved   time.Time `bson:"resolved,omitempty"`
+}
+
+type recSuspension struct {
+	InterestId string    `bson:"interestId"`
+	Created    time.Time `bson:"created"`
+}
+
+type recDelivery struct {
+	NoteId     string    `bson:"noteId"`
+	InterestId string    `bson:"interestId"`
+	Inbox      string    `bson:"inbox"`
+	Announced  string    `bson:"announced,omitempty"`
+	Created    time.Time `bson:"created"`
+	Retracted  time.Time `bson:"retracted,omitempty"`
+}
+
+const attrId = "id"
+const attrActivityId = "activityId"
+const attrReporterId = "reporterId"
+const attrInterestId = "interestId"
+const attrCreated = "created"
+const attrResolution = "resolution"
+const attrResolved = "resolved"
+const attrNoteId = "noteId"
+const attrInbox = "inbox"
+const attrAnnounced = "announced"
+const attrRetracted = "retracted"
+const codeDuplicateKey = 11000
+
+type storageMongo struct {
+	db              *mongo.Database
+	collReports     *mongo.Collection
+	collSuspensions *mongo.Collection
+	collDeliveries  *mongo.Collection
+}
+
+var sortListAsc = bson.D{
+	{
+		Key:   attrId,
+		Value: 1,
+	},
+}
+
+func NewStorage(ctx context.Context, db *mongo.Database, cfgDb config.DbConfig) (s Storage, err error) {
+	var sm storageMongo
+	sm.db = db
+	sm.collReports = db.Collection(cfgDb.Table.Reports.Name)
+	sm.collSuspensions = db.Collection(cfgDb.Table.Suspensions.Name)
+	sm.collDeliveries = db.Collection(cfgDb.Table.Deliveries.Name)
+	err = sm.ensureIndices(ctx, cfgDb.Table.Deliveries.RetentionPeriod)
+	if err == nil {
+		s = sm
+	}
+	return
+}
+
+func (sm storageMongo) ensureIndices(ctx context.Context, retentionPeriodDeliveries time.Duration) (err error) {
+	_, err = sm.collReports.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{
+					Key:   attrId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{
+					Key:   attrActivityId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{
+					Key:   attrInterestId,
+					Value: 1,
+				},
+				{
+					Key:   attrId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetSparse(true).
+				SetUnique(false),
+		},
+	})
+	if err == nil {
+		_, err = sm.collSuspensions.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{
+				{
+					Key:   attrInterestId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(true),
+		})
+	}
+	if err == nil {
+		_, err = sm.collDeliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{
+				Keys: bson.D{
+					{
+						Key:   attrNoteId,
+						Value: 1,
+					},
+					{
+						Key:   attrInbox,
+						Value: 1,
+					},
+				},
+				Options: options.
+					Index().
+					SetUnique(true),
+			},
+			{
+				Keys: bson.D{
+					{
+						Key:   attrAnnounced,
+						Value: 1,
+					},
+				},
+				Options: options.
+					Index().
+					SetSparse(true).
+					SetUnique(false),
+			},
+			{
+				Keys: bson.D{
+					{
+						Key:   attrCreated,
+						Value: 1,
+					},
+				},
+				Options: options.
+					Index().
+					SetExpireAfterSeconds(int32(retentionPeriodDeliveries / time.Second)).
+					SetUnique(false),
+			},
+		})
+	}
+	return
+}
+
+// Close does nothing, the database client is shared and disconnected by the owner.
+func (sm storageMongo) Close() error {
+	return nil
+}
+
+func (sm storageMongo) CreateReport(ctx context.Context, r model.Report) (id string, err error) {
+	t := r.Created
+	if t.IsZero() {
+		t = time.Now().UTC()
+	}
+	var kid ksuid.KSUID
+	kid, err = ksuid.NewRandomWithTime(t)
+	if err == nil {
+		rec := recReport{
+			Id:         kid.String(),
+			ActivityId: r.ActivityId,
+			ReporterId: r.ReporterId,
+			InterestId: r.InterestId,
+			Objects:    r.Objects,
+			Content:    r.Content,
+			Created:    t,
+		}
+		_, err = sm.collReports.InsertOne(ctx, rec)
+	}
+	if err == nil {
+		id = kid.String()
+	}
+	err = decodeError(err, r.ActivityId)
+	return
+}
+
+func (sm storageMongo) ReadReport(ctx context.Context, id string) (r model.Report, err error) {
+	q := bson.M{
+		attrId: id,
+	}
+	result := sm.collReports.FindOne(ctx, q)
+	err = result.Err()
+	var rec recReport
+	if err == nil {
+		err = result.Decode(&rec)
+	}
+	if err == nil {
+		r = rec.decode()
+	}
+	err = decodeError(err, id)
+	return
+}
+
+func (sm storageMongo) ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error) {
+	q := bson.M{
+		attrId: bson.M{
+			"$gt": cursor,
+		},
+	}
+	if filter.InterestId != "" {
+		q[attrInterestId] = filter.InterestId
+	}
+	if filter.ReporterId != "" {
+		q[attrReporterId] = filter.ReporterId
+	}
+	if filter.Unresolved {
+		q[attrResolution] = bson.M{
+			"$exists": false,
+		}
+	}
+	optsList := options.
+		Find().
+		SetLimit(int64(limit)).
+		SetShowRecordID(false).
+		SetSort(sortListAsc)
+	var cur *mongo.Cursor
+	cur, err = sm.collReports.Find(ctx, q, optsList)
+	if err == nil {
+		for cur.Next(ctx) {
+			var rec recReport
+			err = errors.Join(err, cur.Decode(&rec))
+			if err == nil {
+				page = append(page, rec.decode())
+			}
+		}
+	}
+	err = decodeError(err, cursor)
+	return
+}
+
+func (sm storageMongo) ResolveReport(ctx context.Context, id string, action model.ReportAction, t time.Time) (err error) {
+	q := bson.M{
+		attrId: id,
+	}
+	u := bson.M{
+		"$set": bson.M{
+			attrResolution: string(action),
+			attrResolved:   t,
+		},
+	}
+	var result *mongo.UpdateResult
+	result, err = sm.collReports.UpdateOne(ctx, q, u)
+	switch err {
+	case nil:
+		if result.MatchedCount < 1 {
+			err = fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+	default:
+		err = decodeError(err, id)
+	}
+	return
+}
+
+func (sm storageMongo) SetSuspended(ctx context.Context, interestId string, suspended bool) (err error) {
+	q := bson.M{
+		attrInterestId: interestId,
+	}
+	switch suspended {
+	case true:
+		u := bson.M{
+			"$setOnInsert": recSuspension{
+				InterestId: interestId,
+				Created:    time.Now().UTC(),
+			},
+		}
+		_, err = sm.collSuspensions.UpdateOne(ctx, q, u, options.Update().SetUpsert(true))
+	default:
+		_, err = sm.collSuspensions.DeleteOne(ctx, q)
+	}
+	err = decodeError(err, interestId)
+	return
+}
+
+func (sm storageMongo) Suspended(ctx context.Context, interestId string) (suspended bool, err error) {
+	q := bson.M{
+		attrInterestId: interestId,
+	}
+	var count int64
+	count, err = sm.collSuspensions.CountDocuments(ctx, q)
+	suspended = count > 0
+	err = decodeError(err, interestId)
+	return
+}
+
+func (sm storageMongo) AddDeliveries(ctx context.Context, ds []model.Delivery) (err error) {
+	if len(ds) == 0 {
+		return
+	}
+	recs := make([]any, len(ds))
+	for i, d := range ds {
+		recs[i] = recDelivery{
+			NoteId:     d.NoteId,
+			InterestId: d.InterestId,
+			Inbox:      d.Inbox,
+			Announced:  d.Announced,
+			Created:    d.Created,
+		}
+	}
+	_, err = sm.collDeliveries.InsertMany(ctx, recs, options.InsertMany().SetOrdered(false))
+	if duplicatesOnly(err) {
+		// delivered again to the same inbox
+		err = nil
+	}
+	err = decodeError(err, ds[0].NoteId)
+	return
+}
+
+func duplicatesOnly(err error) (dup bool) {
+	var errBulk mongo.BulkWriteException
+	if errors.As(err, &errBulk) && errBulk.WriteConcernError == nil && len(errBulk.WriteErrors) > 0 {
+		dup = true
+		for _, errWrite := range errBulk.WriteErrors {
+			if errWrite.Code != codeDuplicateKey {
+				dup = false
+				break
+			}
+		}
+	}
+	return
+}
+
+func (sm storageMongo) ListDeliveries(ctx context.Context, noteId string) (ds []model.Delivery, err error) {
+	q := bson.M{
+		"$or": bson.A{
+			bson.M{
+				attrNoteId: noteId,
+			},
+			bson.M{
+				attrAnnounced: noteId,
+			},
+		},
+	}
+	var cur *mongo.Cursor
+	cur, err = sm.collDeliveries.Find(ctx, q)
+	if err == nil {
+		for cur.Next(ctx) {
+			var rec recDelivery
+			err = errors.Join(err, cur.Decode(&rec))
+			if err == nil {
+				ds = append(ds, model.Delivery{
+					NoteId:     rec.NoteId,
+					InterestId: rec.InterestId,
+					Inbox:      rec.Inbox,
+					Announced:  rec.Announced,
+					Created:    rec.Created,
+					Retracted:  rec.Retracted,
+				})
+			}
+		}
+	}
+	err = decodeError(err, noteId)
+	return
+}
+
+func (sm storageMongo) SetDeliveryRetracted(ctx context.Context, noteId, inbox string, t time.Time) (err error) {
+	q := bson.M{
+		attrNoteId: noteId,
+		attrInbox:  inbox,
+	}
+	u := bson.M{
+		"$set": bson.M{
+			attrRetracted: t,
+		},
+	}
+	_, err = sm.collDeliveries.UpdateMany(ctx, q, u)
+	err = decodeError(err, noteId)
+	return
+}
+
+func (rec recReport) decode() (r model.Report) {
+	r.Id = rec.Id
+	r.ActivityId = rec.ActivityId
+	r.ReporterId = rec.ReporterId
+	r.InterestId = rec.InterestId
+	r.Objects = rec.Objects
+	r.Content = rec.Content
+	r.Created = rec.Created
+	r.Resolution = model.ReportAction(rec.Resolution)
+	r.Resolved = rec.Resolved
+	return
+}
+
+func decodeError(src error, id string) (dst error) {
+	switch {
+	case src == nil:
+	case errors.Is(src, mongo.ErrNoDocuments):
+		dst = fmt.Errorf("%w: %s", ErrNotFound, id)
+	case mongo.IsDuplicateKeyError(src):
+		dst = fmt.Errorf("%w: %s", ErrConflict, id)
+	default:
+		dst = fmt.Errorf("%w: %s", ErrInternal, src)
+	}
+	return
+}
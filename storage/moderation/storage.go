@@ -0,0 +1,44 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"github.com/awakari/int-activitypub/model"
+	"io"
+	"time"
+)
+
+type Storage interface {
+	io.Closer
+
+	// CreateReport stores the report and returns the assigned time sortable id.
+	// Returns ErrConflict when the report for the same Flag activity exists.
+	CreateReport(ctx context.Context, r model.Report) (id string, err error)
+
+	ReadReport(ctx context.Context, id string) (r model.Report, err error)
+
+	// ListReports returns the page of the reports ordered by the id, the cursor is the last id from the previous page.
+	ListReports(ctx context.Context, filter model.ReportFilter, limit uint32, cursor string) (page []model.Report, err error)
+
+	// ResolveReport sets the action taken on the report.
+	ResolveReport(ctx context.Context, id string, action model.ReportAction, t time.Time) (err error)
+
+	SetSuspended(ctx context.Context, interestId string, suspended bool) (err error)
+
+	Suspended(ctx context.Context, interestId string) (suspended bool, err error)
+
+	// AddDeliveries records the delivered notes at once. The deliveries are removed after the retention period.
+	// The delivery already recorded for the same note and inbox is skipped.
+	AddDeliveries(ctx context.Context, ds []model.Delivery) (err error)
+
+	// ListDeliveries returns the deliveries of the note, or the deliveries of the Announce when the noteId is the
+	// boosted original object IRI.
+	ListDeliveries(ctx context.Context, noteId string) (ds []model.Delivery, err error)
+
+	// SetDeliveryRetracted marks the note delivered to the inbox as retracted, so it's not retracted again.
+	SetDeliveryRetracted(ctx context.Context, noteId, inbox string, t time.Time) (err error)
+}
+
+var ErrInternal = errors.New("moderation storage internal failure")
+var ErrConflict = errors.New("report already exists")
+var ErrNotFound = errors.New("report not found")
@@ -43,7 +43,6 @@ const attrTerm = "term"
 const attrErr = "err"
 
 type storageMongo struct {
-	conn *mongo.Client
 	db   *mongo.Database
 	coll *mongo.Collection
 }
@@ -122,7 +121,8 @@ var projList = bson.D{
 	},
 }
 
-func NewStorage(ctx context.Context, cfgDb config.DbConfig) (s Storage, err error) {
+// Connect creates the database client shared by all the storages. The caller disconnects it when done.
+func Connect(ctx context.Context, cfgDb config.DbConfig) (db *mongo.Database, err error) {
 	clientOpts := options.
 		Client().
 		ApplyURI(cfgDb.Uri).
@@ -138,16 +138,20 @@ func NewStorage(ctx context.Context, cfgDb config.DbConfig) (s Storage, err erro
 		}
 		clientOpts = clientOpts.SetAuth(auth)
 	}
-	conn, err := mongo.Connect(ctx, clientOpts)
-	var sm storageMongo
+	var conn *mongo.Client
+	conn, err = mongo.Connect(ctx, clientOpts)
 	if err == nil {
-		db := conn.Database(cfgDb.Name)
-		coll := db.Collection(cfgDb.Table.Following.Name)
-		sm.conn = conn
-		sm.db = db
-		sm.coll = coll
-		_, err = sm.ensureIndices(ctx, cfgDb.Table.Following.RetentionPeriod)
+		db = conn.Database(cfgDb.Name)
 	}
+	return
+}
+
+func NewStorage(ctx context.Context, db *mongo.Database, cfgDb config.DbConfig) (s Storage, err error) {
+	var sm storageMongo
+	coll := db.Collection(cfgDb.Table.Following.Name)
+	sm.db = db
+	sm.coll = coll
+	_, err = sm.ensureIndices(ctx, cfgDb.Table.Following.RetentionPeriod)
 	if err == nil {
 		s = sm
 	}
@@ -194,8 +198,9 @@ func (sm storageMongo) ensureIndices(ctx context.Context, retentionPeriod time.D
 	})
 }
 
+// Close does nothing, the database client is shared and disconnected by the owner.
 func (sm storageMongo) Close() error {
-	return sm.conn.Disconnect(context.TODO())
+	return nil
 }
 
 func (sm storageMongo) Create(ctx context.Context, src model.Source) (err error) {
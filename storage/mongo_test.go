@@ -27,7 +27,10 @@ func TestNewStorage(t *testing.T) {
 	dbCfg.Tls.Insecure = true
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	defer cancel()
-	s, err := NewStorage(ctx, dbCfg)
+	db, err := Connect(ctx, dbCfg)
+	require.Nil(t, err)
+	defer db.Client().Disconnect(ctx)
+	s, err := NewStorage(ctx, db, dbCfg)
 	assert.Nil(t, err)
 	assert.NotNil(t, s)
 	//
@@ -51,7 +54,10 @@ func TestStorageMongo_Create(t *testing.T) {
 	dbCfg.Tls.Insecure = true
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
 	defer cancel()
-	s, err := NewStorage(ctx, dbCfg)
+	db, err := Connect(ctx, dbCfg)
+	require.Nil(t, err)
+	defer db.Client().Disconnect(ctx)
+	s, err := NewStorage(ctx, db, dbCfg)
 	require.Nil(t, err)
 	assert.NotNil(t, s)
 	//
@@ -105,7 +111,10 @@ func TestStorageMongo_Read(t *testing.T) {
 	dbCfg.Tls.Insecure = true
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
 	defer cancel()
-	s, err := NewStorage(ctx, dbCfg)
+	db, err := Connect(ctx, dbCfg)
+	require.Nil(t, err)
+	defer db.Client().Disconnect(ctx)
+	s, err := NewStorage(ctx, db, dbCfg)
 	require.Nil(t, err)
 	assert.NotNil(t, s)
 	//
@@ -167,7 +176,10 @@ func TestStorageMongo_Update(t *testing.T) {
 	dbCfg.Tls.Insecure = true
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
 	defer cancel()
-	s, err := NewStorage(ctx, dbCfg)
+	db, err := Connect(ctx, dbCfg)
+	require.Nil(t, err)
+	defer db.Client().Disconnect(ctx)
+	s, err := NewStorage(ctx, db, dbCfg)
 	require.Nil(t, err)
 	assert.NotNil(t, s)
 	//
@@ -232,7 +244,10 @@ func TestStorageMongo_Delete(t *testing.T) {
 	dbCfg.Tls.Insecure = true
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
 	defer cancel()
-	s, err := NewStorage(ctx, dbCfg)
+	db, err := Connect(ctx, dbCfg)
+	require.Nil(t, err)
+	defer db.Client().Disconnect(ctx)
+	s, err := NewStorage(ctx, db, dbCfg)
 	require.Nil(t, err)
 	assert.NotNil(t, s)
 	//
@@ -293,7 +308,10 @@ func TestStorageMongo_ListUrls(t *testing.T) {
 	dbCfg.Tls.Insecure = true
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
 	defer cancel()
-	s, err := NewStorage(ctx, dbCfg)
+	db, err := Connect(ctx, dbCfg)
+	require.Nil(t, err)
+	defer db.Client().Disconnect(ctx)
+	s, err := NewStorage(ctx, db, dbCfg)
 	require.Nil(t, err)
 	assert.NotNil(t, s)
 	//
@@ -0,0 +1,48 @@
+package polls
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"time"
+)
+
+// localCache remembers the poll states stored recently, so the same poll delivered to many followers is stored once.
+// The changed poll, e.g. closed, is stored again.
+type localCache struct {
+	stor  Storage
+	cache *expirable.LRU[string, struct{}]
+}
+
+func NewLocalCache(stor Storage, size int, ttl time.Duration) Storage {
+	c := expirable.NewLRU[string, struct{}](size, nil, ttl)
+	return localCache{
+		stor:  stor,
+		cache: c,
+	}
+}
+
+func (lc localCache) Close() error {
+	lc.cache.Purge()
+	return lc.stor.Close()
+}
+
+func (lc localCache) Put(ctx context.Context, p model.PublishedPoll) (err error) {
+	h := sha256.Sum256([]byte(p.Data))
+	k := p.Id + " " + hex.EncodeToString(h[:16])
+	if lc.cache.Contains(k) {
+		return
+	}
+	err = lc.stor.Put(ctx, p)
+	if err == nil {
+		lc.cache.Add(k, struct{}{})
+	}
+	return
+}
+
+func (lc localCache) Read(ctx context.Context, id string) (p model.PublishedPoll, err error) {
+	p, err = lc.stor.Read(ctx, id)
+	return
+}
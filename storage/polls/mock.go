@@ -0,0 +1,42 @@
+package polls
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"time"
+)
+
+type mock struct {
+}
+
+func NewStorageMock() Storage {
+	return mock{}
+}
+
+func (s mock) Close() error {
+	return nil
+}
+
+func (s mock) Put(ctx context.Context, p model.PublishedPoll) (err error) {
+	switch p.Id {
+	case "fail":
+		err = ErrInternal
+	}
+	return
+}
+
+func (s mock) Read(ctx context.Context, id string) (p model.PublishedPoll, err error) {
+	switch id {
+	case "fail":
+		err = ErrInternal
+	case "https://test.social/poll/58fbd4d42a1f8a1b2fd2c6d4b5a5a1c3":
+		p = model.PublishedPoll{
+			Id:      id,
+			Data:    `{"id":"https://test.social/poll/58fbd4d42a1f8a1b2fd2c6d4b5a5a1c3","type":"Question","content":"Which one?","oneOf":[{"type":"Note","name":"yes","replies":{"type":"Collection","totalItems":1}}],"to":["https://www.w3.org/ns/activitystreams#Public"]}`,
+			Updated: time.Date(2024, 11, 12, 13, 14, 15, 0, time.UTC),
+		}
+	default:
+		err = ErrNotFound
+	}
+	return
+}
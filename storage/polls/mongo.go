@@ -0,0 +1,130 @@
+package polls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/config"
+	"github.com/awakari/int-activitypub/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type recPoll struct {
+	Id      string    `bson:"id"`
+	Data    string    `bson:"data"`
+	Updated time.Time `bson:"updated"`
+}
+
+const attrId = "id"
+const attrData = "data"
+const attrUpdated = "updated"
+
+type storageMongo struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+var projRead = bson.D{
+	{
+		Key:   attrData,
+		Value: 1,
+	},
+	{
+		Key:   attrUpdated,
+		Value: 1,
+	},
+}
+
+func NewStorage(ctx context.Context, db *mongo.Database, cfgDb config.DbConfig) (s Storage, err error) {
+	var sm storageMongo
+	sm.db = db
+	sm.coll = db.Collection(cfgDb.Table.Polls.Name)
+	err = sm.ensureIndices(ctx, cfgDb.Table.Polls.RetentionPeriod)
+	if err == nil {
+		s = sm
+	}
+	return
+}
+
+func (sm storageMongo) ensureIndices(ctx context.Context, retentionPeriod time.Duration) (err error) {
+	_, err = sm.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{
+					Key:   attrId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{
+					Key:   attrUpdated,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetExpireAfterSeconds(int32(retentionPeriod / time.Second)).
+				SetUnique(false),
+		},
+	})
+	return
+}
+
+// Close does nothing, the database client is shared and disconnected by the owner.
+func (sm storageMongo) Close() error {
+	return nil
+}
+
+func (sm storageMongo) Put(ctx context.Context, p model.PublishedPoll) (err error) {
+	rec := recPoll{
+		Id:      p.Id,
+		Data:    p.Data,
+		Updated: p.Updated,
+	}
+	q := bson.M{
+		attrId: p.Id,
+	}
+	_, err = sm.coll.ReplaceOne(ctx, q, rec, options.Replace().SetUpsert(true))
+	err = decodeError(err, p.Id)
+	return
+}
+
+func (sm storageMongo) Read(ctx context.Context, id string) (p model.PublishedPoll, err error) {
+	q := bson.M{
+		attrId: id,
+	}
+	optsRead := options.
+		FindOne().
+		SetShowRecordID(false).
+		SetProjection(projRead)
+	var rec recPoll
+	err = sm.coll.FindOne(ctx, q, optsRead).Decode(&rec)
+	if err == nil {
+		p = model.PublishedPoll{
+			Id:      id,
+			Data:    rec.Data,
+			Updated: rec.Updated,
+		}
+	}
+	err = decodeError(err, id)
+	return
+}
+
+func decodeError(src error, id string) (dst error) {
+	switch {
+	case src == nil:
+	case errors.Is(src, mongo.ErrNoDocuments):
+		dst = fmt.Errorf("%w: %s", ErrNotFound, id)
+	default:
+		dst = fmt.Errorf("%w: %s", ErrInternal, src)
+	}
+	return
+}
@@ -0,0 +1,23 @@
+package polls
+
+import (
+	"context"
+	"errors"
+	"github.com/awakari/int-activitypub/model"
+	"io"
+)
+
+// Storage keeps the recent public polls, so these are resolvable by their ids. The polls are removed after the
+// retention period since the last update.
+type Storage interface {
+	io.Closer
+
+	// Put stores the poll. Replaces the same poll stored before, e.g. the closed poll replaces the open one.
+	Put(ctx context.Context, p model.PublishedPoll) (err error)
+
+	// Read returns the poll by its id.
+	Read(ctx context.Context, id string) (p model.PublishedPoll, err error)
+}
+
+var ErrNotFound = errors.New("poll not found")
+var ErrInternal = errors.New("polls storage internal failure")
@@ -0,0 +1,43 @@
+package tags
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"time"
+)
+
+// localCache remembers the notes stored recently, so the same note delivered to many followers is stored once.
+type localCache struct {
+	stor  Storage
+	cache *expirable.LRU[string, struct{}]
+}
+
+func NewLocalCache(stor Storage, size int, ttl time.Duration) Storage {
+	c := expirable.NewLRU[string, struct{}](size, nil, ttl)
+	return localCache{
+		stor:  stor,
+		cache: c,
+	}
+}
+
+func (lc localCache) Close() error {
+	lc.cache.Purge()
+	return lc.stor.Close()
+}
+
+func (lc localCache) Put(ctx context.Context, note model.TaggedNote, tags []string) (err error) {
+	if lc.cache.Contains(note.NoteId) {
+		return
+	}
+	err = lc.stor.Put(ctx, note, tags)
+	if err == nil {
+		lc.cache.Add(note.NoteId, struct{}{})
+	}
+	return
+}
+
+func (lc localCache) List(ctx context.Context, tag string, limit uint32) (notes []model.TaggedNote, err error) {
+	notes, err = lc.stor.List(ctx, tag, limit)
+	return
+}
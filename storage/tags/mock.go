@@ -0,0 +1,43 @@
+package tags
+
+import (
+	"context"
+	"github.com/awakari/int-activitypub/model"
+	"time"
+)
+
+type mock struct {
+}
+
+func NewStorageMock() Storage {
+	return mock{}
+}
+
+func (s mock) Close() error {
+	return nil
+}
+
+func (s mock) Put(ctx context.Context, note model.TaggedNote, tags []string) (err error) {
+	switch note.InterestId {
+	case "fail":
+		err = ErrInternal
+	}
+	return
+}
+
+func (s mock) List(ctx context.Context, tag string, limit uint32) (notes []model.TaggedNote, err error) {
+	switch tag {
+	case "fail":
+		err = ErrInternal
+	case "golang":
+		notes = []model.TaggedNote{
+			{
+				NoteId:     "https://test.social/2jrVcFeXfGNcExKHLCcrrXBYyLJ",
+				InterestId: "interest0",
+				Data:       `{"id":"https://test.social/2jrVcFeXfGNcExKHLCcrrXBYyLJ","type":"Note","content":"Go 1.25 is released","to":["https://www.w3.org/ns/activitystreams#Public"]}`,
+				Created:    time.Date(2024, 11, 12, 13, 14, 15, 0, time.UTC),
+			},
+		}
+	}
+	return
+}
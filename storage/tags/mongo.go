@@ -0,0 +1,166 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/awakari/int-activitypub/config"
+	"github.com/awakari/int-activitypub/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type recNote struct {
+	Tag        string    `bson:"tag"`
+	NoteId     string    `bson:"noteId"`
+	InterestId string    `bson:"interestId"`
+	Data       string    `bson:"data"`
+	Created    time.Time `bson:"created"`
+}
+
+const attrTag = "tag"
+const attrNoteId = "noteId"
+const attrCreated = "created"
+
+type storageMongo struct {
+	db   *mongo.Database
+	coll *mongo.Collection
+}
+
+var sortListDesc = bson.D{
+	{
+		Key:   attrCreated,
+		Value: -1,
+	},
+}
+
+func NewStorage(ctx context.Context, db *mongo.Database, cfgDb config.DbConfig) (s Storage, err error) {
+	var sm storageMongo
+	sm.db = db
+	sm.coll = db.Collection(cfgDb.Table.Tags.Name)
+	err = sm.ensureIndices(ctx, cfgDb.Table.Tags.RetentionPeriod)
+	if err == nil {
+		s = sm
+	}
+	return
+}
+
+func (sm storageMongo) ensureIndices(ctx context.Context, retentionPeriod time.Duration) (err error) {
+	_, err = sm.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{
+					Key:   attrTag,
+					Value: 1,
+				},
+				{
+					Key:   attrNoteId,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{
+					Key:   attrTag,
+					Value: 1,
+				},
+				{
+					Key:   attrCreated,
+					Value: -1,
+				},
+			},
+			Options: options.
+				Index().
+				SetUnique(false),
+		},
+		{
+			Keys: bson.D{
+				{
+					Key:   attrCreated,
+					Value: 1,
+				},
+			},
+			Options: options.
+				Index().
+				SetExpireAfterSeconds(int32(retentionPeriod / time.Second)).
+				SetUnique(false),
+		},
+	})
+	return
+}
+
+// Close does nothing, the database client is shared and disconnected by the owner.
+func (sm storageMongo) Close() error {
+	return nil
+}
+
+func (sm storageMongo) Put(ctx context.Context, note model.TaggedNote, tags []string) (err error) {
+	var models []mongo.WriteModel
+	for _, tag := range tags {
+		rec := recNote{
+			Tag:        tag,
+			NoteId:     note.NoteId,
+			InterestId: note.InterestId,
+			Data:       note.Data,
+			Created:    note.Created,
+		}
+		models = append(models, mongo.
+			NewReplaceOneModel().
+			SetFilter(bson.M{
+				attrTag:    tag,
+				attrNoteId: note.NoteId,
+			}).
+			SetReplacement(rec).
+			SetUpsert(true),
+		)
+	}
+	if len(models) > 0 {
+		_, err = sm.coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	}
+	err = decodeError(err)
+	return
+}
+
+func (sm storageMongo) List(ctx context.Context, tag string, limit uint32) (notes []model.TaggedNote, err error) {
+	q := bson.M{
+		attrTag: tag,
+	}
+	optsList := options.
+		Find().
+		SetLimit(int64(limit)).
+		SetShowRecordID(false).
+		SetSort(sortListDesc)
+	var cur *mongo.Cursor
+	cur, err = sm.coll.Find(ctx, q, optsList)
+	if err == nil {
+		for cur.Next(ctx) {
+			var rec recNote
+			err = errors.Join(err, cur.Decode(&rec))
+			if err == nil {
+				notes = append(notes, model.TaggedNote{
+					NoteId:     rec.NoteId,
+					InterestId: rec.InterestId,
+					Data:       rec.Data,
+					Created:    rec.Created,
+				})
+			}
+		}
+	}
+	err = decodeError(err)
+	return
+}
+
+func decodeError(src error) (dst error) {
+	switch {
+	case src == nil:
+	default:
+		dst = fmt.Errorf("%w: %s", ErrInternal, src)
+	}
+	return
+}
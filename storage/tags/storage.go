@@ -0,0 +1,21 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"github.com/awakari/int-activitypub/model"
+	"io"
+)
+
+// Storage keeps the recent public Notes by the hashtags. The notes are removed after the retention period.
+type Storage interface {
+	io.Closer
+
+	// Put stores the note for every normalized tag, see model.NormalizeTag. Replaces the same note stored before.
+	Put(ctx context.Context, note model.TaggedNote, tags []string) (err error)
+
+	// List returns the most recent notes having the normalized tag, the newest first.
+	List(ctx context.Context, tag string, limit uint32) (notes []model.TaggedNote, err error)
+}
+
+var ErrInternal = errors.New("tags storage internal failure")
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"github.com/valyala/fastjson"
+	"strings"
+)
+
+const typeEvent = "Event"
+
+// ActivityEvent is the Event object's data the activitypub library doesn't decode, e.g. the Mobilizon extensions,
+// see https://docs.joinmobilizon.org/contribute/activity_pub/
+type ActivityEvent struct {
+	Timezone         string
+	JoinMode         string
+	ParticipantCount int
+
+	// Organizer is the organizing actor IRI: Mobilizon sets the "actor" while "attributedTo" may be a group.
+	Organizer string
+
+	Place *ActivityPlace
+}
+
+// ActivityPlace is the event location. The address may be the plain text (Gancio) or the schema.org PostalAddress
+// (Mobilizon), the latter is formatted as a single line.
+type ActivityPlace struct {
+	Name      string
+	Address   string
+	Latitude  float64
+	Longitude float64
+	HasCoords bool
+}
+
+func decodeEvent(v *fastjson.Value) (evt *ActivityEvent) {
+	if string(v.GetStringBytes("type")) != typeEvent {
+		return
+	}
+	evt = &ActivityEvent{
+		Timezone:         string(v.GetStringBytes("timezone")),
+		JoinMode:         string(v.GetStringBytes("joinMode")),
+		ParticipantCount: v.GetInt("participantCount"),
+		Organizer:        decodeId(v.Get("actor")),
+	}
+	if evt.Organizer == "" {
+		evt.Organizer = decodeId(v.Get("attributedTo"))
+	}
+	evt.Place = decodePlace(v.Get("location"))
+	return
+}
+
+func decodePlace(v *fastjson.Value) (p *ActivityPlace) {
+	if v != nil && v.Type() == fastjson.TypeArray {
+		items, _ := v.Array()
+		v = nil
+		if len(items) > 0 {
+			v = items[0]
+		}
+	}
+	if v == nil || v.Type() != fastjson.TypeObject {
+		return
+	}
+	p = &ActivityPlace{
+		Name:    string(v.GetStringBytes("name")),
+		Address: decodeAddress(v.Get("address")),
+	}
+	lat, lon := v.Get("latitude"), v.Get("longitude")
+	if lat != nil && lon != nil && lat.Type() == fastjson.TypeNumber && lon.Type() == fastjson.TypeNumber {
+		p.Latitude, p.Longitude = lat.GetFloat64(), lon.GetFloat64()
+		p.HasCoords = true
+	}
+	if p.Name == "" && p.Address == "" && !p.HasCoords {
+		p = nil
+	}
+	return
+}
+
+func decodeAddress(v *fastjson.Value) (addr string) {
+	if v == nil {
+		return
+	}
+	switch v.Type() {
+	case fastjson.TypeString:
+		addr = string(v.GetStringBytes())
+	case fastjson.TypeObject:
+		var parts []string
+		for _, k := range []string{"streetAddress", "postalCode", "addressLocality", "addressRegion", "addressCountry"} {
+			if part := strings.TrimSpace(string(v.GetStringBytes(k))); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		addr = strings.Join(parts, ", ")
+	}
+	return
+}
+
+// decodeId returns the IRI of the item that may be a link, an object or a list of these, the 1st one is used.
+func decodeId(v *fastjson.Value) (id string) {
+	if v == nil {
+		return
+	}
+	switch v.Type() {
+	case fastjson.TypeString:
+		id = string(v.GetStringBytes())
+	case fastjson.TypeObject:
+		id = string(v.GetStringBytes("id"))
+	case fastjson.TypeArray:
+		items, _ := v.Array()
+		for _, item := range items {
+			if id = decodeId(item); id != "" {
+				break
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,158 @@
+package util
+
+import (
+	"github.com/valyala/fastjson"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ActivityMediaObject is the Video or Audio object's data the activitypub library decodes partially. PeerTube and
+// Funkwhale list the HTML page and the media files in the "url", the thumbnails of several sizes in the "icon" and
+// set the "duration" either as ISO-8601 string or as the seconds count.
+type ActivityMediaObject struct {
+	Title        string
+	Language     string
+	PageUrl      string
+	MediaUrl     string
+	MediaType    string
+	ThumbnailUrl string
+	Duration     time.Duration
+}
+
+const mediaTypeHtml = "text/html"
+
+// reIsoDuration matches the ISO-8601 durations like "PT1H2M3S" or "P1DT30M", years and months are not supported.
+var reIsoDuration = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+func decodeMediaObject(v *fastjson.Value) (m *ActivityMediaObject) {
+	switch string(v.GetStringBytes("type")) {
+	case "Video", "Audio":
+	default:
+		return
+	}
+	m = &ActivityMediaObject{
+		Title:    decodeName(v),
+		Language: string(v.GetStringBytes("language", "identifier")), // PeerTube
+		Duration: decodeDuration(v.Get("duration")),
+	}
+	m.PageUrl, m.MediaUrl, m.MediaType = decodeMediaLinks(v.Get("url"))
+	m.ThumbnailUrl = decodeThumbnail(v.Get("icon"))
+	if m.ThumbnailUrl == "" {
+		m.ThumbnailUrl = decodeThumbnail(v.Get("image"))
+	}
+	return
+}
+
+func decodeName(v *fastjson.Value) (name string) {
+	name = string(v.GetStringBytes("name"))
+	if name == "" {
+		if o := v.GetObject("nameMap"); o != nil {
+			o.Visit(func(_ []byte, lv *fastjson.Value) {
+				if name == "" {
+					name = string(lv.GetStringBytes())
+				}
+			})
+		}
+	}
+	return
+}
+
+// ParseIsoDuration parses the ISO-8601 duration, e.g. "PT1234S". Returns zero when the value is not supported.
+func ParseIsoDuration(s string) (d time.Duration) {
+	parts := reIsoDuration.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(s)))
+	if parts == nil {
+		return
+	}
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if parts[i+1] != "" {
+			n, _ := strconv.ParseFloat(parts[i+1], 64)
+			d += time.Duration(n * float64(unit))
+		}
+	}
+	return
+}
+
+func decodeDuration(v *fastjson.Value) (d time.Duration) {
+	if v == nil {
+		return
+	}
+	switch v.Type() {
+	case fastjson.TypeNumber:
+		d = time.Duration(v.GetFloat64() * float64(time.Second))
+	case fastjson.TypeString:
+		d = ParseIsoDuration(string(v.GetStringBytes()))
+	}
+	return
+}
+
+// decodeMediaLinks selects the HTML page and the 1st audio or video file from the url links. Funkwhale used to set
+// the "mimeType" instead of the "mediaType".
+func decodeMediaLinks(v *fastjson.Value) (pageUrl, mediaUrl, mediaType string) {
+	if v == nil {
+		return
+	}
+	items := []*fastjson.Value{v}
+	if v.Type() == fastjson.TypeArray {
+		items, _ = v.Array()
+	}
+	var first string
+	for _, item := range items {
+		var href, mt string
+		switch item.Type() {
+		case fastjson.TypeString:
+			href = string(item.GetStringBytes())
+		case fastjson.TypeObject:
+			href = string(item.GetStringBytes("href"))
+			mt = string(item.GetStringBytes("mediaType"))
+			if mt == "" {
+				mt = string(item.GetStringBytes("mimeType"))
+			}
+		}
+		switch {
+		case href == "":
+		case strings.HasPrefix(mt, mediaTypeHtml) && pageUrl == "":
+			pageUrl = href
+		case (strings.HasPrefix(mt, "video/") || strings.HasPrefix(mt, "audio/")) && mediaUrl == "":
+			mediaUrl, mediaType = href, mt
+		case mt == "" && first == "":
+			first = href
+		}
+	}
+	if pageUrl == "" {
+		pageUrl = first
+	}
+	return
+}
+
+// decodeThumbnail selects the largest image, the 1st one when the sizes are unknown.
+func decodeThumbnail(v *fastjson.Value) (addr string) {
+	if v == nil {
+		return
+	}
+	items := []*fastjson.Value{v}
+	if v.Type() == fastjson.TypeArray {
+		items, _ = v.Array()
+	}
+	areaMax := -1
+	for _, item := range items {
+		var href string
+		switch item.Type() {
+		case fastjson.TypeString:
+			href = string(item.GetStringBytes())
+		case fastjson.TypeObject:
+			href, _ = decodeMediaUrl(item.Get("url"), "")
+			if href == "" {
+				href = string(item.GetStringBytes("href"))
+			}
+		}
+		if href == "" {
+			continue
+		}
+		if area := item.GetInt("width") * item.GetInt("height"); area > areaMax {
+			addr, areaMax = href, area
+		}
+	}
+	return
+}
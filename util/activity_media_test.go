@@ -0,0 +1,65 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseIsoDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"PT1234S":    1234 * time.Second,
+		"PT1H2M3S":   time.Hour + 2*time.Minute + 3*time.Second,
+		"P1DT30M":    24*time.Hour + 30*time.Minute,
+		"pt1.5s":     1500 * time.Millisecond,
+		"P1Y":        0,
+		"1234":       0,
+		"":           0,
+		"PT":         0,
+		"PT12M":      12 * time.Minute,
+		"P2D":        48 * time.Hour,
+		"PT-1S":      0,
+		"PT1H30M0S ": 90 * time.Minute,
+	}
+	for in, out := range cases {
+		t.Run(in, func(t *testing.T) {
+			assert.Equal(t, out, ParseIsoDuration(in))
+		})
+	}
+}
+
+func TestDecodeEnvelope_MediaObject(t *testing.T) {
+	cases := map[string]*ActivityMediaObject{
+		"peertube_create_video": {
+			Title:        "Building a gopher robot",
+			Language:     "en",
+			PageUrl:      "https://peertube.example/videos/watch/3f1b6e2a-6d55-4c41-9b0e-7d1a2c3b4e5f",
+			MediaUrl:     "https://peertube.example/static/web-videos/3f1b6e2a-6d55-4c41-9b0e-7d1a2c3b4e5f-720.mp4",
+			MediaType:    "video/mp4",
+			ThumbnailUrl: "https://peertube.example/lazy-static/previews/3f1b6e2a.jpg",
+			Duration:     1234 * time.Second,
+		},
+		"funkwhale_create_audio": {
+			Title:        "Episode 12: Generics in practice",
+			PageUrl:      "https://funkwhale.example/library/tracks/4242",
+			MediaUrl:     "https://funkwhale.example/api/v1/listen/3f0c1d2e-4b5a-6978-8a9b-0c1d2e3f4a5b/?upload=9d1c2f3e-1b2a-4c5d-8e9f-0a1b2c3d4e5f&download=false",
+			MediaType:    "audio/mpeg",
+			ThumbnailUrl: "https://funkwhale.example/media/attachments/ab/cd/ef/cover.jpg",
+			Duration:     2710 * time.Second,
+		},
+		"mastodon_create_note": nil,
+	}
+	for k, m := range cases {
+		t.Run(k, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "inbox", k+".json"))
+			require.Nil(t, err)
+			var env Envelope
+			env, err = DecodeEnvelope(data)
+			require.Nil(t, err)
+			assert.Equal(t, m, env.Object.Media)
+		})
+	}
+}
@@ -1,20 +1,33 @@
 package util
 
-// ActivityTags that the activitypub library fails to deserialize
-type ActivityTags struct {
-	Tag    []ActivityTag `json:"tag,omitempty"`
-	Object ObjectTags    `json:"object,omitempty"`
-}
-
+// ObjectTags contains the actor's data that the activitypub library fails to deserialize
 type ObjectTags struct {
 	Tag []ActivityTag `json:"tag,omitempty"`
+
+	// Fields are the actor's profile metadata, e.g. the Mastodon "PropertyValue" attachments.
+	Fields []ActorField `json:"-"`
+
+	// Extra contains the raw JSON values of the actor's properties unknown to the activitypub library, e.g. "indexable".
+	Extra map[string][]byte `json:"-"`
 }
 
+// ActivityTag is the Hashtag, Mention, Emoji or Link tag.
 type ActivityTag struct {
 	Type string `json:"type"`
 	Name string `json:"name"`
+
+	// Href is the hashtag page or the mentioned actor IRI.
+	Href string `json:"href,omitempty"`
+
+	// IconUrl is the custom emoji image URL.
+	IconUrl string `json:"-"`
+
+	// MediaType is set for the object links, e.g. the FEP-e232 quote reference.
+	MediaType string `json:"-"`
 }
 
-type ActivityContentMap struct {
-	ContentMap map[string]string `json:"contentMap,omitempty"`
+// ActorField is the actor's profile field.
+type ActorField struct {
+	Name  string
+	Value string
 }
@@ -0,0 +1,107 @@
+package util
+
+import (
+	"errors"
+	"image"
+	"math"
+	"strings"
+)
+
+const blurHashChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// blurHashSampleMax is the max count of the pixels sampled along the each image side, the hash is too coarse to
+// benefit from the larger images.
+const blurHashSampleMax = 64
+
+var ErrBlurHash = errors.New("failed to encode the blurhash")
+
+// BlurHash encodes the image as https://blurha.sh does using the specified components count along the each axis.
+func BlurHash(img image.Image, xComps, yComps int) (hash string, err error) {
+	if xComps < 1 || xComps > 9 || yComps < 1 || yComps > 9 {
+		err = errors.Join(ErrBlurHash, errors.New("components count should be in the range 1..9"))
+		return
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w < 1 || h < 1 {
+		err = errors.Join(ErrBlurHash, errors.New("empty image"))
+		return
+	}
+	stepX, stepY := max(1, w/blurHashSampleMax), max(1, h/blurHashSampleMax)
+	factors := make([][3]float64, 0, xComps*yComps)
+	for j := 0; j < yComps; j++ {
+		for i := 0; i < xComps; i++ {
+			var f [3]float64
+			var n int
+			for y := 0; y < h; y += stepY {
+				for x := 0; x < w; x += stepX {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(h))
+					r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+					f[0] += basis * srgbToLinear(r>>8)
+					f[1] += basis * srgbToLinear(g>>8)
+					f[2] += basis * srgbToLinear(bl>>8)
+					n++
+				}
+			}
+			norm := 2.0
+			if i == 0 && j == 0 {
+				norm = 1.0
+			}
+			scale := norm / float64(n)
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(encodeBase83((xComps-1)+(yComps-1)*9, 1))
+	maxVal := 1.0
+	if len(factors) > 1 {
+		var actualMax float64
+		for _, f := range factors[1:] {
+			actualMax = max(actualMax, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+		}
+		quantMax := int(max(0, min(82, math.Floor(actualMax*166-0.5))))
+		maxVal = float64(quantMax+1) / 166
+		sb.WriteString(encodeBase83(quantMax, 1))
+	} else {
+		sb.WriteString(encodeBase83(0, 1))
+	}
+	dc := factors[0]
+	sb.WriteString(encodeBase83(linearToSrgb(dc[0])<<16+linearToSrgb(dc[1])<<8+linearToSrgb(dc[2]), 4))
+	for _, f := range factors[1:] {
+		v := quantAc(f[0]/maxVal)*19*19 + quantAc(f[1]/maxVal)*19 + quantAc(f[2]/maxVal)
+		sb.WriteString(encodeBase83(v, 2))
+	}
+	hash = sb.String()
+	return
+}
+
+func srgbToLinear(v uint32) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(v float64) int {
+	c := max(0, min(1, v))
+	if c <= 0.0031308 {
+		return int(c*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(c, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func quantAc(v float64) int {
+	signPow := math.Copysign(math.Pow(math.Abs(v), 0.5), v)
+	return int(max(0, min(18, math.Floor(signPow*9+9.5))))
+}
+
+func encodeBase83(v, length int) string {
+	buf := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		buf[i] = blurHashChars[v%83]
+		v /= 83
+	}
+	return string(buf)
+}
@@ -0,0 +1,76 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestBlurHash(t *testing.T) {
+	cases := map[string]struct {
+		img    image.Image
+		xComps int
+		yComps int
+		hash   string
+		err    error
+	}{
+		"black": {
+			img:    solid(color.Black, 32, 32),
+			xComps: 4,
+			yComps: 3,
+			hash:   "L00000fQfQfQfQfQfQfQfQfQfQfQ",
+		},
+		"white": {
+			img:    solid(color.White, 100, 50),
+			xComps: 4,
+			yComps: 3,
+			hash:   "L6TSUA-;fQ-;~qj[fQj[fQfQfQfQ",
+		},
+		"dc only": {
+			img:    solid(color.White, 8, 8),
+			xComps: 1,
+			yComps: 1,
+			hash:   "00TSUA",
+		},
+		"too many components": {
+			img:    solid(color.White, 8, 8),
+			xComps: 10,
+			yComps: 3,
+			err:    ErrBlurHash,
+		},
+		"empty": {
+			img:    image.NewRGBA(image.Rect(0, 0, 0, 0)),
+			xComps: 4,
+			yComps: 3,
+			err:    ErrBlurHash,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			hash, err := BlurHash(c.img, c.xComps, c.yComps)
+			assert.Equal(t, c.hash, hash)
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestBlurHash_Gradient(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 32; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: 0, B: uint8(255 - x*4), A: 255})
+		}
+	}
+	hash, err := BlurHash(img, 4, 3)
+	assert.Nil(t, err)
+	assert.Len(t, hash, 28)
+	assert.NotEqual(t, "fQ", hash[6:8]) // the horizontal gradient yields the non-zero 1st AC component
+}
+
+func solid(c color.Color, w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
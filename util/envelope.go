@@ -0,0 +1,376 @@
+package util
+
+import (
+	vocab "github.com/go-ap/activitypub"
+	"github.com/valyala/fastjson"
+	"time"
+)
+
+// Envelope is the inbound activity payload decoded in a single pass.
+type Envelope struct {
+	Activity vocab.Activity
+
+	// Object contains the embedded object's data which the activitypub library doesn't decode.
+	// Empty when the activity's object is a link.
+	Object EnvelopeObject
+
+	// Tags are the activity's own tags. The object's tags are in the Object.
+	Tags []ActivityTag
+
+	// Attachments are the activity's own media attachments. The object's attachments are in the Object.
+	Attachments []ActivityAttachment
+
+	ContentMap map[string]string
+
+	// Extra contains the raw JSON values of the properties unknown to the activitypub library, e.g. "sensitive".
+	Extra map[string][]byte
+}
+
+type EnvelopeObject struct {
+	Tags        []ActivityTag
+	Attachments []ActivityAttachment
+	ContentMap  map[string]string
+	Extra       map[string][]byte
+
+	// Poll is set when the object is a Question with the options.
+	Poll *ActivityPoll
+
+	// Event is set when the object is an Event.
+	Event *ActivityEvent
+
+	// Media is set when the object is a Video or an Audio.
+	Media *ActivityMediaObject
+}
+
+// ActivityAttachment is the media attachment with the properties the activitypub library doesn't decode.
+type ActivityAttachment struct {
+	Type       string    `json:"type,omitempty"`
+	MediaType  string    `json:"mediaType,omitempty"`
+	Url        string    `json:"url"`
+	Name       string    `json:"name,omitempty"`
+	Blurhash   string    `json:"blurhash,omitempty"`
+	Width      int       `json:"width,omitempty"`
+	Height     int       `json:"height,omitempty"`
+	FocalPoint []float64 `json:"focalPoint,omitempty"`
+}
+
+// ActivityPoll is the Question's poll data the activitypub library decodes partially: Mastodon sends the "closed"
+// timestamp instead of the boolean and the "votersCount" extension.
+type ActivityPoll struct {
+	Options  []ActivityPollOption
+	Multiple bool
+	Voters   int
+	EndTime  time.Time
+	Closed   bool
+	ClosedAt time.Time
+}
+
+type ActivityPollOption struct {
+	Name  string `json:"name"`
+	Votes int    `json:"votes"`
+}
+
+// IsClosed returns true when the poll is explicitly closed or its end time has passed.
+func (p ActivityPoll) IsClosed(now time.Time) bool {
+	return p.Closed || (!p.EndTime.IsZero() && !p.EndTime.After(now))
+}
+
+const typePropertyValue = "PropertyValue"
+
+// propsKnown are decoded by the activitypub library, anything else goes to the extra fields.
+var propsKnown = map[string]bool{
+	"@context":     true,
+	"actor":        true,
+	"attachment":   true,
+	"attributedTo": true,
+	"audience":     true,
+	"bcc":          true,
+	"bto":          true,
+	"cc":           true,
+	"closed":       true,
+	"content":      true,
+	"contentMap":   true,
+	"context":      true,
+	"duration":     true,
+	"endTime":      true,
+	"generator":    true,
+	"icon":         true,
+	"id":           true,
+	"image":        true,
+	"inReplyTo":    true,
+	"instrument":   true,
+	"likes":        true,
+	"location":     true,
+	"mediaType":    true,
+	"name":         true,
+	"nameMap":      true,
+	"object":       true,
+	"oneOf":        true,
+	"anyOf":        true,
+	"origin":       true,
+	"preview":      true,
+	"published":    true,
+	"replies":      true,
+	"result":       true,
+	"shares":       true,
+	"source":       true,
+	"startTime":    true,
+	"summary":      true,
+	"summaryMap":   true,
+	"tag":          true,
+	"target":       true,
+	"to":           true,
+	"type":         true,
+	"updated":      true,
+	"url":          true,
+	// actor
+	"endpoints":         true,
+	"followers":         true,
+	"following":         true,
+	"inbox":             true,
+	"liked":             true,
+	"outbox":            true,
+	"preferredUsername": true,
+	"publicKey":         true,
+	"streams":           true,
+}
+
+// DecodeEnvelope parses the activity payload once and extracts everything from the same parsed value.
+func DecodeEnvelope(data []byte) (e Envelope, err error) {
+	p := fastjson.Parser{}
+	var v *fastjson.Value
+	v, err = p.ParseBytes(data)
+	if err == nil {
+		err = vocab.JSONLoadActivity(v, &e.Activity)
+	}
+	if err == nil {
+		e.Tags = decodeTags(v)
+		e.Attachments = decodeAttachments(v)
+		e.ContentMap = decodeContentMap(v)
+		e.Extra = decodeExtra(v)
+		if obj := v.Get("object"); obj != nil && obj.Type() == fastjson.TypeObject {
+			e.Object.Tags = decodeTags(obj)
+			e.Object.Attachments = decodeAttachments(obj)
+			e.Object.ContentMap = decodeContentMap(obj)
+			e.Object.Extra = decodeExtra(obj)
+			e.Object.Poll = decodePoll(obj)
+			e.Object.Event = decodeEvent(obj)
+			e.Object.Media = decodeMediaObject(obj)
+		}
+	}
+	return
+}
+
+// DecodeActor parses the actor payload once and extracts the actor's tags, profile fields and extra properties
+// from the same parsed value.
+func DecodeActor(data []byte) (a vocab.Actor, tags ObjectTags, err error) {
+	p := fastjson.Parser{}
+	var v *fastjson.Value
+	v, err = p.ParseBytes(data)
+	if err == nil {
+		err = vocab.JSONLoadActor(v, &a)
+	}
+	if err == nil {
+		tags.Tag = decodeTags(v)
+		tags.Fields = decodeFields(v)
+		tags.Extra = decodeExtra(v)
+	}
+	return
+}
+
+func decodeFields(v *fastjson.Value) (fields []ActorField) {
+	va := v.Get("attachment")
+	if va == nil {
+		return
+	}
+	items := []*fastjson.Value{va}
+	if va.Type() == fastjson.TypeArray {
+		items, _ = va.Array()
+	}
+	for _, item := range items {
+		if item.Type() != fastjson.TypeObject || string(item.GetStringBytes("type")) != typePropertyValue {
+			continue
+		}
+		fields = append(fields, ActorField{
+			Name:  string(item.GetStringBytes("name")),
+			Value: string(item.GetStringBytes("value")),
+		})
+	}
+	return
+}
+
+func decodeTags(v *fastjson.Value) (tags []ActivityTag) {
+	vt := v.Get("tag")
+	if vt == nil {
+		return
+	}
+	items := []*fastjson.Value{vt}
+	if vt.Type() == fastjson.TypeArray {
+		items, _ = vt.Array()
+	}
+	for _, item := range items {
+		if item.Type() != fastjson.TypeObject {
+			continue
+		}
+		t := ActivityTag{
+			Type:      string(item.GetStringBytes("type")),
+			Name:      string(item.GetStringBytes("name")),
+			Href:      string(item.GetStringBytes("href")),
+			MediaType: string(item.GetStringBytes("mediaType")),
+		}
+		if icon := item.Get("icon"); icon != nil {
+			if icon.Type() == fastjson.TypeArray {
+				icon = icon.Get("0")
+			}
+			t.IconUrl = string(icon.GetStringBytes("url"))
+		}
+		tags = append(tags, t)
+	}
+	return
+}
+
+func decodeAttachments(v *fastjson.Value) (atts []ActivityAttachment) {
+	va := v.Get("attachment")
+	if va == nil {
+		return
+	}
+	items := []*fastjson.Value{va}
+	if va.Type() == fastjson.TypeArray {
+		items, _ = va.Array()
+	}
+	for _, item := range items {
+		var att ActivityAttachment
+		switch item.Type() {
+		case fastjson.TypeString:
+			att.Url = string(item.GetStringBytes())
+		case fastjson.TypeObject:
+			att.Type = string(item.GetStringBytes("type"))
+			if att.Type == typePropertyValue {
+				continue
+			}
+			att.MediaType = string(item.GetStringBytes("mediaType"))
+			att.Url, att.MediaType = decodeMediaUrl(item.Get("url"), att.MediaType)
+			if att.Url == "" {
+				att.Url = string(item.GetStringBytes("href"))
+			}
+			att.Name = string(item.GetStringBytes("name"))
+			att.Blurhash = string(item.GetStringBytes("blurhash"))
+			att.Width = item.GetInt("width")
+			att.Height = item.GetInt("height")
+			if fp := item.GetArray("focalPoint"); len(fp) == 2 {
+				att.FocalPoint = []float64{fp[0].GetFloat64(), fp[1].GetFloat64()}
+			}
+		}
+		if att.Url != "" {
+			atts = append(atts, att)
+		}
+	}
+	return
+}
+
+// decodeMediaUrl resolves the media url that may be a string, a Link or a list of Links, e.g. PeerTube lists the
+// HTML page 1st and then the media files. Returns the media type of the link selected when it's not known yet.
+func decodeMediaUrl(vu *fastjson.Value, mediaType string) (addr, mt string) {
+	mt = mediaType
+	if vu == nil {
+		return
+	}
+	items := []*fastjson.Value{vu}
+	if vu.Type() == fastjson.TypeArray {
+		items, _ = vu.Array()
+	}
+	for _, item := range items {
+		switch item.Type() {
+		case fastjson.TypeString:
+			if addr == "" {
+				addr = string(item.GetStringBytes())
+			}
+		case fastjson.TypeObject:
+			href := string(item.GetStringBytes("href"))
+			linkMediaType := string(item.GetStringBytes("mediaType"))
+			switch {
+			case href == "":
+			case addr == "":
+				addr = href
+				if mediaType == "" {
+					mt = linkMediaType
+				}
+			case mt == "text/html" && linkMediaType != "text/html":
+				// prefer the media file to the page
+				addr, mt = href, linkMediaType
+			}
+		}
+	}
+	return
+}
+
+func decodePoll(v *fastjson.Value) (p *ActivityPoll) {
+	multiple := false
+	options := v.GetArray("oneOf")
+	if len(options) == 0 {
+		options = v.GetArray("anyOf")
+		multiple = true
+	}
+	if len(options) == 0 {
+		return
+	}
+	p = &ActivityPoll{
+		Multiple: multiple,
+		Voters:   v.GetInt("votersCount"),
+		EndTime:  decodeTime(v.Get("endTime")),
+	}
+	for _, o := range options {
+		name := string(o.GetStringBytes("name"))
+		if name != "" {
+			p.Options = append(p.Options, ActivityPollOption{
+				Name:  name,
+				Votes: o.GetInt("replies", "totalItems"),
+			})
+		}
+	}
+	if vc := v.Get("closed"); vc != nil {
+		switch vc.Type() {
+		case fastjson.TypeTrue:
+			p.Closed = true
+			p.ClosedAt = p.EndTime
+		case fastjson.TypeString:
+			p.ClosedAt = decodeTime(vc)
+			p.Closed = !p.ClosedAt.IsZero()
+		}
+	}
+	return
+}
+
+func decodeTime(v *fastjson.Value) (t time.Time) {
+	if v != nil && v.Type() == fastjson.TypeString {
+		t, _ = time.Parse(time.RFC3339, string(v.GetStringBytes()))
+	}
+	return
+}
+
+func decodeContentMap(v *fastjson.Value) (cm map[string]string) {
+	o := v.GetObject("contentMap")
+	if o != nil && o.Len() > 0 {
+		cm = make(map[string]string, o.Len())
+		o.Visit(func(k []byte, lv *fastjson.Value) {
+			cm[string(k)] = string(lv.GetStringBytes())
+		})
+	}
+	return
+}
+
+func decodeExtra(v *fastjson.Value) (extra map[string][]byte) {
+	o, _ := v.Object()
+	if o == nil {
+		return
+	}
+	o.Visit(func(k []byte, pv *fastjson.Value) {
+		if !propsKnown[string(k)] {
+			if extra == nil {
+				extra = make(map[string][]byte)
+			}
+			extra[string(k)] = pv.MarshalTo(nil)
+		}
+	})
+	return
+}
@@ -0,0 +1,661 @@
+package util
+
+import (
+	"github.com/bytedance/sonic"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDecodeEnvelope_Tags(t *testing.T) {
+	cases := map[string]struct {
+		in   string
+		tags []ActivityTag
+	}{
+		"nobot": {
+			in: `{
+  "@context": [
+    "https://www.w3.org/ns/activitystreams",
+    {
+      "ostatus": "http://ostatus.org#",
+      "atomUri": "ostatus:atomUri",
+      "inReplyToAtomUri": "ostatus:inReplyToAtomUri",
+      "conversation": "ostatus:conversation",
+      "sensitive": "as:sensitive",
+      "toot": "http://joinmastodon.org/ns#",
+      "votersCount": "toot:votersCount",
+      "Hashtag": "as:Hashtag"
+    }
+  ],
+  "id": "https://mastodon.social/users/akurilov/statuses/112614067761000729",
+  "type": "Note",
+  "summary": null,
+  "inReplyTo": null,
+  "published": "2024-06-14T08:38:25Z",
+  "url": "https://mastodon.social/@akurilov/112614067761000729",
+  "attributedTo": "https://mastodon.social/users/akurilov",
+  "to": [
+    "https://www.w3.org/ns/activitystreams#Public"
+  ],
+  "cc": [
+    "https://mastodon.social/users/akurilov/followers"
+  ],
+  "sensitive": false,
+  "atomUri": "https://mastodon.social/users/akurilov/statuses/112614067761000729",
+  "inReplyToAtomUri": null,
+  "conversation": "tag:mastodon.social,2024-06-14:objectId=729942125:objectType=Conversation",
+  "content": "\u003cp\u003etest \u003ca href=\"https://mastodon.social/tags/nobot\" class=\"mention hashtag\" rel=\"tag\"\u003e#\u003cspan\u003enobot\u003c/span\u003e\u003c/a\u003e\u003c/p\u003e",
+  "contentMap": {
+    "en": "\u003cp\u003etest \u003ca href=\"https://mastodon.social/tags/nobot\" class=\"mention hashtag\" rel=\"tag\"\u003e#\u003cspan\u003enobot\u003c/span\u003e\u003c/a\u003e\u003c/p\u003e"
+  },
+  "attachment": [],
+  "tag": [
+    {
+      "type": "Hashtag",
+      "href": "https://mastodon.social/tags/nobot",
+      "name": "#nobot"
+    }
+  ],
+  "replies": {
+    "id": "https://mastodon.social/users/akurilov/statuses/112614067761000729/replies",
+    "type": "Collection",
+    "first": {
+      "type": "CollectionPage",
+      "next": "https://mastodon.social/users/akurilov/statuses/112614067761000729/replies?only_other_accounts=true\u0026page=true",
+      "partOf": "https://mastodon.social/users/akurilov/statuses/112614067761000729/replies",
+      "items": []
+    }
+  }
+}`,
+			tags: []ActivityTag{
+				{
+					Type: "Hashtag",
+					Name: "#nobot",
+					Href: "https://mastodon.social/tags/nobot",
+				},
+			},
+		},
+		"single tag object": {
+			in:   `{"type":"Note","tag":{"type":"Hashtag","name":"#nobot"}}`,
+			tags: []ActivityTag{{Type: "Hashtag", Name: "#nobot"}},
+		},
+		"object link": {
+			in: `{"type":"Note","tag":[{"type":"Link","mediaType":"application/activity+json","href":"https://host/notes/1"}]}`,
+			tags: []ActivityTag{
+				{
+					Type:      "Link",
+					Href:      "https://host/notes/1",
+					MediaType: "application/activity+json",
+				},
+			},
+		},
+		"no tags": {
+			in: `{"type":"Note","content":"foo"}`,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			assert.Equal(t, c.tags, env.Tags)
+		})
+	}
+}
+
+func TestDecodeEnvelope(t *testing.T) {
+	cases := map[string]struct {
+		typ        vocab.ActivityVocabularyType
+		objType    vocab.ActivityVocabularyType
+		tags       []ActivityTag
+		objTags    []ActivityTag
+		contentMap map[string]string
+		extra      []string
+		objExtra   []string
+		err        bool
+	}{
+		"mastodon_create_note": {
+			typ:     vocab.CreateType,
+			objType: vocab.NoteType,
+			objTags: []ActivityTag{
+				{
+					Type: "Mention",
+					Name: "@janedoe@fosstodon.org",
+					Href: "https://fosstodon.org/users/janedoe",
+				},
+				{
+					Type: "Hashtag",
+					Name: "#golang",
+					Href: "https://mastodon.social/tags/golang",
+				},
+				{
+					Type:    "Emoji",
+					Name:    ":blobcat:",
+					IconUrl: "https://files.mastodon.social/custom_emojis/images/000/012/345/original/blobcat.png",
+				},
+			},
+			objExtra: []string{"sensitive", "atomUri", "inReplyToAtomUri", "conversation"},
+		},
+		"misskey_create_note": {
+			typ:     vocab.CreateType,
+			objType: vocab.NoteType,
+			objTags: []ActivityTag{
+				{
+					Type: "Hashtag",
+					Name: "#misskey",
+					Href: "https://misskey.io/tags/misskey",
+				},
+			},
+			objExtra: []string{"_misskey_content", "_misskey_quote", "quoteUrl", "sensitive"},
+		},
+		"pleroma_create_note": {
+			typ:     vocab.CreateType,
+			objType: vocab.NoteType,
+			objTags: []ActivityTag{
+				{
+					Type: "Hashtag",
+					Name: "#fediverse",
+					Href: "https://pleroma.example/tags/fediverse",
+				},
+			},
+			extra:    []string{"directMessage"},
+			objExtra: []string{"conversation", "sensitive"},
+		},
+		"peertube_create_video": {
+			typ:     vocab.CreateType,
+			objType: vocab.VideoType,
+			objTags: []ActivityTag{
+				{
+					Type: "Hashtag",
+					Name: "robots",
+				},
+				{
+					Type: "Hashtag",
+					Name: "diy",
+				},
+			},
+			objExtra: []string{
+				"uuid", "category", "licence", "language", "views", "sensitive", "commentsEnabled", "downloadEnabled",
+				"waitTranscoding", "state", "originallyPublishedAt", "uploadDate", "support", "subtitleLanguage",
+				"aspectRatio", "dislikes", "comments", "hasParts", "isLiveBroadcast", "liveSaveReplay", "permanentLive",
+				"latencyMode",
+			},
+		},
+		"funkwhale_create_audio": {
+			typ:     vocab.CreateType,
+			objType: vocab.AudioType,
+			objTags: []ActivityTag{
+				{
+					Type: "Hashtag",
+					Name: "#golang",
+				},
+			},
+			objExtra: []string{"position", "disc", "album", "license", "copyright"},
+		},
+		"lemmy_create_page": {
+			typ:     vocab.AnnounceType,
+			objType: vocab.CreateType,
+		},
+		"mastodon_update_person": {
+			typ:     vocab.UpdateType,
+			objType: vocab.PersonType,
+			objTags: []ActivityTag{
+				{
+					Type: "Hashtag",
+					Name: "#nobot",
+					Href: "https://mastodon.social/tags/nobot",
+				},
+			},
+			objExtra: []string{"featured", "manuallyApprovesFollowers", "discoverable", "indexable"},
+		},
+		"mastodon_follow": {
+			typ: vocab.FollowType,
+		},
+		"mastodon_undo_follow": {
+			typ:     vocab.UndoType,
+			objType: vocab.FollowType,
+		},
+		"mastodon_delete_note": {
+			typ:      vocab.DeleteType,
+			objType:  vocab.TombstoneType,
+			extra:    []string{"signature"},
+			objExtra: []string{"atomUri"},
+		},
+		"mastodon_announce": {
+			typ: vocab.AnnounceType,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "inbox", k+".json"))
+			require.Nil(t, err)
+			var env Envelope
+			env, err = DecodeEnvelope(data)
+			require.Nil(t, err)
+			assert.Equal(t, c.typ, env.Activity.Type)
+			if c.objType != "" {
+				assert.Equal(t, c.objType, env.Activity.Object.GetType())
+			}
+			assert.Equal(t, c.tags, env.Tags)
+			assert.Equal(t, c.objTags, env.Object.Tags)
+			assert.Equal(t, len(c.extra), len(env.Extra))
+			for _, e := range c.extra {
+				assert.Contains(t, env.Extra, e)
+			}
+			assert.Equal(t, len(c.objExtra), len(env.Object.Extra))
+			for _, e := range c.objExtra {
+				assert.Contains(t, env.Object.Extra, e)
+			}
+		})
+	}
+}
+
+func TestDecodeEnvelope_Fields(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "inbox", "mastodon_create_note.json"))
+	require.Nil(t, err)
+	var env Envelope
+	env, err = DecodeEnvelope(data)
+	require.Nil(t, err)
+	assert.Nil(t, env.ContentMap)
+	assert.Equal(t, 1, len(env.Object.ContentMap))
+	assert.Contains(t, env.Object.ContentMap["en"], "look at this")
+	assert.Equal(t, "false", string(env.Object.Extra["sensitive"]))
+	assert.Equal(t, `null`, string(env.Object.Extra["inReplyToAtomUri"]))
+}
+
+func TestDecodeEnvelope_Attachments(t *testing.T) {
+	cases := map[string]struct {
+		in   string
+		atts []ActivityAttachment
+	}{
+		"none": {
+			in: `{"type":"Create","object":{"type":"Note","content":"hello"}}`,
+		},
+		"mastodon": {
+			in: `{
+				"type":"Create",
+				"object":{
+					"type":"Note",
+					"attachment":[
+						{
+							"type":"Document",
+							"mediaType":"image/png",
+							"url":"https://host/a.png",
+							"name":"A gopher at the desk",
+							"blurhash":"UBL_:rOpGG-oBUNG,qRj2so|=eE1w^n4S5NH",
+							"width":1200,
+							"height":800,
+							"focalPoint":[-0.5,0.25]
+						},
+						{
+							"type":"Document",
+							"mediaType":"video/mp4",
+							"url":"https://host/b.mp4"
+						}
+					]
+				}
+			}`,
+			atts: []ActivityAttachment{
+				{
+					Type:       "Document",
+					MediaType:  "image/png",
+					Url:        "https://host/a.png",
+					Name:       "A gopher at the desk",
+					Blurhash:   "UBL_:rOpGG-oBUNG,qRj2so|=eE1w^n4S5NH",
+					Width:      1200,
+					Height:     800,
+					FocalPoint: []float64{-0.5, 0.25},
+				},
+				{
+					Type:      "Document",
+					MediaType: "video/mp4",
+					Url:       "https://host/b.mp4",
+				},
+			},
+		},
+		"single link and property value skipped": {
+			in: `{
+				"type":"Create",
+				"object":{
+					"type":"Note",
+					"attachment":[
+						"https://host/c.jpg",
+						{"type":"PropertyValue","name":"Website","value":"example.com"}
+					]
+				}
+			}`,
+			atts: []ActivityAttachment{
+				{
+					Url: "https://host/c.jpg",
+				},
+			},
+		},
+		"url links": {
+			in: `{
+				"type":"Create",
+				"object":{
+					"type":"Note",
+					"attachment":{
+						"type":"Video",
+						"name":"clip",
+						"url":[
+							{"type":"Link","mediaType":"text/html","href":"https://host/w/1"},
+							{"type":"Link","mediaType":"video/mp4","href":"https://host/1.mp4"}
+						]
+					}
+				}
+			}`,
+			atts: []ActivityAttachment{
+				{
+					Type:      "Video",
+					MediaType: "video/mp4",
+					Url:       "https://host/1.mp4",
+					Name:      "clip",
+				},
+			},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			assert.Equal(t, c.atts, env.Object.Attachments)
+		})
+	}
+}
+
+func TestDecodeEnvelope_Poll(t *testing.T) {
+	endTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		in     string
+		poll   *ActivityPoll
+		closed bool
+	}{
+		"not a poll": {
+			in: `{"type":"Create","object":{"type":"Note","content":"hello"}}`,
+		},
+		"mastodon open": {
+			in: `{
+				"type":"Create",
+				"object":{
+					"type":"Question",
+					"content":"Tabs or spaces?",
+					"endTime":"2124-05-01T12:00:00Z",
+					"votersCount":5,
+					"oneOf":[
+						{"type":"Note","name":"tabs","replies":{"type":"Collection","totalItems":2}},
+						{"type":"Note","name":"spaces","replies":{"type":"Collection","totalItems":3}}
+					]
+				}
+			}`,
+			poll: &ActivityPoll{
+				Options: []ActivityPollOption{
+					{Name: "tabs", Votes: 2},
+					{Name: "spaces", Votes: 3},
+				},
+				Voters:  5,
+				EndTime: time.Date(2124, 5, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		"mastodon closed": {
+			in: `{
+				"type":"Update",
+				"object":{
+					"type":"Question",
+					"content":"Pick any",
+					"endTime":"2024-05-01T12:00:00Z",
+					"closed":"2024-05-01T12:00:01Z",
+					"anyOf":[
+						{"type":"Note","name":"a","replies":{"totalItems":7}},
+						{"type":"Note","name":""}
+					]
+				}
+			}`,
+			poll: &ActivityPoll{
+				Options:  []ActivityPollOption{{Name: "a", Votes: 7}},
+				Multiple: true,
+				EndTime:  endTime,
+				Closed:   true,
+				ClosedAt: endTime.Add(time.Second),
+			},
+			closed: true,
+		},
+		"closed flag": {
+			in: `{
+				"type":"Update",
+				"object":{
+					"type":"Question",
+					"endTime":"2024-05-01T12:00:00Z",
+					"closed":true,
+					"oneOf":[{"type":"Note","name":"yes"}]
+				}
+			}`,
+			poll: &ActivityPoll{
+				Options:  []ActivityPollOption{{Name: "yes"}},
+				EndTime:  endTime,
+				Closed:   true,
+				ClosedAt: endTime,
+			},
+			closed: true,
+		},
+		"end time passed": {
+			in: `{
+				"type":"Update",
+				"object":{
+					"type":"Question",
+					"endTime":"2024-05-01T12:00:00Z",
+					"oneOf":[{"type":"Note","name":"yes"}]
+				}
+			}`,
+			poll: &ActivityPoll{
+				Options: []ActivityPollOption{{Name: "yes"}},
+				EndTime: endTime,
+			},
+			closed: true,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			assert.Equal(t, c.poll, env.Object.Poll)
+			if c.poll != nil {
+				assert.Equal(t, c.closed, env.Object.Poll.IsClosed(time.Now()))
+			}
+		})
+	}
+}
+
+func TestDecodeEnvelope_Event(t *testing.T) {
+	cases := map[string]struct {
+		in  string
+		evt *ActivityEvent
+	}{
+		"not an event": {
+			in: `{"type":"Create","object":{"type":"Note","content":"hello"}}`,
+		},
+		"mobilizon": {
+			in: `{
+				"type":"Create",
+				"object":{
+					"type":"Event",
+					"name":"Go meetup",
+					"startTime":"2024-05-01T18:00:00+02:00",
+					"endTime":"2024-05-01T21:00:00+02:00",
+					"timezone":"Europe/Paris",
+					"joinMode":"free",
+					"participantCount":12,
+					"actor":"https://mobilizon.fr/@johndoe",
+					"attributedTo":"https://mobilizon.fr/@gophers",
+					"location":{
+						"type":"Place",
+						"name":"La Cantine",
+						"address":{
+							"type":"PostalAddress",
+							"streetAddress":"10 Rue de Rivoli",
+							"postalCode":"75004",
+							"addressLocality":"Paris",
+							"addressCountry":"France"
+						},
+						"latitude":48.8566,
+						"longitude":2.3522
+					}
+				}
+			}`,
+			evt: &ActivityEvent{
+				Timezone:         "Europe/Paris",
+				JoinMode:         "free",
+				ParticipantCount: 12,
+				Organizer:        "https://mobilizon.fr/@johndoe",
+				Place: &ActivityPlace{
+					Name:      "La Cantine",
+					Address:   "10 Rue de Rivoli, 75004, Paris, France",
+					Latitude:  48.8566,
+					Longitude: 2.3522,
+					HasCoords: true,
+				},
+			},
+		},
+		"gancio": {
+			in: `{
+				"type":"Create",
+				"object":{
+					"type":"Event",
+					"name":"Concert",
+					"startTime":"2024-05-01T20:00:00Z",
+					"attributedTo":{"type":"Application","id":"https://gancio.example/federation/u/relay"},
+					"location":{"type":"Place","name":"Main square","address":"Piazza 1, Torino"}
+				}
+			}`,
+			evt: &ActivityEvent{
+				Organizer: "https://gancio.example/federation/u/relay",
+				Place: &ActivityPlace{
+					Name:    "Main square",
+					Address: "Piazza 1, Torino",
+				},
+			},
+		},
+		"empty location": {
+			in:  `{"type":"Create","object":{"type":"Event","name":"Online","location":[{"type":"Place"}]}}`,
+			evt: &ActivityEvent{},
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			env, err := DecodeEnvelope([]byte(c.in))
+			require.Nil(t, err)
+			assert.Equal(t, c.evt, env.Object.Event)
+		})
+	}
+}
+
+func TestDecodeEnvelope_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"empty":     ``,
+		"truncated": `{"type":"Create","object":{`,
+		"not json":  `<html></html>`,
+	}
+	for k, in := range cases {
+		t.Run(k, func(t *testing.T) {
+			_, err := DecodeEnvelope([]byte(in))
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestDecodeActor(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "actor", "mastodon_person.json"))
+	require.Nil(t, err)
+	a, tags, err := DecodeActor(data)
+	require.Nil(t, err)
+	assert.Equal(t, vocab.PersonType, a.Type)
+	assert.Equal(t, "https://mastodon.social/inbox", a.Endpoints.SharedInbox.GetLink().String())
+	assert.Equal(t, "https://mastodon.social/users/johndoe#main-key", a.PublicKey.ID.String())
+	assert.Equal(t, []ActivityTag{{Type: "Hashtag", Name: "#nobot", Href: "https://mastodon.social/tags/nobot"}}, tags.Tag)
+	assert.Equal(t, []ActorField{{Name: "Website", Value: `<a href="https://example.com" rel="me nofollow noopener noreferrer" target="_blank">example.com</a>`}}, tags.Fields)
+	assert.Equal(t, "false", string(tags.Extra["indexable"]))
+	assert.Equal(t, "true", string(tags.Extra["discoverable"]))
+}
+
+func loadCorpus(b *testing.B, dir string) (corpus [][]byte) {
+	files, err := filepath.Glob(filepath.Join("testdata", dir, "*.json"))
+	require.Nil(b, err)
+	for _, f := range files {
+		var data []byte
+		data, err = os.ReadFile(f)
+		require.Nil(b, err)
+		corpus = append(corpus, data)
+	}
+	return
+}
+
+// decodeMultiPass reproduces the former inbox decoding: the payload is unmarshalled once per destination.
+func decodeMultiPass(data []byte) (err error) {
+	var a vocab.Activity
+	err = sonic.Unmarshal(data, &a)
+	if err == nil {
+		var tags struct {
+			Tag    []ActivityTag `json:"tag,omitempty"`
+			Object ObjectTags    `json:"object,omitempty"`
+		}
+		_ = sonic.Unmarshal(data, &tags)
+		var cm struct {
+			ContentMap map[string]string `json:"contentMap,omitempty"`
+		}
+		_ = sonic.Unmarshal(data, &cm)
+	}
+	return
+}
+
+func BenchmarkDecodeEnvelope(b *testing.B) {
+	corpus := loadCorpus(b, "inbox")
+	cases := map[string]func(data []byte) error{
+		"multi pass": decodeMultiPass,
+		"single pass": func(data []byte) (err error) {
+			_, err = DecodeEnvelope(data)
+			return
+		},
+	}
+	for k, decode := range cases {
+		b.Run(k, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := decode(corpus[i%len(corpus)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeActor(b *testing.B) {
+	corpus := loadCorpus(b, "actor")
+	cases := map[string]func(data []byte) error{
+		"multi pass": func(data []byte) (err error) {
+			var a vocab.Actor
+			err = sonic.Unmarshal(data, &a)
+			if err == nil {
+				var tags ObjectTags
+				err = sonic.Unmarshal(data, &tags)
+			}
+			return
+		},
+		"single pass": func(data []byte) (err error) {
+			_, _, err = DecodeActor(data)
+			return
+		},
+	}
+	for k, decode := range cases {
+		b.Run(k, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := decode(corpus[i%len(corpus)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
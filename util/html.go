@@ -0,0 +1,110 @@
+package util
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"regexp"
+	"strings"
+)
+
+var reHtmlSpace = regexp.MustCompile(`[ \t\r\n\f]+`)
+var reHtmlLineSpace = regexp.MustCompile(` *\n *`)
+var reHtmlParagraphs = regexp.MustCompile(`\n{3,}`)
+
+// htmlParagraphs are separated by the empty line, the other block elements by the line break.
+var htmlParagraphs = map[atom.Atom]bool{
+	atom.Article:    true,
+	atom.Blockquote: true,
+	atom.Figure:     true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Header:     true,
+	atom.Footer:     true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Table:      true,
+	atom.Ul:         true,
+}
+
+var htmlLines = map[atom.Atom]bool{
+	atom.Br:         true,
+	atom.Dd:         true,
+	atom.Div:        true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.Hr:         true,
+	atom.Tr:         true,
+}
+
+// htmlSkipped elements have no readable text.
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Noscript: true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+}
+
+// HtmlToText converts the HTML to the plain text keeping the paragraphs and the list items on the separate lines.
+// The entities are decoded, the scripts and styles are dropped and the whitespace is collapsed.
+func HtmlToText(src string) (txt string) {
+	z := html.NewTokenizer(strings.NewReader(src))
+	var sb strings.Builder
+	var skipDepth int
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		t := z.Token()
+		switch tt {
+		case html.TextToken:
+			if skipDepth == 0 {
+				sb.WriteString(reHtmlSpace.ReplaceAllString(t.Data, " "))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch {
+			case htmlSkipped[t.DataAtom]:
+				if tt == html.StartTagToken {
+					skipDepth++
+				}
+			case htmlParagraphs[t.DataAtom]:
+				sb.WriteString("\n\n")
+			case t.DataAtom == atom.Li:
+				sb.WriteString("\n- ")
+			case htmlLines[t.DataAtom]:
+				sb.WriteString("\n")
+			case t.DataAtom == atom.Img:
+				for _, a := range t.Attr {
+					if a.Key == "alt" && strings.TrimSpace(a.Val) != "" {
+						sb.WriteString(" " + strings.TrimSpace(a.Val) + " ")
+					}
+				}
+			case t.DataAtom == atom.Td, t.DataAtom == atom.Th:
+				sb.WriteString(" ")
+			}
+		case html.EndTagToken:
+			switch {
+			case htmlSkipped[t.DataAtom]:
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			case htmlParagraphs[t.DataAtom]:
+				sb.WriteString("\n\n")
+			case htmlLines[t.DataAtom]:
+				sb.WriteString("\n")
+			}
+		}
+	}
+	txt = reHtmlLineSpace.ReplaceAllString(sb.String(), "\n")
+	txt = reHtmlParagraphs.ReplaceAllString(txt, "\n\n")
+	txt = strings.TrimSpace(txt)
+	return
+}
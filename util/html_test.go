@@ -0,0 +1,44 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHtmlToText(t *testing.T) {
+	cases := map[string]struct {
+		in  string
+		out string
+	}{
+		"plain": {
+			in:  "just   text",
+			out: "just text",
+		},
+		"paragraphs": {
+			in:  "<h1>Title</h1>\n<p>First <b>bold</b>\nline.</p><p>Second&nbsp;&amp; last.</p>",
+			out: "Title\n\nFirst bold line.\n\nSecond & last.",
+		},
+		"list and breaks": {
+			in:  "<p>Items:</p><ul><li>one</li><li>two</li></ul>end<br>of<br/>post",
+			out: "Items:\n\n- one\n- two\n\nend\nof\npost",
+		},
+		"scripts and styles": {
+			in:  "<style>p{color:red}</style><p>visible</p><script>alert(1)</script><noscript>no</noscript>",
+			out: "visible",
+		},
+		"image alt": {
+			in:  `<figure><img src="a.png" alt="a gopher"><figcaption>Fig. 1</figcaption></figure>`,
+			out: "a gopher\nFig. 1",
+		},
+		"unclosed": {
+			in:  "<div>broken <p>markup",
+			out: "broken\n\nmarkup",
+		},
+		"empty": {},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, c.out, HtmlToText(c.in))
+		})
+	}
+}
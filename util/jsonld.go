@@ -0,0 +1,456 @@
+package util
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/bytedance/sonic"
+	"path"
+	"sort"
+	"strings"
+)
+
+// The well-known JSON-LD contexts are bundled to never fetch these over the network.
+//
+//go:embed jsonld/*.jsonld
+var jsonLdFiles embed.FS
+
+const jsonLdFileCanonical = "canonical.jsonld"
+
+const iriNsAs = "https://www.w3.org/ns/activitystreams#"
+const iriAsPublic = iriNsAs + "Public"
+
+// jsonLdContextUrls maps the normalized well-known context URLs to the bundled files.
+var jsonLdContextUrls = map[string]string{
+	"www.w3.org/ns/activitystreams":        "activitystreams.jsonld",
+	"www.w3.org/ns/activitystreams.jsonld": "activitystreams.jsonld",
+	"w3id.org/security/v1":                 "security-v1.jsonld",
+	"w3id.org/security/v1.jsonld":          "security-v1.jsonld",
+	"joinmastodon.org/ns":                  "toot.jsonld",
+	"schema.org":                           "schema.jsonld",
+	"schema.org/docs/jsonldcontext.jsonld": "schema.jsonld",
+}
+
+// Pleroma and Akkoma serve the litepub context from every instance.
+const jsonLdContextPathLitepub = "/schemas/litepub-0.1.jsonld"
+
+const jsonLdContextDepthMax = 8
+const jsonLdContainerLanguage = "@language"
+
+var ErrJsonLd = errors.New("failed to normalize JSON-LD")
+
+var jsonLdApi = sonic.Config{
+	UseNumber:  true,
+	CopyString: true,
+}.Froze()
+
+type jsonLdTerm struct {
+	id        string
+	typeId    bool
+	container string
+	null      bool
+}
+
+// jsonLdContext is the active context. Every local context adds a new layer on top of the parent one.
+type jsonLdContext struct {
+	parent   *jsonLdContext
+	terms    map[string]jsonLdTerm
+	vocab    string
+	vocabSet bool
+}
+
+var jsonLdBundled = map[string]*jsonLdContext{}
+var jsonLdCanonical any
+var jsonLdCanonicalCtx *jsonLdContext
+
+// jsonLdReverse maps the expanded IRI to the canonical term.
+var jsonLdReverse = map[string]string{}
+
+func init() {
+	entries, err := jsonLdFiles.ReadDir("jsonld")
+	if err != nil {
+		panic(err)
+	}
+	raw := map[string]any{}
+	for _, e := range entries {
+		var data []byte
+		data, err = jsonLdFiles.ReadFile(path.Join("jsonld", e.Name()))
+		var doc map[string]any
+		if err == nil {
+			err = sonic.Unmarshal(data, &doc)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("%s: %s", e.Name(), err))
+		}
+		raw[e.Name()] = doc["@context"]
+	}
+	// the bundled contexts may refer each other, process these in the dependency order
+	var load func(name string, depth int) *jsonLdContext
+	load = func(name string, depth int) *jsonLdContext {
+		c, ok := jsonLdBundled[name]
+		if !ok && depth < jsonLdContextDepthMax {
+			c = (&jsonLdContext{}).with(raw[name], depth, func(ref string, depth int) *jsonLdContext {
+				if n := jsonLdContextFile(ref); n != "" {
+					return load(n, depth+1)
+				}
+				return nil
+			})
+			jsonLdBundled[name] = c
+		}
+		return c
+	}
+	for name := range raw {
+		load(name, 0)
+	}
+	jsonLdCanonical = raw[jsonLdFileCanonical]
+	jsonLdCanonicalCtx = jsonLdBundled[jsonLdFileCanonical]
+	for _, term := range jsonLdCanonicalCtx.names() {
+		t, _ := jsonLdCanonicalCtx.term(term)
+		k := jsonLdReverseKey(t.id, t.container)
+		// prefer the shortest term when several terms have the same IRI
+		if prev, found := jsonLdReverse[k]; !found || len(term) < len(prev) {
+			jsonLdReverse[k] = term
+		}
+	}
+}
+
+func jsonLdContextFile(ref string) (name string) {
+	u := strings.TrimPrefix(strings.TrimPrefix(ref, "https://"), "http://")
+	u = strings.TrimRight(u, "/#")
+	name = jsonLdContextUrls[u]
+	if name == "" && strings.HasSuffix(u, jsonLdContextPathLitepub) {
+		name = "litepub-0.1.jsonld"
+	}
+	return
+}
+
+func jsonLdBundledRef(ref string, _ int) (c *jsonLdContext) {
+	if n := jsonLdContextFile(ref); n != "" {
+		c = jsonLdBundled[n]
+	}
+	return
+}
+
+func jsonLdReverseKey(iri, container string) string {
+	if container != "" {
+		return iri + " " + container
+	}
+	return iri
+}
+
+// NormalizeJsonLd expands the JSON-LD document against the bundled contexts and compacts it to the canonical context.
+// Unlike the complete JSON-LD processing, the properties that can not be expanded are kept as is
+// and the remote contexts other than bundled are ignored.
+func NormalizeJsonLd(data []byte) (out []byte, err error) {
+	var doc map[string]any
+	err = jsonLdApi.Unmarshal(data, &doc)
+	if err == nil {
+		c := &jsonLdContext{}
+		if _, ok := doc["@context"]; !ok {
+			// the ActivityStreams context is implied when missing
+			c = jsonLdBundled["activitystreams.jsonld"]
+		}
+		doc = jsonLdNode(c, doc)
+		doc["@context"] = jsonLdCanonical
+		out, err = jsonLdApi.Marshal(doc)
+	}
+	if err != nil {
+		err = fmt.Errorf("%w: %s", ErrJsonLd, err)
+	}
+	return
+}
+
+// jsonLdNode compacts every property of the node. When several properties compact to the same term, the one already
+// having the compacted form wins, otherwise the first one in the lexicographical order of the source keys wins.
+func jsonLdNode(c *jsonLdContext, m map[string]any) (out map[string]any) {
+	if lc, ok := m["@context"]; ok {
+		c = c.with(lc, 0, jsonLdBundledRef)
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out = make(map[string]any, len(m))
+	for _, k := range keys {
+		v := m[k]
+		if k == "@context" {
+			continue
+		}
+		t, defined := c.term(k)
+		iri := c.expandIri(k, true)
+		kOut := jsonLdCompactKey(iri, t.container, k)
+		// the canonical definition helps when the source context doesn't tell the value type
+		tc, _ := jsonLdCanonicalCtx.term(kOut)
+		if !defined {
+			t.container = tc.container
+		}
+		t.typeId = t.typeId || tc.typeId
+		switch {
+		case iri == "@type":
+			v = jsonLdTypes(c, v)
+		case iri == "@id":
+			if s, ok := v.(string); ok {
+				v = c.expandIriValue(s)
+			}
+		case t.container == jsonLdContainerLanguage:
+		case t.typeId:
+			v = jsonLdIris(c, v)
+		default:
+			v = jsonLdValues(c, v)
+		}
+		if _, dup := out[kOut]; dup && kOut != k {
+			continue
+		}
+		out[kOut] = v
+	}
+	return
+}
+
+func jsonLdValues(c *jsonLdContext, v any) any {
+	switch vt := v.(type) {
+	case map[string]any:
+		if _, ok := vt["@value"]; !ok {
+			v = jsonLdNode(c, vt)
+		}
+	case []any:
+		for i, item := range vt {
+			vt[i] = jsonLdValues(c, item)
+		}
+	}
+	return v
+}
+
+func jsonLdIris(c *jsonLdContext, v any) any {
+	switch vt := v.(type) {
+	case string:
+		v = c.expandIriValue(vt)
+	case map[string]any:
+		v = jsonLdNode(c, vt)
+	case []any:
+		for i, item := range vt {
+			vt[i] = jsonLdIris(c, item)
+		}
+	}
+	return v
+}
+
+func jsonLdTypes(c *jsonLdContext, v any) any {
+	switch vt := v.(type) {
+	case string:
+		v = jsonLdCompactKey(c.expandIri(vt, true), "", vt)
+	case []any:
+		for i, item := range vt {
+			if s, ok := item.(string); ok {
+				vt[i] = jsonLdCompactKey(c.expandIri(s, true), "", s)
+			}
+		}
+	}
+	return v
+}
+
+func jsonLdCompactKey(iri, container, orig string) (term string) {
+	term, ok := jsonLdReverse[jsonLdReverseKey(iri, container)]
+	switch {
+	case ok:
+	case strings.HasPrefix(iri, iriNsAs) && container == "":
+		term = strings.TrimPrefix(iri, iriNsAs)
+	default:
+		term = orig
+	}
+	return
+}
+
+func (c *jsonLdContext) term(name string) (t jsonLdTerm, ok bool) {
+	for l := c; l != nil && !ok; l = l.parent {
+		t, ok = l.terms[name]
+	}
+	if t.null {
+		t, ok = jsonLdTerm{}, false
+	}
+	return
+}
+
+func (c *jsonLdContext) names() (names []string) {
+	seen := map[string]bool{}
+	for l := c; l != nil; l = l.parent {
+		for name := range l.terms {
+			if !seen[name] {
+				seen[name] = true
+				if t, _ := c.term(name); t.id != "" {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+func (c *jsonLdContext) vocabIri() (v string) {
+	for l := c; l != nil; l = l.parent {
+		if l.vocabSet {
+			v = l.vocab
+			break
+		}
+	}
+	return
+}
+
+// expandIri resolves the term or the compact IRI.
+// Only the vocabulary relative values, such as property names and types, may be terms.
+func (c *jsonLdContext) expandIri(s string, vocabRel bool) string {
+	if strings.HasPrefix(s, "@") {
+		return s
+	}
+	if vocabRel {
+		if t, ok := c.term(s); ok {
+			return t.id
+		}
+	}
+	if i := strings.IndexByte(s, ':'); i > 0 {
+		prefix, suffix := s[:i], s[i+1:]
+		if prefix == "_" || strings.HasPrefix(suffix, "//") {
+			return s
+		}
+		if t, ok := c.term(prefix); ok && t.id != "" {
+			return t.id + suffix
+		}
+		return s
+	}
+	if vocabRel {
+		if v := c.vocabIri(); v != "" {
+			return v + s
+		}
+	}
+	return s
+}
+
+// expandIriValue also accepts the bare "Public" that some implementations use for the public collection.
+func (c *jsonLdContext) expandIriValue(s string) (iri string) {
+	iri = c.expandIri(s, false)
+	if iri == "Public" {
+		iri = iriAsPublic
+	}
+	return
+}
+
+// with returns the new active context after processing the local context.
+func (c *jsonLdContext) with(lc any, depth int, resolve func(ref string, depth int) *jsonLdContext) (result *jsonLdContext) {
+	result = c
+	switch lct := lc.(type) {
+	case nil:
+		result = &jsonLdContext{}
+	case string:
+		if remote := resolve(lct, depth); remote != nil {
+			result = c.merge(remote)
+		}
+	case []any:
+		for _, item := range lct {
+			result = result.with(item, depth, resolve)
+		}
+	case map[string]any:
+		result = c.define(lct)
+	}
+	return
+}
+
+// merge puts the layers of the other context on top of this one.
+func (c *jsonLdContext) merge(other *jsonLdContext) (result *jsonLdContext) {
+	result = c
+	var layers []*jsonLdContext
+	for l := other; l != nil; l = l.parent {
+		layers = append(layers, l)
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		result = &jsonLdContext{
+			parent:   result,
+			terms:    l.terms,
+			vocab:    l.vocab,
+			vocabSet: l.vocabSet,
+		}
+	}
+	return
+}
+
+func (c *jsonLdContext) define(defs map[string]any) (result *jsonLdContext) {
+	result = &jsonLdContext{
+		parent: c,
+		terms:  make(map[string]jsonLdTerm, len(defs)),
+	}
+	raw := make(map[string]string, len(defs))
+	for name, def := range defs {
+		switch name {
+		case "@vocab":
+			if v, ok := def.(string); ok {
+				result.vocab = v
+			}
+			result.vocabSet = true
+			continue
+		}
+		if strings.HasPrefix(name, "@") {
+			continue
+		}
+		var t jsonLdTerm
+		switch dt := def.(type) {
+		case nil:
+			t.null = true
+		case string:
+			raw[name] = dt
+		case map[string]any:
+			id, _ := dt["@id"].(string)
+			if id == "" {
+				id = name
+			}
+			raw[name] = id
+			switch dt["@type"] {
+			case "@id", "@vocab":
+				t.typeId = true
+			}
+			t.container, _ = dt["@container"].(string)
+		default:
+			continue
+		}
+		result.terms[name] = t
+	}
+	// the term definitions may use the prefixes defined in the same local context
+	if result.vocabSet {
+		result.vocab = result.expandRaw(result.vocab, raw, 0)
+	}
+	for name, id := range raw {
+		t := result.terms[name]
+		t.id = result.expandRaw(id, raw, 0)
+		result.terms[name] = t
+	}
+	return
+}
+
+func (c *jsonLdContext) expandRaw(id string, raw map[string]string, depth int) string {
+	if strings.HasPrefix(id, "@") {
+		return id
+	}
+	if i := strings.IndexByte(id, ':'); i > 0 {
+		prefix, suffix := id[:i], id[i+1:]
+		if prefix == "_" || strings.HasPrefix(suffix, "//") {
+			return id
+		}
+		if p, ok := raw[prefix]; ok && depth < jsonLdContextDepthMax {
+			return c.expandRaw(p, raw, depth+1) + suffix
+		}
+		if t, ok := c.parent.term(prefix); ok && t.id != "" {
+			return t.id + suffix
+		}
+		return id
+	}
+	if p, ok := raw[id]; ok && p != id && depth < jsonLdContextDepthMax {
+		return c.expandRaw(p, raw, depth+1)
+	}
+	if t, ok := c.parent.term(id); ok {
+		return t.id
+	}
+	if v := c.vocabIri(); v != "" {
+		return v + id
+	}
+	return id
+}
@@ -0,0 +1,284 @@
+package util
+
+import (
+	"github.com/bytedance/sonic"
+	vocab "github.com/go-ap/activitypub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeJsonLd(t *testing.T) {
+	cases := map[string]struct {
+		in  string
+		out map[string]any
+		err error
+	}{
+		"compact prefixed keys": {
+			in: `{
+  "@context": "https://www.w3.org/ns/activitystreams",
+  "as:id": "https://host.social/notes/1",
+  "@type": "as:Note",
+  "as:content": "hello",
+  "as:to": "as:Public"
+}`,
+			out: map[string]any{
+				"id":      "https://host.social/notes/1",
+				"type":    "Note",
+				"content": "hello",
+				"to":      iriAsPublic,
+			},
+		},
+		"aliased terms": {
+			in: `{
+  "@context": [
+    "https://www.w3.org/ns/activitystreams",
+    {
+      "body": "as:content",
+      "recipients": {"@id": "as:to", "@type": "@id"},
+      "Post": "as:Note",
+      "nsfw": "as:sensitive"
+    }
+  ],
+  "type": "Post",
+  "body": "hello",
+  "recipients": ["Public", "https://host.social/users/john/followers"],
+  "nsfw": true
+}`,
+			out: map[string]any{
+				"type":      "Note",
+				"content":   "hello",
+				"to":        []any{iriAsPublic, "https://host.social/users/john/followers"},
+				"sensitive": true,
+			},
+		},
+		"bare public in cc": {
+			in: `{
+  "@context": "https://www.w3.org/ns/activitystreams",
+  "type": "Note",
+  "cc": ["Public"]
+}`,
+			out: map[string]any{
+				"type": "Note",
+				"cc":   []any{iriAsPublic},
+			},
+		},
+		"language map is kept": {
+			in: `{
+  "@context": "https://www.w3.org/ns/activitystreams",
+  "type": "Note",
+  "contentMap": {"en": "hello", "type": "not a property"}
+}`,
+			out: map[string]any{
+				"type": "Note",
+				"contentMap": map[string]any{
+					"en":   "hello",
+					"type": "not a property",
+				},
+			},
+		},
+		"implied context": {
+			in: `{"type": "Note", "as:content": "hello", "to": "as:Public"}`,
+			out: map[string]any{
+				"type":    "Note",
+				"content": "hello",
+				"to":      iriAsPublic,
+			},
+		},
+		"unknown remote context and property": {
+			in: `{
+  "@context": ["https://www.w3.org/ns/activitystreams", "https://unknown.social/context.json"],
+  "type": "Note",
+  "foo": "bar"
+}`,
+			out: map[string]any{
+				"type": "Note",
+				"foo":  "bar",
+			},
+		},
+		"nested objects and extension namespace": {
+			in: `{
+  "@context": [
+    "https://www.w3.org/ns/activitystreams",
+    {"t": "http://joinmastodon.org/ns#", "h": "as:Hashtag"}
+  ],
+  "type": "Create",
+  "object": {
+    "type": "Note",
+    "tag": [{"type": "h", "name": "#golang"}, {"type": "t:Emoji", "name": ":cat:"}],
+    "t:blurhash": "xxx"
+  }
+}`,
+			out: map[string]any{
+				"type": "Create",
+				"object": map[string]any{
+					"type": "Note",
+					"tag": []any{
+						map[string]any{"type": "Hashtag", "name": "#golang"},
+						map[string]any{"type": "Emoji", "name": ":cat:"},
+					},
+					"blurhash": "xxx",
+				},
+			},
+		},
+		"litepub context": {
+			in: `{
+  "@context": ["https://pleroma.example/schemas/litepub-0.1.jsonld", {"@language": "und"}],
+  "type": "Create",
+  "litepub:directMessage": false
+}`,
+			out: map[string]any{
+				"type":          "Create",
+				"directMessage": false,
+			},
+		},
+		"invalid": {
+			in:  `{"type": `,
+			err: ErrJsonLd,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			out, err := NormalizeJsonLd([]byte(c.in))
+			assert.ErrorIs(t, err, c.err)
+			if c.err == nil {
+				var m map[string]any
+				require.Nil(t, sonic.Unmarshal(out, &m))
+				assert.NotNil(t, m["@context"])
+				delete(m, "@context")
+				assert.Equal(t, c.out, m)
+			}
+		})
+	}
+}
+
+func TestNormalizeJsonLd_Collisions(t *testing.T) {
+	cases := map[string]struct {
+		in      string
+		content any
+	}{
+		"compacted term wins over the prefixed and full iris": {
+			in: `{
+  "@context": "https://www.w3.org/ns/activitystreams",
+  "type": "Note",
+  "https://www.w3.org/ns/activitystreams#content": "full",
+  "content": "term",
+  "as:content": "prefixed"
+}`,
+			content: "term",
+		},
+		"compacted term wins over the alias": {
+			in: `{
+  "@context": ["https://www.w3.org/ns/activitystreams", {"body": "as:content"}],
+  "type": "Note",
+  "body": "alias",
+  "content": "term"
+}`,
+			content: "term",
+		},
+		"prefixed iri wins over the full iri": {
+			in: `{
+  "@context": "https://www.w3.org/ns/activitystreams",
+  "type": "Note",
+  "https://www.w3.org/ns/activitystreams#content": "full",
+  "as:content": "prefixed"
+}`,
+			content: "prefixed",
+		},
+		"aliases in the lexicographical order": {
+			in: `{
+  "@context": ["https://www.w3.org/ns/activitystreams", {"text": "as:content", "body": "as:content"}],
+  "type": "Note",
+  "text": "text",
+  "body": "body"
+}`,
+			content: "body",
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			// the map iteration order is random, so every run should give the same result
+			for i := 0; i < 100; i++ {
+				out, err := NormalizeJsonLd([]byte(c.in))
+				require.Nil(t, err)
+				var m map[string]any
+				require.Nil(t, sonic.Unmarshal(out, &m))
+				require.Equal(t, c.content, m["content"])
+			}
+		})
+	}
+}
+
+func TestNormalizeJsonLd_Numbers(t *testing.T) {
+	out, err := NormalizeJsonLd([]byte(`{"type": "Image", "width": 12345678901234567890, "height": 0.5}`))
+	require.Nil(t, err)
+	assert.Contains(t, string(out), `"width":12345678901234567890`)
+	assert.Contains(t, string(out), `"height":0.5`)
+}
+
+func TestNormalizeJsonLd_Corpus(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "inbox", "*.json"))
+	require.Nil(t, err)
+	require.NotEmpty(t, files)
+	for _, f := range files {
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			data, err := os.ReadFile(f)
+			require.Nil(t, err)
+			var envOrig, envNorm Envelope
+			envOrig, err = DecodeEnvelope(data)
+			require.Nil(t, err)
+			var norm []byte
+			norm, err = NormalizeJsonLd(data)
+			require.Nil(t, err)
+			envNorm, err = DecodeEnvelope(norm)
+			require.Nil(t, err)
+			// the payloads using the default context should decode the same
+			assert.Equal(t, envOrig.Activity.Type, envNorm.Activity.Type)
+			assert.Equal(t, envOrig.Activity.ID, envNorm.Activity.ID)
+			assert.Equal(t, envOrig.Activity.To, envNorm.Activity.To)
+			assert.Equal(t, envOrig.Activity.CC, envNorm.Activity.CC)
+			assert.Equal(t, envOrig.Tags, envNorm.Tags)
+			assert.Equal(t, envOrig.Object.Tags, envNorm.Object.Tags)
+			assert.Equal(t, envOrig.Object.ContentMap, envNorm.Object.ContentMap)
+			assert.Equal(t, len(envOrig.Object.Extra), len(envNorm.Object.Extra))
+		})
+	}
+}
+
+func TestNormalizeJsonLd_AsPrefixed(t *testing.T) {
+	in := `{
+  "@context": ["https://www.w3.org/ns/activitystreams", "https://w3id.org/security/v1"],
+  "@id": "https://host.social/activities/1",
+  "@type": "as:Create",
+  "as:actor": "https://host.social/users/john",
+  "as:to": ["as:Public"],
+  "as:object": {
+    "@id": "https://host.social/notes/1",
+    "@type": "as:Note",
+    "as:content": "hello #golang",
+    "as:tag": [{"@type": "as:Hashtag", "as:name": "#golang", "as:href": "https://host.social/tags/golang"}]
+  }
+}`
+	out, err := NormalizeJsonLd([]byte(in))
+	require.Nil(t, err)
+	var env Envelope
+	env, err = DecodeEnvelope(out)
+	require.Nil(t, err)
+	assert.Equal(t, vocab.CreateType, env.Activity.Type)
+	assert.Equal(t, vocab.IRI("https://host.social/users/john"), env.Activity.Actor.GetLink())
+	assert.Equal(t, vocab.ItemCollection{vocab.PublicNS}, env.Activity.To)
+	assert.Equal(t, vocab.NoteType, env.Activity.Object.GetType())
+	assert.Equal(t, []ActivityTag{{Type: "Hashtag", Name: "#golang", Href: "https://host.social/tags/golang"}}, env.Object.Tags)
+}
+
+func BenchmarkNormalizeJsonLd(b *testing.B) {
+	corpus := loadCorpus(b, "inbox")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NormalizeJsonLd(corpus[i%len(corpus)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package util
+
+import (
+	"bufio"
+	"embed"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// langProfilesFs contains the n-gram language profiles, one file per language code: the comment line, the total count of
+// the corpus n-grams and the most frequent n-grams with their counts. See TestBuildLangProfiles to rebuild them.
+//
+//go:embed langprofiles/*.txt
+var langProfilesFs embed.FS
+
+// langNgramLenMax is the longest n-gram, the shorter ones are counted too, so the letter frequencies matter
+const langNgramLenMax = 3
+
+// langNgramsMin is the minimum count of the text's n-grams to guess the language of the alphabet shared by many.
+const langNgramsMin = 40
+
+// LangConfidenceMin is the minimum confidence of the detected language, no language is returned below it.
+const LangConfidenceMin = 0.5
+
+const langScriptLatin = "latin"
+const langScriptCyrillic = "cyrillic"
+
+// langScripts are the writing systems used mostly by the single language.
+var langScripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Thai, "th"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Arabic, "ar"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Bengali, "bn"},
+	{unicode.Tamil, "ta"},
+	{unicode.Georgian, "ka"},
+	{unicode.Armenian, "hy"},
+}
+
+// langPersianLetters are the Arabic script letters absent in Arabic: پ چ ژ گ ک ی
+const langPersianLetters = "پچژگکی"
+
+type langProfile struct {
+	lang      string
+	script    string
+	logProbs  map[string]float64
+	logUnseen float64
+}
+
+var langProfiles []langProfile
+var langProfilesOnce sync.Once
+
+// DetectLanguage guesses the ISO 639-1 language code of the plain text and the guess confidence from 0 to 1.
+// The language of the alphabet shared by many (Latin, Cyrillic) is guessed by the character n-grams, the others are
+// identified by the writing system. Returns the empty language when the text is too short, has no letters or the
+// confidence is below LangConfidenceMin.
+func DetectLanguage(txt string) (lang string, confidence float64) {
+	var letters int
+	counts := map[string]int{}
+	for _, r := range txt {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		counts[runeScript(r)]++
+	}
+	var script string
+	for s, c := range counts {
+		if c > counts[script] || c == counts[script] && s < script {
+			script = s
+		}
+	}
+	switch script {
+	case "":
+		return
+	case langScriptLatin, langScriptCyrillic:
+		lang, confidence = detectLanguageNgrams(txt, script)
+	case "zh":
+		// the Japanese text mixes the kanji and the kana
+		if counts["ja"] > 0 {
+			script = "ja"
+		}
+		lang, confidence = script, 1
+	case "ar":
+		lang, confidence = script, 1
+		if strings.ContainsAny(txt, langPersianLetters) {
+			lang = "fa"
+		}
+	default:
+		lang, confidence = script, 1
+	}
+	if script == "ja" {
+		counts[script] += counts["zh"]
+	}
+	confidence *= float64(counts[script]) / float64(letters)
+	if confidence < LangConfidenceMin {
+		lang, confidence = "", 0
+	}
+	return
+}
+
+func runeScript(r rune) (script string) {
+	switch {
+	case unicode.Is(unicode.Latin, r):
+		script = langScriptLatin
+	case unicode.Is(unicode.Cyrillic, r):
+		script = langScriptCyrillic
+	default:
+		for _, s := range langScripts {
+			if unicode.Is(s.table, r) {
+				script = s.lang
+				break
+			}
+		}
+	}
+	return
+}
+
+// detectLanguageNgrams scores the text's n-grams against every language profile of the script and returns the most
+// probable language having its posterior probability as the confidence.
+func detectLanguageNgrams(txt, script string) (lang string, confidence float64) {
+	langProfilesOnce.Do(loadLangProfiles)
+	ngrams := langNgrams(txt, script)
+	if len(ngrams) < langNgramsMin {
+		return
+	}
+	var langs []string
+	var scores []float64
+	for _, p := range langProfiles {
+		if p.script != script {
+			continue
+		}
+		var score float64
+		for _, ng := range ngrams {
+			logProb, found := p.logProbs[ng]
+			if !found {
+				logProb = p.logUnseen
+			}
+			score += logProb
+		}
+		langs = append(langs, p.lang)
+		scores = append(scores, score)
+	}
+	if len(langs) == 0 {
+		return
+	}
+	best := 0
+	for i, score := range scores {
+		if score > scores[best] {
+			best = i
+		}
+	}
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - scores[best])
+	}
+	lang, confidence = langs[best], 1/sum
+	return
+}
+
+// langNgrams splits the lower case text into the words of the script's letters, every word is padded with spaces.
+func langNgrams(txt, script string) (ngrams []string) {
+	words := strings.FieldsFunc(strings.ToLower(txt), func(r rune) bool {
+		return !unicode.IsLetter(r) || runeScript(r) != script
+	})
+	for _, w := range words {
+		runes := []rune(" " + w + " ")
+		for n := 1; n <= langNgramLenMax; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				if ng := string(runes[i : i+n]); ng != " " {
+					ngrams = append(ngrams, ng)
+				}
+			}
+		}
+	}
+	return
+}
+
+func loadLangProfiles() {
+	entries, _ := langProfilesFs.ReadDir("langprofiles")
+	for _, e := range entries {
+		data, err := langProfilesFs.ReadFile(path.Join("langprofiles", e.Name()))
+		if err != nil {
+			continue
+		}
+		p := langProfile{
+			lang:     strings.TrimSuffix(e.Name(), path.Ext(e.Name())),
+			script:   langScriptLatin,
+			logProbs: map[string]float64{},
+		}
+		var total float64
+		countMin := math.MaxFloat64
+		lines := bufio.NewScanner(strings.NewReader(string(data)))
+		for lines.Scan() {
+			line := lines.Text()
+			ng, count, found := strings.Cut(line, "\t")
+			switch {
+			case strings.HasPrefix(line, "#"):
+			case !found:
+				total, _ = strconv.ParseFloat(line, 64)
+			default:
+				c, _ := strconv.ParseFloat(count, 64)
+				p.logProbs[ng] = c
+				countMin = min(countMin, c)
+				if runeScript([]rune(ng)[0]) == langScriptCyrillic {
+					p.script = langScriptCyrillic
+				}
+			}
+		}
+		if total <= 0 || len(p.logProbs) == 0 {
+			continue
+		}
+		for ng, c := range p.logProbs {
+			p.logProbs[ng] = math.Log(c / total)
+		}
+		// the n-grams rarer than the least frequent kept one are dropped, the unseen ones get the half of its weight
+		p.logUnseen = math.Log(0.5 * countMin / total)
+		langProfiles = append(langProfiles, p)
+	}
+}
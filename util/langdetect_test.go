@@ -0,0 +1,166 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]struct {
+		in            string
+		lang          string
+		confidenceMin float64
+		confidenceMax float64
+	}{
+		"empty": {},
+		"no letters": {
+			in: "12:30 🎉 :-)",
+		},
+		"too short": {
+			in: "gm",
+		},
+		"below the confidence threshold": {
+			in: "今日はとても良い天気ですね, nice weather",
+		},
+		"en": {
+			in:            "Just released a new version of my side project, check it out!",
+			lang:          "en",
+			confidenceMin: 0.9,
+		},
+		"de": {
+			in:            "Ich freue mich schon auf das Wochenende mit meinen Freunden",
+			lang:          "de",
+			confidenceMin: 0.9,
+		},
+		"fr": {
+			in:            "Je suis tellement fatigué aujourd'hui, vivement les vacances",
+			lang:          "fr",
+			confidenceMin: 0.9,
+		},
+		"es": {
+			in:            "¿Alguien sabe dónde puedo comprar entradas para el concierto?",
+			lang:          "es",
+			confidenceMin: 0.9,
+		},
+		"pt": {
+			in:            "Alguém sabe onde posso comprar ingressos para o show?",
+			lang:          "pt",
+			confidenceMin: 0.9,
+		},
+		"pl": {
+			in:            "Nie mogę się doczekać weekendu, pojedziemy w góry",
+			lang:          "pl",
+			confidenceMin: 0.9,
+		},
+		"ru": {
+			in:            "Кто-нибудь знает хороший ресторан в центре города?",
+			lang:          "ru",
+			confidenceMin: 0.9,
+		},
+		"uk": {
+			in:            "Сьогодні вийшла нова версія нашої програми",
+			lang:          "uk",
+			confidenceMin: 0.9,
+		},
+		"bg": {
+			in:            "Днес излезе новата версия на нашата програма",
+			lang:          "bg",
+			confidenceMin: 0.9,
+		},
+		"ja": {
+			in:            "今日はとても良い天気ですね",
+			lang:          "ja",
+			confidenceMin: 0.9,
+		},
+		"zh": {
+			in:            "今天天气很好，我们去公园吧",
+			lang:          "zh",
+			confidenceMin: 0.9,
+		},
+		"ko": {
+			in:            "오늘 날씨가 정말 좋네요",
+			lang:          "ko",
+			confidenceMin: 0.9,
+		},
+		"ar": {
+			in:            "صباح الخير للجميع",
+			lang:          "ar",
+			confidenceMin: 0.9,
+		},
+		"fa": {
+			in:            "سلام به همه دوستان، امروز هوا خیلی خوب است",
+			lang:          "fa",
+			confidenceMin: 0.9,
+		},
+		"mixed scripts lower the confidence": {
+			in:            "今日はとても良い天気ですね, a really nice weather today",
+			lang:          "en",
+			confidenceMin: 0.5,
+			confidenceMax: 0.8,
+		},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			lang, confidence := DetectLanguage(c.in)
+			assert.Equal(t, c.lang, lang)
+			assert.GreaterOrEqual(t, confidence, c.confidenceMin)
+			if c.confidenceMax > 0 {
+				assert.LessOrEqual(t, confidence, c.confidenceMax)
+			} else {
+				assert.LessOrEqual(t, confidence, 1.0)
+			}
+			if c.lang == "" {
+				assert.Zero(t, confidence)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage_ClosePairs(t *testing.T) {
+	cases := map[string][]string{
+		"es": {
+			"Hoy por fin terminé de leer el libro que me recomendaste, me encantó el final",
+			"¿Alguien sabe si mañana abren las tiendas del centro? Necesito comprar un regalo",
+			"Qué bonito atardecer desde la playa, ojalá pudiera quedarme aquí todo el verano",
+			"Llevamos tres horas esperando el tren y nadie nos dice nada, vaya desastre",
+			"Mi abuela cocina la mejor tortilla del mundo y nunca me quiere dar la receta",
+		},
+		"pt": {
+			"Hoje finalmente terminei de ler o livro que você me recomendou, adorei o final",
+			"Alguém sabe se amanhã as lojas do centro vão abrir? Preciso comprar um presente",
+			"Que pôr do sol lindo na praia, queria poder ficar aqui o verão inteiro",
+			"Estamos há três horas esperando o trem e ninguém nos diz nada, que desastre",
+			"Minha avó faz o melhor bolo do mundo e nunca quer me passar a receita",
+		},
+		"it": {
+			"Oggi ho finalmente finito di leggere il libro che mi hai consigliato, il finale è bellissimo",
+			"Qualcuno sa se domani i negozi del centro sono aperti? Devo comprare un regalo",
+			"Che tramonto meraviglioso dalla spiaggia, vorrei restare qui tutta l'estate",
+			"Siamo in stazione da tre ore ad aspettare il treno e nessuno ci dice niente",
+			"Mia nonna cucina le migliori lasagne del mondo e non mi vuole mai dare la ricetta",
+		},
+		"cs": {
+			"Dneska jsem konečně dočetl tu knihu, kterou jsi mi doporučil, konec byl skvělý",
+			"Neví někdo, jestli budou zítra otevřené obchody v centru? Potřebuju koupit dárek",
+			"Krásný západ slunce nad horami, nejradši bych tady zůstal celé léto",
+			"Čekáme na vlak už tři hodiny a nikdo nám nic neřekne, to je hrůza",
+			"Moje babička peče nejlepší koláče na světě a nikdy mi nechce dát recept",
+		},
+		"pl": {
+			"Dzisiaj w końcu skończyłem czytać książkę, którą mi poleciłeś, zakończenie było świetne",
+			"Czy ktoś wie, czy jutro sklepy w centrum będą otwarte? Muszę kupić prezent",
+			"Piękny zachód słońca nad górami, najchętniej zostałbym tu całe lato",
+			"Czekamy na pociąg już trzy godziny i nikt nam nic nie mówi, to jest koszmar",
+			"Moja babcia piecze najlepsze ciasta na świecie i nigdy nie chce mi dać przepisu",
+		},
+	}
+	for lang, txts := range cases {
+		for _, txt := range txts {
+			t.Run(lang+": "+txt, func(t *testing.T) {
+				detected, confidence := DetectLanguage(txt)
+				assert.Equal(t, lang, detected)
+				assert.GreaterOrEqual(t, confidence, LangConfidenceMin)
+			})
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package util
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+var updateLangProfiles = flag.Bool("update-langprofiles", false, "rebuild the language profiles from the gettext catalogs")
+var localesDir = flag.String("locales", "/usr/share/locale", "the gettext catalogs directory to build the profiles from")
+
+// langProfileLen is the count of the most frequent n-grams kept in the profile.
+const langProfileLen = 3000
+
+const moMagic = 0x950412de
+
+var reMoPlaceholder = regexp.MustCompile(`%(\d+\$)?[-+ #0']*(\d+|\*)?(\.(\d+|\*))?[hlLqjzt]*[a-zA-Z%]|%\([^)]*\)[a-z]|\{[^}]*}|<[^>]*>|\$\{?\w+}?`)
+var reMoAccelerator = regexp.MustCompile(`[_&~](\pL)`)
+
+// TestBuildLangProfiles rebuilds langprofiles/*.txt from the human translated gettext catalogs of the system packages,
+// the English one is built from the catalogs' source messages. Run with -update-langprofiles after changing the n-grams
+// or the languages. The result depends on the installed catalogs: langprofiles/CATALOGS lists the packages the committed
+// profiles are built from. The catalogs are the user interface messages, not the social posts, so the word frequencies
+// differ, but the character n-grams of the language are mostly the same.
+func TestBuildLangProfiles(t *testing.T) {
+	if !*updateLangProfiles {
+		t.Skip("run with -update-langprofiles to rebuild the language profiles")
+	}
+	entries, err := os.ReadDir("langprofiles")
+	require.Nil(t, err)
+	dirs, err := os.ReadDir(*localesDir)
+	require.Nil(t, err)
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) != ".txt" {
+			continue
+		}
+		lang := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+		var catalogs []string
+		for _, d := range dirs {
+			name := d.Name()
+			if lang == "en" || name == lang || strings.HasPrefix(name, lang+"_") && !strings.Contains(name, "@") {
+				found, _ := filepath.Glob(filepath.Join(*localesDir, name, "LC_MESSAGES", "*.mo"))
+				catalogs = append(catalogs, found...)
+			}
+		}
+		require.NotEmpty(t, catalogs, "no gettext catalogs of %s in %s, install the packages from langprofiles/CATALOGS", lang, *localesDir)
+		msgs := map[string]struct{}{}
+		for _, c := range catalogs {
+			var pairs [][2]string
+			pairs, err = readMo(c)
+			require.Nil(t, err, c)
+			for _, p := range pairs {
+				switch {
+				case lang == "en":
+					msgs[p[0]] = struct{}{}
+				case p[1] != "" && p[1] != p[0]:
+					msgs[p[1]] = struct{}{}
+				}
+			}
+		}
+		script := langScriptLatin
+		if lang == "bg" || lang == "ru" || lang == "uk" {
+			script = langScriptCyrillic
+		}
+		counts := map[string]int{}
+		var total int
+		for msg := range msgs {
+			for _, ng := range langNgrams(moText(msg), script) {
+				counts[ng]++
+				total++
+			}
+		}
+		require.Nil(t, writeLangProfile(filepath.Join("langprofiles", e.Name()), len(catalogs), total, counts))
+	}
+}
+
+// readMo returns the source and the translated messages of the compiled gettext catalog, the plural forms are skipped.
+func readMo(file string) (pairs [][2]string, err error) {
+	var data []byte
+	data, err = os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	if len(data) < 20 {
+		err = errors.New("too short")
+		return
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(data) != moMagic {
+		order = binary.BigEndian
+	}
+	n := int(order.Uint32(data[8:]))
+	offSrc, offDst := int(order.Uint32(data[12:])), int(order.Uint32(data[16:]))
+	str := func(tbl, i int) (s string, ok bool) {
+		pos := tbl + 8*i
+		if pos+8 > len(data) {
+			return
+		}
+		l, o := int(order.Uint32(data[pos:])), int(order.Uint32(data[pos+4:]))
+		if o+l > len(data) {
+			return
+		}
+		s, _, _ = strings.Cut(string(data[o:o+l]), "\x00")
+		ok = true
+		return
+	}
+	for i := 0; i < n; i++ {
+		src, okSrc := str(offSrc, i)
+		dst, okDst := str(offDst, i)
+		if okSrc && okDst && src != "" {
+			if _, msg, found := strings.Cut(src, "\x04"); found { // skip the message context
+				src = msg
+			}
+			pairs = append(pairs, [2]string{src, dst})
+		}
+	}
+	return
+}
+
+// moText keeps the natural language words of the message: the placeholders, the options, the file names, the upper
+// case metavariables and the like are dropped.
+func moText(msg string) string {
+	msg = reMoPlaceholder.ReplaceAllString(msg, " ")
+	msg = reMoAccelerator.ReplaceAllString(msg, "$1")
+	var words []string
+	for _, w := range strings.Fields(msg) {
+		w = strings.TrimFunc(w, func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+		if w == "" || w == strings.ToUpper(w) && len([]rune(w)) > 1 {
+			continue
+		}
+		natural := true
+		for _, r := range w {
+			if !unicode.IsLetter(r) && r != '\'' && r != '’' && r != '-' {
+				natural = false
+				break
+			}
+		}
+		if natural {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func writeLangProfile(file string, catalogs, total int, counts map[string]int) (err error) {
+	ngrams := make([]string, 0, len(counts))
+	for ng := range counts {
+		ngrams = append(ngrams, ng)
+	}
+	sort.Slice(ngrams, func(i, j int) bool {
+		ci, cj := counts[ngrams[i]], counts[ngrams[j]]
+		return ci > cj || ci == cj && ngrams[i] < ngrams[j]
+	})
+	ngrams = ngrams[:min(len(ngrams), langProfileLen)]
+	var f *os.File
+	f, err = os.Create(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	_, _ = fmt.Fprintf(w, "# the most frequent n-grams of %d gettext catalogs, see TestBuildLangProfiles\n", catalogs)
+	_, _ = fmt.Fprintf(w, "%d\n", total)
+	for _, ng := range ngrams {
+		_, _ = fmt.Fprintf(w, "%s\t%d\n", ng, counts[ng])
+	}
+	err = w.Flush()
+	return
+}
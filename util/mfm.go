@@ -0,0 +1,53 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MediaTypeMfm is the source media type of the Misskey-family servers (Misskey, Sharkey, Firefish, Iceshrimp).
+const MediaTypeMfm = "text/x.misskeymarkdown"
+
+var reMfmCodeBlock = regexp.MustCompile("(?s)```[^\n]*\n(.*?)\n?```")
+var reMfmCodeInline = regexp.MustCompile("`([^`\n]+)`")
+var reMfmLink = regexp.MustCompile(`\??\[([^\[\]\n]*)\]\(<?(https?://[^\s)>]+)>?\)`)
+var reMfmFn = regexp.MustCompile(`\$\[[A-Za-z0-9_]+(?:\.[^\s\[\]]*)? ([^\[\]]*)\]`)
+var reMfmTag = regexp.MustCompile(`</?(?:b|i|s|small|center|plain|sup|sub)>`)
+var reMfmBold = regexp.MustCompile(`\*\*\*?([^*\n]+?)\*?\*\*|__([^_\n]+?)__`)
+var reMfmStrike = regexp.MustCompile(`~~([^~\n]+?)~~`)
+var reMfmMath = regexp.MustCompile(`\\\((.+?)\\\)`)
+var reMfmSearch = regexp.MustCompile(`(?m)^(.*\S) (?:\[(?:Search|検索)\]|Search|検索)$`)
+var reMfmQuote = regexp.MustCompile(`(?m)^> ?`)
+var reMfmLineSpace = regexp.MustCompile(`[ \t]*\n`)
+
+// MfmToText renders the Misskey Flavored Markdown source to the plain text, see https://misskey-hub.net/en/docs/for-users/features/mfm/
+// The decorations and the animation functions are dropped keeping the decorated text, the links are replaced with
+// their labels. The mentions, the hashtags and the custom emoji shortcodes are left as is.
+func MfmToText(src string) (txt string) {
+	txt = reMfmCodeBlock.ReplaceAllString(src, "$1")
+	txt = reMfmCodeInline.ReplaceAllString(txt, "$1")
+	txt = reMfmLink.ReplaceAllStringFunc(txt, func(l string) string {
+		m := reMfmLink.FindStringSubmatch(l)
+		if m[1] == "" {
+			return m[2]
+		}
+		return m[1]
+	})
+	// the functions may be nested, unwrap the innermost ones until nothing is left
+	for {
+		unwrapped := reMfmFn.ReplaceAllString(txt, "$1")
+		if unwrapped == txt {
+			break
+		}
+		txt = unwrapped
+	}
+	txt = reMfmTag.ReplaceAllString(txt, "")
+	txt = reMfmBold.ReplaceAllString(txt, "$1$2")
+	txt = reMfmStrike.ReplaceAllString(txt, "$1")
+	txt = reMfmMath.ReplaceAllString(txt, "$1")
+	txt = reMfmSearch.ReplaceAllString(txt, "$1")
+	txt = reMfmQuote.ReplaceAllString(txt, "")
+	txt = reMfmLineSpace.ReplaceAllString(txt, "\n")
+	txt = strings.TrimSpace(txt)
+	return
+}
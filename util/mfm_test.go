@@ -0,0 +1,56 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMfmToText(t *testing.T) {
+	cases := map[string]struct {
+		in  string
+		out string
+	}{
+		"plain": {
+			in:  "おはよう #misskey",
+			out: "おはよう #misskey",
+		},
+		"decorations": {
+			in:  "**bold** __also bold__ ~~gone~~ <i>italic</i> <small>tiny</small> <center>middle</center>",
+			out: "bold also bold gone italic tiny middle",
+		},
+		"nested functions": {
+			in:  "$[x2 $[spin.speed=2s,alternate **New**] release] $[fg.color=f00 red]",
+			out: "New release red",
+		},
+		"links": {
+			in:  "See ?[the docs](https://misskey-hub.net/docs) and [here](<https://example.com/a>) or https://example.com/b",
+			out: "See the docs and here or https://example.com/b",
+		},
+		"link inside function": {
+			in:  "$[x3 [big link](https://example.com)]",
+			out: "big link",
+		},
+		"code": {
+			in:  "run `go test`:\n```go\nfmt.Println(\"**hi**\")\n```",
+			out: "run go test:\nfmt.Println(\"hi\")",
+		},
+		"mentions and emoji kept": {
+			in:  "@alice@misskey.io :blobcat: \\(x^2\\)",
+			out: "@alice@misskey.io :blobcat: x^2",
+		},
+		"quote and search": {
+			in:  "> quoted line  \nmisskey Search",
+			out: "quoted line\nmisskey",
+		},
+		"unbalanced": {
+			in:  "$[x2 unclosed **bold",
+			out: "$[x2 unclosed **bold",
+		},
+		"empty": {},
+	}
+	for k, c := range cases {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, c.out, MfmToText(c.in))
+		})
+	}
+}